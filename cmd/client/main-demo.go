@@ -9,11 +9,21 @@ import (
 	"github.com/boloc/go-frame-server/cmd/client/route"
 	"github.com/boloc/go-frame-server/pkg/constant"
 	"github.com/boloc/go-frame-server/pkg/frame"
+	"github.com/boloc/go-frame-server/pkg/frame/admin"
+	"github.com/boloc/go-frame-server/pkg/frame/api"
+	"github.com/boloc/go-frame-server/pkg/frame/audit"
 	"github.com/boloc/go-frame-server/pkg/frame/components"
 	"github.com/boloc/go-frame-server/pkg/frame/config"
 	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+	"github.com/boloc/go-frame-server/pkg/frame/tracing"
 	"github.com/boloc/go-frame-server/pkg/logger"
+	"github.com/boloc/go-frame-server/pkg/monitor"
 	"github.com/boloc/go-frame-server/pkg/util"
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -29,22 +39,136 @@ func main() {
 	// fmt.Println("打印日志级别", level)
 
 	/* 日志组件 */
+	// 日志脱敏(日志、请求记录、访问日志共用全局脱敏引擎)
+	maskEnable := config.GetConfigValue("logs.mask.enable", true)
+	mask.SetDefault(mask.New(mask.WithExtraFields(conf.GetStringSlice("logs.mask.fields")...)))
 	// 注册日志组件
-	log := logger.NewLoggerComponent(
-		logger.WithLoggerLevel(conf.GetString("logs.log_level")),     // 设置日志级别
-		logger.WithLoggerStdout(conf.GetBool("logs.is_stdout")),      // 设置是否输出到控制台
-		logger.WithLoggerIsFile(conf.GetBool("logs.is_file")),        // 设置是否输出到文件
-		logger.WithLoggerFilename(conf.GetString("logs.file_name")),  // 设置文件名
-		logger.WithLoggerMaxSize(conf.GetInt("logs.max_size")),       // 设置文件最大大小
-		logger.WithLoggerMaxBackups(conf.GetInt("logs.max_backups")), // 设置文件最大备份数
-		logger.WithLoggerMaxAge(conf.GetInt("logs.max_age")),         // 设置文件最大保存时间
-		logger.WithLoggerCompress(conf.GetBool("logs.compress")),     // 设置是否压缩文件
-	)
-	log.Start()
+	bufferFlushInterval := config.GetConfigValue("logs.buffer.flush_interval", time.Second)
+	// 按级别采样规则
+	samplingRules := make(map[string]logger.SamplingRule)
+	for _, level := range []string{"debug", "info", "warn", "error"} {
+		if key := "logs.sampling.levels." + level; conf.GetViper().IsSet(key) {
+			samplingRules[level] = logger.SamplingRule{First: conf.GetInt(key + ".first"), Thereafter: conf.GetInt(key + ".thereafter")}
+		}
+	}
+	loggerOptions := []logger.LoggerOption{
+		logger.WithLoggerLevel(conf.GetString("logs.log_level")),                          // 设置日志级别
+		logger.WithLoggerStdout(conf.GetBool("logs.is_stdout")),                           // 设置是否输出到控制台
+		logger.WithLoggerIsFile(conf.GetBool("logs.is_file")),                             // 设置是否输出到文件
+		logger.WithLoggerFilename(conf.GetString("logs.file_name")),                       // 设置文件名
+		logger.WithLoggerMaxSize(conf.GetInt("logs.max_size")),                            // 设置文件最大大小
+		logger.WithLoggerMaxBackups(conf.GetInt("logs.max_backups")),                      // 设置文件最大备份数
+		logger.WithLoggerMaxAge(conf.GetInt("logs.max_age")),                              // 设置文件最大保存时间
+		logger.WithLoggerCompress(conf.GetBool("logs.compress")),                          // 设置是否压缩文件
+		logger.WithLoggerModuleLevels(conf.GetViper().GetStringMapString("logs.modules")), // 设置模块日志级别
+		logger.WithLoggerMask(maskEnable, nil),                                            // 设置日志脱敏
+		logger.WithLoggerBuffer(conf.GetInt("logs.buffer.size"), bufferFlushInterval),     // 设置缓冲写入
+		// 设置采样、相同错误去重和按调用位置限流
+		logger.WithLoggerSampling(config.GetConfigValue("logs.sampling.tick", time.Second), samplingRules),
+		logger.WithLoggerDedup(conf.GetStringTimeDuration("logs.sampling.dedup.window"), conf.GetString("logs.sampling.dedup.level")),
+		logger.WithLoggerCallerLimit(conf.GetInt("logs.sampling.caller_limit.limit"), config.GetConfigValue("logs.sampling.caller_limit.interval", time.Second), conf.GetString("logs.sampling.caller_limit.level")),
+	}
+	// 额外的日志输出(异步批量写入，队列满时丢弃)
+	sinkOptions := []logger.SinkOption{
+		logger.WithSinkBufferSize(config.GetConfigValue("logs.sinks.buffer_size", 4096)),                                                           // 队列长度
+		logger.WithSinkBatch(config.GetConfigValue("logs.sinks.batch_size", 500), config.GetConfigValue("logs.sinks.flush_interval", time.Second)), // 批量大小和刷新间隔
+	}
+	if url := conf.GetString("logs.sinks.http.url"); url != "" {
+		var httpOptions []logger.HTTPSinkOption
+		if token := conf.GetString("logs.sinks.http.token"); token != "" {
+			httpOptions = append(httpOptions, logger.WithHTTPSinkHeader("Authorization", "Bearer "+token))
+		}
+		loggerOptions = append(loggerOptions, logger.WithLoggerSink(logger.NewHTTPSink(url, httpOptions...), sinkOptions...))
+	}
+	if network := conf.GetString("logs.sinks.syslog.network"); network != "" {
+		tag := conf.GetString("logs.sinks.syslog.tag")
+		if tag == "" {
+			tag = conf.GetString("server.name")
+		}
+		syslogSink := logger.NewSyslogSink(network, conf.GetString("logs.sinks.syslog.address"), tag,
+			logger.WithSyslogFormat(config.GetConfigValue("logs.sinks.syslog.format", logger.SyslogFormatRFC5424)),
+		)
+		loggerOptions = append(loggerOptions, logger.WithLoggerSink(syslogSink, sinkOptions...))
+	}
+	// 写入ClickHouse(需先创建ClickHouse组件，见下方ClickHouse组件示例)
+	// loggerOptions = append(loggerOptions, logger.WithLoggerSink(components.NewClickHouseLogSink(clickhouseComponent, "app_logs"), sinkOptions...))
+	log := logger.NewLoggerComponent(loggerOptions...)
+	// 先启动以记录其他组件的启动日志，框架启动时不会重复启动
+	if err := log.Start(context.Background()); err != nil {
+		fmt.Println("Logger error", err)
+		os.Exit(1)
+	}
+	// 第一个注册，框架停止时最后停止，写出缓冲的日志
+	f.RegisterComponent(log)
+	// 配置文件修改后热更新日志级别
+	conf.OnChange(func(c *config.ConfigComponent) {
+		if err := logger.ApplyLevels(c.GetString("logs.log_level"), c.GetViper().GetStringMapString("logs.modules")); err != nil {
+			logger.Error("reload log level failed", zap.Error(err))
+		}
+	})
 	// 给框架设置日志记录器
 	f.SetLogger(log.GetLogger())
 	/* 日志组件 end */
 
+	/* 指标组件 */
+	// 独立的Registry，组件连接池、查询耗时等指标启动后注册到此
+	var metricsComponent *monitor.MetricsComponent
+	if conf.GetBool("prometheus.enable") {
+		metricsOptions := []monitor.MetricsOption{
+			monitor.WithMetricsNamespace(conf.GetString("prometheus.namespace")),                            // 设置指标名称前缀
+			monitor.WithMetricsService(conf.GetString("server.name"), conf.GetString("server.env")),         // 设置service、env标签
+			monitor.WithMetricsInterval(config.GetConfigValue("prometheus.interval", 15*time.Second)),       // 设置资源指标采集间隔
+			monitor.WithMetricsRuntimeCollectors(config.GetConfigValue("prometheus.runtime_metrics", true)), // 设置是否输出Go运行时和进程指标
+		}
+		// 推送到Pushgateway或remote write(定时任务等来不及被抓取的服务)
+		if conf.GetBool("prometheus.push.enable") {
+			metricsOptions = append(metricsOptions, monitor.WithMetricsPush(monitor.PushConfig{
+				Mode:      conf.GetString("prometheus.push.mode"),
+				URL:       conf.GetString("prometheus.push.url"),
+				Job:       conf.GetString("prometheus.push.job"),
+				Grouping:  conf.GetViper().GetStringMapString("prometheus.push.grouping"),
+				Interval:  conf.GetStringTimeDuration("prometheus.push.interval"),
+				Timeout:   conf.GetStringTimeDuration("prometheus.push.timeout"),
+				Retries:   conf.GetInt("prometheus.push.retries"),
+				RetryWait: conf.GetStringTimeDuration("prometheus.push.retry_wait"),
+				Username:  conf.GetString("prometheus.push.username"),
+				Password:  conf.GetString("prometheus.push.password"),
+				Headers:   conf.GetViper().GetStringMapString("prometheus.push.headers"),
+			}))
+		}
+		metricsComponent = monitor.NewMetricsComponent(metricsOptions...)
+		// 在数据库等组件之前注册
+		f.RegisterComponent(metricsComponent)
+		// 停止前推送最终的指标(此时其他组件还未停止)
+		f.BeforeStop(metricsComponent.Push)
+	}
+	/* 指标组件 end */
+
+	/* 链路追踪组件 */
+	// 数据库、Redis的埋点(未开启时为空)
+	var (
+		gormPlugins []gorm.Plugin
+		redisHooks  []redis.Hook
+	)
+	tracingEnable := conf.GetBool("tracing.enable")
+	if tracingEnable {
+		tracingComponent := tracing.NewTracingComponent(
+			tracing.WithTracingServiceName(conf.GetString("server.name")),                                                      // 设置服务名称
+			tracing.WithTracingEnvironment(conf.GetString("server.env")),                                                       // 设置部署环境
+			tracing.WithTracingExporter(conf.GetString("tracing.exporter")),                                                    // 设置导出方式
+			tracing.WithTracingOTLP(conf.GetString("tracing.endpoint"), conf.GetViper().GetStringMapString("tracing.headers")), // 设置OTLP地址
+			tracing.WithTracingFile(conf.GetString("tracing.file_path")),                                                       // 设置导出文件
+			tracing.WithTracingSampleRatio(config.GetConfigValue("tracing.sample_ratio", 1.0)),                                 // 设置采样率
+		)
+		// 在日志组件之后、其他组件之前注册，停止时最后导出剩余的span
+		f.RegisterComponent(tracingComponent)
+		gormPlugins = append(gormPlugins, tracing.GormPlugin())
+		redisHooks = append(redisHooks, tracing.RedisHook())
+		// 外部请求注入traceparent
+		tracing.InstrumentResty(util.GetClient())
+	}
+	/* 链路追踪组件 end */
+
 	/******************** 数据库组件 start ********************/
 	// 组装主库dsn
 	dataBaseName := constant.DefaultDBName
@@ -70,6 +194,10 @@ func main() {
 			ConnMaxLifetime: conf.GetStringTimeDuration(dbMap["conn_max_lifetime"].(string)),
 			Prefix:          dbMap["prefix"].(string),
 			LogLevel:        components.GormLogLevelForEnv(conf.GetString("server.env")),
+			SlowThreshold:   conf.GetStringTimeDuration(dbMapStr + ".slow_threshold"), // 慢查询阈值
+			RedactParams:    conf.GetBool(dbMapStr + ".redact_params"),                // 日志中不输出SQL参数
+			Plugins:         gormPlugins,                                              // GORM插件(链路追踪)
+			SlowObserver:    monitor.ObserveSlowQuery,                                 // 慢查询计数
 		},
 		true, // 是否默认
 	)
@@ -101,11 +229,13 @@ func main() {
 	/******************** Redis组件 start ********************/
 	// 注册Redis单机组件
 	redisComponent := components.NewRedisComponent(
+		components.WithRedisName("single"),                                           // 设置实例名称(组件名称redis/single，指标component标签)
 		components.WithRedisAddr(conf.GetString("redis.single.addr")),                // 设置Redis地址
 		components.WithRedisPassword(conf.GetString("redis.single.password")),        // 设置Redis密码
 		components.WithRedisDB(conf.GetInt("redis.single.db")),                       // 设置Redis数据库
 		components.WithRedisPoolSize(conf.GetInt("redis.single.pool_size")),          // 设置Redis连接池大小
 		components.WithRedisMinIdleConns(conf.GetInt("redis.single.min_idle_conns")), // 设置Redis最小空闲连接数
+		components.WithRedisHooks(redisHooks...),                                     // 设置命令钩子(链路追踪)
 	)
 	f.RegisterComponent(redisComponent)
 
@@ -144,6 +274,7 @@ func main() {
 	// 	components.WithClickHouseCompression(clickhouse.CompressionLZ4),                                              // 设置压缩方式
 	// 	components.WithClickHouseDebug(conf.GetBool("clickhouse.default.debug")),                                     // 设置调试
 	// 	components.WithClickHouseProtocol(conf.GetString("clickhouse.default.protocol")),                             // 设置协议
+	// 	components.WithClickHouseConnWrapper(tracing.WrapClickHouse),                                                 // 设置链路追踪
 	// )
 
 	// f.RegisterComponent(clickhouseComponent)
@@ -164,25 +295,148 @@ func main() {
 			MaxOpenConns:    conf.GetInt("clickhouse.default.max_open_conns"),                              // 最大连接数
 			ConnMaxLifetime: conf.GetStringTimeDuration("clickhouse.default.conn_max_lifetime"),            // 连接最大生命周期
 			LogLevel:        components.GormLogLevelForEnv(conf.GetString("clickhouse.default.log_level")), // 日志等级
+			SlowThreshold:   conf.GetStringTimeDuration("clickhouse.default.slow_threshold"),               // 慢查询阈值
+			Plugins:         gormPlugins,                                                                   // GORM插件(链路追踪)
 		},
 		true, // 设为默认实例
 	)
 	f.RegisterComponent(clickhouseGorm)
 	/******************** ClickHouse组件 end ********************/
 
+	/******************** 审计组件 start ********************/
+	var auditor *audit.Auditor
+	if conf.GetBool("audit.enable") {
+		// 写入MySQL主库，也可以使用audit.NewClickHouseStore(clickhouseComponent, "audit_logs")
+		auditStore := audit.NewMySQLStore(mysqlComponent, config.GetConfigValue("audit.table", "audit_logs"))
+		auditor = audit.New(auditStore,
+			audit.WithChain(conf.GetString("audit.chain")),            // 设置链名称
+			audit.WithCreateTable(conf.GetBool("audit.create_table")), // 设置启动时建表
+			audit.WithMask(maskEnable, nil),                           // 设置数据脱敏
+		)
+		// 记录指定表的新增、修改、删除，模型也可以实现audit.Auditable
+		if err := mysqlComponent.Use(auditor.GormPlugin(audit.WithGormTables(conf.GetStringSlice("audit.tables")...))); err != nil {
+			fmt.Println("Audit error", err)
+			os.Exit(1)
+		}
+		// 在MySQL组件之后注册
+		f.RegisterComponent(auditor)
+	}
+	/******************** 审计组件 end ********************/
+
 	/******************** Gin组件 start ********************/
 	// 注册Gin组件
-	ginComponent := components.NewGinComponent(
-		components.WithGinPort(conf.GetString("server.port")),                          // 设置Gin端口
-		components.WithGinMode(components.GinModeForEnv(conf.GetString("server.env"))), // 设置Gin模式
-		components.WithGinShutdownTimeout(5*time.Second),                               // 设置5秒关闭超时
-		components.WithGinRouter(route.RegisterRoutes),                                 // 注册路由
-	)
+	ginOptions := []components.GinOption{
+		components.WithGinPort(conf.GetString("server.port")),                                                              // 设置Gin端口
+		components.WithGinMode(components.GinModeForEnv(conf.GetString("server.env"))),                                     // 设置Gin模式
+		components.WithGinShutdownTimeout(config.GetConfigValue("server.shutdown_timeout", 5*time.Second)),                 // 设置关闭超时
+		components.WithGinReadTimeout(config.GetConfigValue("server.read_timeout", 30*time.Second)),                        // 设置读取超时
+		components.WithGinReadHeaderTimeout(config.GetConfigValue("server.read_header_timeout", 10*time.Second)),           // 设置读取请求头超时
+		components.WithGinWriteTimeout(config.GetConfigValue("server.write_timeout", 30*time.Second)),                      // 设置写响应超时
+		components.WithGinIdleTimeout(config.GetConfigValue("server.idle_timeout", 120*time.Second)),                       // 设置空闲连接超时
+		components.WithGinMaxHeaderBytes(config.GetConfigValue("server.max_header_bytes", 1<<20)),                          // 设置请求头最大字节数
+		components.WithGinPreStopDelay(conf.GetStringTimeDuration("server.pre_stop_delay")),                                // 设置停止前等待时间
+		components.WithGinRouter(route.RegisterRoutes),                                                                     // 注册路由
+		components.WithGinVersionHeader(conf.GetString("server.version_header"), conf.GetString("server.default_version")), // 设置基于请求头的API版本
+		components.WithGinPrintRoutes(config.GetConfigValue("server.print_routes", true)),                                  // 设置是否打印路由表
+		components.WithGinH2C(conf.GetBool("server.h2c")),                                                                  // 设置是否开启h2c
+		components.WithGinTrustedProxies(conf.GetStringSlice("server.trusted_proxies")...),                                 // 设置受信任代理
+		components.WithGinRemoteIPHeaders(conf.GetStringSlice("server.remote_ip_headers")...),                              // 设置获取客户端IP的请求头
+		components.WithGinTrustUnixSocket(conf.GetBool("server.trust_unix_socket")),                                        // 设置是否信任unix socket连接的请求头
+	}
+	// 开启TLS
+	if conf.GetBool("server.tls.enable") {
+		ginOptions = append(ginOptions,
+			components.WithGinTLS(conf.GetString("server.tls.cert_file"), conf.GetString("server.tls.key_file")),                     // 设置证书
+			components.WithGinClientCA(conf.GetString("server.tls.client_ca_file"), conf.GetBool("server.tls.client_cert_required")), // 设置客户端CA(mTLS)
+		)
+		// HTTP跳转HTTPS
+		if redirectPort := conf.GetString("server.tls.redirect_port"); redirectPort != "" {
+			ginOptions = append(ginOptions, components.WithGinListeners(
+				components.GinListener{Address: ":" + conf.GetString("server.port"), TLS: true},
+				components.GinListener{Address: ":" + redirectPort, RedirectHTTPS: true},
+			))
+		}
+	}
+	// OpenAPI文档
+	if conf.GetBool("server.openapi.enable") {
+		api.DefaultSpec().SetInfo(api.Info{
+			Title:   conf.GetString("server.openapi.title"),
+			Version: conf.GetString("server.openapi.version"),
+		})
+		ginOptions = append(ginOptions, components.WithGinRouteModules(
+			api.DocsModule(api.WithSwaggerUI(conf.GetString("server.openapi.swagger_ui"))),
+		))
+	}
+	// WebSocket(通过Redis发布订阅跨实例广播)
+	var websocketComponent *components.WebSocketComponent
+	if conf.GetBool("websocket.enable") {
+		websocketComponent = components.NewWebSocketComponent(
+			components.WithWebSocketPath(config.GetConfigValue("websocket.path", "/ws")),                                                                                         // 设置挂载路径
+			components.WithWebSocketAllowAnonymous(conf.GetBool("websocket.allow_anonymous")),                                                                                    // 设置是否允许未认证连接
+			components.WithWebSocketMaxMessageSize(int64(config.GetConfigValue("websocket.max_message_size", 64<<10))),                                                           // 设置单条消息最大字节数
+			components.WithWebSocketSendBuffer(config.GetConfigValue("websocket.send_buffer", 256), conf.GetBool("websocket.drop_on_full")),                                      // 设置发送队列
+			components.WithWebSocketHeartbeat(config.GetConfigValue("websocket.ping_interval", 30*time.Second), config.GetConfigValue("websocket.pong_timeout", 60*time.Second)), // 设置心跳
+			components.WithWebSocketRedis(redisComponent, conf.GetString("websocket.redis_channel")),                                                                             // 设置跨实例广播
+			components.WithWebSocketRoomAuthorizer(func(client *components.WebSocketClient, room string) bool { return room == "user:"+client.Identity }),                        // 设置房间授权，只允许订阅自己的房间
+		)
+		ginOptions = append(ginOptions, components.WithGinRouteModules(websocketComponent.Module()))
+	}
+	// 指标接口
+	if metricsComponent != nil {
+		ginOptions = append(ginOptions, components.WithGinRouteModules(
+			metricsComponent.Module(config.GetConfigValue("prometheus.path", "/metrics"), monitor.PrometheusAuth()),
+		))
+	}
+	ginComponent := components.NewGinComponent(ginOptions...)
 	// 添加全局中间件
-	ginComponent.Use(middleware.ContextMiddleware())
+	if conf.GetBool("server.compression.enable") {
+		// 响应压缩(需在最前面注册)
+		ginComponent.Use(middleware.CompressMiddleware(
+			middleware.WithCompressMinSize(config.GetConfigValue("server.compression.min_size", 1024)), // 最小压缩字节数
+			middleware.WithCompressLevel(conf.GetInt("server.compression.level")),                      // 压缩等级，0为默认
+			middleware.WithCompressObserver(monitor.ObserveCompression),                                // 压缩统计
+		))
+	}
+	if tracingEnable {
+		// 链路追踪(需在ContextMiddleware之前)
+		ginComponent.Use(tracing.Middleware(tracing.WithMiddlewareSkipPaths(components.DefaultReadinessPath, components.DefaultLivenessPath)))
+	}
+	ginComponent.Use(
+		middleware.BodyLimitMiddleware(int64(config.GetConfigValue("server.max_body_size", 10<<20))),  // 请求体大小限制(需在ContextMiddleware之前)
+		middleware.TimeoutMiddleware(config.GetConfigValue("server.request_timeout", 10*time.Second)), // 请求超时
+		middleware.ContextMiddleware(
+			middleware.WithCaptureMaxBytes(int64(config.GetConfigValue("server.capture_body.max_bytes", middleware.DefaultCaptureMaxBytes))),         // 最多记录的请求体字节数
+			middleware.WithCaptureContentTypes(config.GetConfigValue("server.capture_body.content_types", middleware.DefaultCaptureContentTypes)...), // 需要记录请求体的Content-Type
+			middleware.WithCaptureMask(maskEnable, nil), // 记录的请求参数脱敏
+		),
+	)
 	f.RegisterComponent(ginComponent)
+	// WebSocket在Gin之后注册，停止时先向连接发送关闭帧
+	if websocketComponent != nil {
+		f.RegisterComponent(websocketComponent)
+	}
 	/******************** Gin组件 end ********************/
 
+	/******************** 管理组件 start ********************/
+	// 独立端口提供pprof、GC、构建信息、组件健康状态和日志级别切换，SIGUSR1写快照到日志目录
+	if conf.GetBool("admin.enable") {
+		dumpDir := conf.GetString("admin.dump_dir")
+		if dumpDir == "" {
+			dumpDir = log.Dir()
+		}
+		adminComponent := admin.NewAdminComponent(f,
+			admin.WithAdminAddress(config.GetConfigValue("admin.address", "127.0.0.1:6060")),        // 设置监听地址
+			admin.WithAdminAuth(conf.GetString("admin.username"), conf.GetString("admin.password")), // 设置基本认证
+			admin.WithAdminDumpDir(dumpDir), // 设置快照目录
+		)
+		// 审计查询接口 /audit、/audit/verify、/audit/stats
+		if auditor != nil {
+			auditor.RegisterRoutes(adminComponent.Engine().Group("/audit"))
+		}
+		f.RegisterComponent(adminComponent)
+	}
+	/******************** 管理组件 end ********************/
+
 	// 注册启动后的操作
 	f.AfterStart(func(ctx context.Context) error {
 		// 在这里执行启动后的操作
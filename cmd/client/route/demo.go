@@ -0,0 +1,30 @@
+package route
+
+import (
+	"github.com/boloc/go-frame-server/pkg/frame/router"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 路由模块示例，业务包可各自定义模块，在init中通过router.Register注册，
+// 或在创建Gin组件时通过components.WithGinRouteModules传入
+func init() {
+	router.Register(
+		router.NewModule("demo", "/demo", registerDemoV1, router.WithVersion("v1")),
+		router.NewModule("demo", "/demo", registerDemoV2, router.WithVersion("v2")),
+	)
+}
+
+// registerDemoV1 v1版本路由 -> GET /v1/demo/ping
+func registerDemoV1(group *gin.RouterGroup) {
+	group.GET("/ping", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "pong", "version": "v1"})
+	})
+}
+
+// registerDemoV2 v2版本路由 -> GET /v2/demo/ping
+func registerDemoV2(group *gin.RouterGroup) {
+	group.GET("/ping", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "pong", "version": "v2"})
+	})
+}
@@ -0,0 +1,77 @@
+package route
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/boloc/go-frame-server/pkg/frame/api"
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/pagination"
+	"github.com/boloc/go-frame-server/pkg/frame/router"
+	"github.com/boloc/go-frame-server/pkg/throw"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 类型化接口示例，请求参数自动绑定校验，并生成OpenAPI文档
+func init() {
+	router.Register(router.NewModule("order", "/orders", registerOrder, router.WithVersion("v1")))
+}
+
+// CreateOrderRequest 创建订单请求
+type CreateOrderRequest struct {
+	Name   string `json:"name" binding:"required,max=64" description:"订单名称"`
+	Amount int64  `json:"amount" binding:"required,min=1" description:"金额(分)"`
+}
+
+// ListOrderRequest 订单列表请求
+type ListOrderRequest struct {
+	pagination.PageRequest
+	Keyword string `form:"keyword" description:"关键词"`
+}
+
+// GetOrderRequest 订单详情请求
+type GetOrderRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// Order 订单
+type Order struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Amount int64  `json:"amount"`
+}
+
+// registerOrder 注册订单路由
+func registerOrder(group *gin.RouterGroup) {
+	api.Handle(group, http.MethodPost, "", createOrder, api.WithSummary("创建订单"))
+	api.Handle(group, http.MethodGet, "", listOrder, api.WithSummary("订单列表"))
+	api.Handle(group, http.MethodGet, "/:id", getOrder, api.WithSummary("订单详情"), api.WithErrorCodes(enum.NOT_FOUND))
+	// 不需要生成文档时，也可以直接在gin路由上使用适配器
+	group.DELETE("/:id", api.WrapAction(deleteOrder))
+}
+
+func createOrder(ctx context.Context, req CreateOrderRequest) (*Order, error) {
+	return &Order{ID: 1, Name: req.Name, Amount: req.Amount}, nil
+}
+
+func listOrder(ctx context.Context, req ListOrderRequest) (*pagination.PageResponse, error) {
+	page, pageSize := req.GetPageInfo()
+	return pagination.NewPageResponse([]*Order{}, 0, page, pageSize), nil
+}
+
+func getOrder(ctx context.Context, req GetOrderRequest) (*Order, error) {
+	if req.ID != 1 {
+		return nil, throw.ApiCustomException(enum.NOT_FOUND, "订单不存在")
+	}
+	return &Order{ID: req.ID, Name: "demo", Amount: 100}, nil
+}
+
+func deleteOrder(ctx context.Context, req GetOrderRequest) error {
+	// RequestContext可以直接从ctx中获取
+	if rc := content.FromContext(ctx); rc != nil {
+		rc.Set("deleted_order_id", req.ID)
+	}
+	return nil
+}
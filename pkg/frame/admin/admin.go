@@ -0,0 +1,395 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame"
+	"github.com/boloc/go-frame-server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 构建信息，编译时通过 -ldflags "-X github.com/boloc/go-frame-server/pkg/frame/admin.Version=v1.0.0" 注入
+var (
+	Version   = ""
+	Commit    = ""
+	BuildTime = ""
+)
+
+// AdminOption 定义管理组件选项函数类型
+type AdminOption func(*AdminComponent)
+
+// AdminComponent 管理组件
+// 在独立端口提供pprof、goroutine、GC、构建信息、组件健康状态和日志级别(全局及模块)切换，
+// 收到SIGUSR1时将堆和goroutine快照写入日志目录
+type AdminComponent struct {
+	frame  *frame.Frame
+	config *AdminConfig
+	engine *gin.Engine
+	server *http.Server
+	// 快照信号
+	stopSignal func()
+	dumpMu     sync.Mutex
+}
+
+// AdminConfig 管理组件配置
+type AdminConfig struct {
+	Address    string // 监听地址，默认只监听本机
+	Username   string // 基本认证用户名
+	Password   string // 基本认证密码，为空则不认证，只允许监听本机地址
+	DumpDir    string // 快照目录，一般与日志文件同目录
+	DumpSignal bool   // 是否监听SIGUSR1写快照
+}
+
+// WithAdminAddress 设置监听地址，默认127.0.0.1:6060，监听非本机地址时必须设置认证密码
+func WithAdminAddress(address string) AdminOption {
+	return func(a *AdminComponent) {
+		a.config.Address = address
+	}
+}
+
+// WithAdminAuth 设置基本认证账号
+func WithAdminAuth(username, password string) AdminOption {
+	return func(a *AdminComponent) {
+		a.config.Username = username
+		a.config.Password = password
+	}
+}
+
+// WithAdminDumpDir 设置快照目录，例: WithAdminDumpDir(log.Dir())
+func WithAdminDumpDir(dir string) AdminOption {
+	return func(a *AdminComponent) {
+		a.config.DumpDir = dir
+	}
+}
+
+// WithAdminDumpSignal 设置是否监听SIGUSR1写快照，默认开启
+func WithAdminDumpSignal(enable bool) AdminOption {
+	return func(a *AdminComponent) {
+		a.config.DumpSignal = enable
+	}
+}
+
+// NewAdminComponent 创建管理组件，f用于获取已注册组件和健康状态
+func NewAdminComponent(f *frame.Frame, opts ...AdminOption) *AdminComponent {
+	a := &AdminComponent{
+		frame: f,
+		config: &AdminConfig{
+			Address:    "127.0.0.1:6060",
+			Username:   "admin",
+			DumpDir:    "./logs",
+			DumpSignal: true,
+		},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.engine = gin.New()
+	a.engine.Use(gin.Recovery())
+	if a.config.Password != "" {
+		a.engine.Use(gin.BasicAuth(gin.Accounts{a.config.Username: a.config.Password}))
+	}
+	a.registerRoutes()
+	return a
+}
+
+// Name 组件名称
+func (a *AdminComponent) Name() string {
+	return "admin"
+}
+
+// Handler 管理接口处理器
+func (a *AdminComponent) Handler() http.Handler {
+	return a.engine
+}
+
+// Engine 管理接口路由，用于注册自定义管理接口(与内置接口共用认证)，需要在Start前注册
+func (a *AdminComponent) Engine() *gin.Engine {
+	return a.engine
+}
+
+// Start 启动管理组件，未设置认证密码且监听非本机地址时启动失败
+func (a *AdminComponent) Start(ctx context.Context) error {
+	if a.config.Password == "" {
+		if !isLoopback(a.config.Address) {
+			return fmt.Errorf("admin server - basic auth password is required to listen on %s, or listen on 127.0.0.1", a.config.Address)
+		}
+		fmt.Printf("admin server - basic auth is disabled, listening on loopback %s only\n", a.config.Address)
+	}
+	listener, err := net.Listen("tcp", a.config.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen admin address %s: %w", a.config.Address, err)
+	}
+	a.server = &http.Server{
+		Handler:           a.engine,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := a.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("admin server error: %v\n", err)
+		}
+	}()
+	fmt.Printf("admin server start - %s\n", listener.Addr())
+
+	if a.config.DumpSignal {
+		a.stopSignal = a.notifyDump()
+	}
+	return nil
+}
+
+// Stop 停止管理组件
+func (a *AdminComponent) Stop(ctx context.Context) error {
+	if a.stopSignal != nil {
+		a.stopSignal()
+		a.stopSignal = nil
+	}
+	if a.server == nil {
+		return nil
+	}
+	fmt.Printf("admin server stop - %s\n", a.config.Address)
+	return a.server.Shutdown(ctx)
+}
+
+// registerRoutes 注册管理接口
+func (a *AdminComponent) registerRoutes() {
+	// pprof
+	debugGroup := a.engine.Group("/debug")
+	debugGroup.GET("/pprof/", gin.WrapF(pprof.Index))
+	debugGroup.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	debugGroup.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	debugGroup.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debugGroup.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debugGroup.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		debugGroup.GET("/pprof/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+
+	// 运行时信息
+	debugGroup.GET("/goroutines", a.goroutines)
+	debugGroup.GET("/gc", a.gcStats)
+	debugGroup.POST("/gc", a.runGC)
+	debugGroup.GET("/build", a.buildInfo)
+	debugGroup.POST("/dump", a.dump)
+
+	// 组件状态
+	a.engine.GET("/components", a.components)
+	a.engine.GET("/health", a.health)
+
+	// 日志级别: GET查询，PUT {"level":"debug"}修改
+	a.engine.GET("/log/level", gin.WrapH(logger.Level()))
+	a.engine.PUT("/log/level", gin.WrapH(logger.Level()))
+	a.engine.GET("/log/levels", a.logLevels)
+	a.engine.PUT("/log/level/:module", a.setModuleLevel)
+	a.engine.DELETE("/log/level/:module", a.resetModuleLevel)
+	a.engine.GET("/log/sinks", a.logSinks)
+}
+
+// logLevels 输出全局和模块日志级别
+func (a *AdminComponent) logLevels(c *gin.Context) {
+	level, modules := logger.Levels()
+	c.JSON(http.StatusOK, gin.H{"level": level, "modules": modules})
+}
+
+// setModuleLevel 设置模块日志级别，请求体 {"level":"debug"}
+func (a *AdminComponent) setModuleLevel(c *gin.Context) {
+	var req struct {
+		Level string `json:"level" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	module := c.Param("module")
+	if err := logger.SetModuleLevel(module, req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"module": module, "level": logger.ModuleLevel(module).String()})
+}
+
+// resetModuleLevel 模块日志级别恢复跟随全局级别
+func (a *AdminComponent) resetModuleLevel(c *gin.Context) {
+	logger.ResetModuleLevel(c.Param("module"))
+	c.Status(http.StatusNoContent)
+}
+
+// logSinks 输出日志Sink的写入、失败和丢弃数
+func (a *AdminComponent) logSinks(c *gin.Context) {
+	c.JSON(http.StatusOK, logger.Sinks())
+}
+
+// goroutines 输出所有goroutine的调用栈
+func (a *AdminComponent) goroutines(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	_ = rpprof.Lookup("goroutine").WriteTo(c.Writer, 2)
+}
+
+// gcStats 输出内存和GC统计
+func (a *AdminComponent) gcStats(c *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	var gcStats debug.GCStats
+	gcStats.PauseQuantiles = make([]time.Duration, 5)
+	debug.ReadGCStats(&gcStats)
+
+	c.JSON(http.StatusOK, gin.H{
+		"goroutines":      runtime.NumGoroutine(),
+		"heap_alloc":      memStats.HeapAlloc,
+		"heap_sys":        memStats.HeapSys,
+		"heap_idle":       memStats.HeapIdle,
+		"heap_inuse":      memStats.HeapInuse,
+		"heap_objects":    memStats.HeapObjects,
+		"stack_inuse":     memStats.StackInuse,
+		"sys":             memStats.Sys,
+		"total_alloc":     memStats.TotalAlloc,
+		"next_gc":         memStats.NextGC,
+		"num_gc":          gcStats.NumGC,
+		"last_gc":         gcStats.LastGC,
+		"pause_total":     gcStats.PauseTotal.String(),
+		"pause_quantiles": durationStrings(gcStats.PauseQuantiles), // 最小、25%、50%、75%、最大
+		"gc_cpu_fraction": memStats.GCCPUFraction,
+		"gomaxprocs":      runtime.GOMAXPROCS(0),
+		"memory_limit":    debug.SetMemoryLimit(-1),
+	})
+}
+
+// runGC 手动触发GC并归还内存给操作系统
+func (a *AdminComponent) runGC(c *gin.Context) {
+	start := time.Now()
+	debug.FreeOSMemory()
+	c.JSON(http.StatusOK, gin.H{"duration": time.Since(start).String()})
+}
+
+// buildInfo 输出构建信息
+func (a *AdminComponent) buildInfo(c *gin.Context) {
+	result := gin.H{
+		"version":    Version,
+		"commit":     Commit,
+		"build_time": BuildTime,
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		settings := make(map[string]string, len(info.Settings))
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+		deps := make(map[string]string, len(info.Deps))
+		for _, dep := range info.Deps {
+			deps[dep.Path] = dep.Version
+		}
+		result["path"] = info.Path
+		result["main"] = info.Main.Version
+		result["settings"] = settings
+		result["deps"] = deps
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// components 输出已注册组件及健康状态
+func (a *AdminComponent) components(c *gin.Context) {
+	c.JSON(http.StatusOK, a.frame.Health(c.Request.Context()))
+}
+
+// health 所有组件健康时返回200，否则返回503
+func (a *AdminComponent) health(c *gin.Context) {
+	statuses := a.frame.Health(c.Request.Context())
+	status := http.StatusOK
+	for _, s := range statuses {
+		if !s.Healthy {
+			status = http.StatusServiceUnavailable
+			break
+		}
+	}
+	c.JSON(status, statuses)
+}
+
+// dump 写入堆和goroutine快照
+func (a *AdminComponent) dump(c *gin.Context) {
+	files, err := a.WriteDump()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "files": files})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
+// WriteDump 将堆和goroutine快照写入快照目录，返回写入的文件
+func (a *AdminComponent) WriteDump() ([]string, error) {
+	a.dumpMu.Lock()
+	defer a.dumpMu.Unlock()
+
+	if err := os.MkdirAll(a.config.DumpDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建快照目录失败: %w", err)
+	}
+	suffix := time.Now().Format("20060102-150405.000")
+	var files []string
+	// heap使用pprof二进制格式，goroutine使用可读文本
+	for _, profile := range []struct {
+		name  string
+		file  string
+		debug int
+	}{
+		{"heap", "heap-" + suffix + ".pprof", 0},
+		{"goroutine", "goroutine-" + suffix + ".txt", 2},
+	} {
+		filename := filepath.Join(a.config.DumpDir, profile.file)
+		if err := writeProfile(profile.name, filename, profile.debug); err != nil {
+			return files, err
+		}
+		files = append(files, filename)
+	}
+	return files, nil
+}
+
+// writeProfile 写入profile文件
+func writeProfile(name, filename string, debugLevel int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建快照文件失败: %w", err)
+	}
+	if name == "heap" {
+		runtime.GC() // 获取最新的堆统计
+	}
+	if err := rpprof.Lookup(name).WriteTo(file, debugLevel); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("写入%s快照失败: %w", name, err)
+	}
+	return file.Close()
+}
+
+// isLoopback 监听地址是否只能本机访问，主机为空(监听所有网卡)不是本机地址
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// durationStrings 转换为可读字符串
+func durationStrings(durations []time.Duration) []string {
+	result := make([]string, len(durations))
+	for i, d := range durations {
+		result[i] = d.String()
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package admin
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyDump 监听SIGUSR1写快照，返回停止监听函数
+func (a *AdminComponent) notifyDump() func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				files, err := a.WriteDump()
+				if err != nil {
+					fmt.Printf("admin dump error: %v\n", err)
+					continue
+				}
+				fmt.Printf("admin dump - %v\n", files)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package admin
+
+// notifyDump windows不支持SIGUSR1，可通过 POST /debug/dump 写快照
+func (a *AdminComponent) notifyDump() func() {
+	return func() {}
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/realip"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ActionFunc 无返回数据的类型化处理函数
+type ActionFunc[Req any] func(ctx context.Context, req Req) error
+
+// Wrap 将类型化处理函数转换为gin.HandlerFunc，可直接用于gin路由
+// 1. 按 查询参数(form) -> 请求体(json/form) -> 请求头(header) -> 路径参数(uri) 的顺序绑定到同一个结构体并校验
+// 2. 绑定或校验失败返回 throw.ValidationException
+// 3. 调用fn，ctx中可通过content.FromContext(ctx)获取RequestContext
+// 4. 成功以统一响应结构返回，失败按throw异常返回对应业务码
+// 例: r.POST("/orders/:id", api.Wrap(updateOrder))
+func Wrap[Req, Resp any](fn HandlerFunc[Req, Resp]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := Bind[Req](c)
+		if err != nil {
+			response.Error(c, throw.ValidationException(err))
+			return
+		}
+
+		resp, err := fn(requestContext(c), req)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		response.Success(c, resp)
+	}
+}
+
+// WrapAction 将无返回数据的处理函数转换为gin.HandlerFunc，成功时data为null
+func WrapAction[Req any](fn ActionFunc[Req]) gin.HandlerFunc {
+	return Wrap(func(ctx context.Context, req Req) (any, error) {
+		return nil, fn(ctx, req)
+	})
+}
+
+// Bind 绑定请求参数到T并校验，T可以是结构体或结构体指针
+func Bind[T any](c *gin.Context) (T, error) {
+	var req T
+	target := reflect.ValueOf(&req)
+	// 指针类型需要先初始化
+	if t := reflect.TypeOf(req); t != nil && t.Kind() == reflect.Pointer {
+		target.Elem().Set(reflect.New(t.Elem()))
+		target = target.Elem()
+	}
+	if reflect.Indirect(target).Kind() != reflect.Struct {
+		return req, nil
+	}
+
+	err := bind(c, target.Interface())
+	return req, err
+}
+
+// bind 绑定请求参数并校验
+func bind(c *gin.Context, obj any) error {
+	// 查询参数
+	if err := binding.MapFormWithTag(obj, c.Request.URL.Query(), "form"); err != nil {
+		return err
+	}
+
+	// 请求体
+	if methodHasBody(c.Request.Method) && c.Request.Body != nil && c.Request.Body != http.NoBody {
+		switch c.ContentType() {
+		case binding.MIMEMultipartPOSTForm:
+			if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+				return err
+			}
+			if err := binding.MapFormWithTag(obj, c.Request.MultipartForm.Value, "form"); err != nil {
+				return err
+			}
+		case binding.MIMEPOSTForm:
+			if err := c.Request.ParseForm(); err != nil {
+				return err
+			}
+			if err := binding.MapFormWithTag(obj, c.Request.PostForm, "form"); err != nil {
+				return err
+			}
+		default:
+			decoder := json.NewDecoder(c.Request.Body)
+			if binding.EnableDecoderUseNumber {
+				decoder.UseNumber()
+			}
+			if err := decoder.Decode(obj); err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+		}
+	}
+
+	// 请求头，标签名不区分大小写；只绑定声明了header标签的字段
+	if len(c.Request.Header) > 0 {
+		headers := make(map[string][]string, len(c.Request.Header))
+		for key, values := range c.Request.Header {
+			headers[strings.ToLower(key)] = values
+		}
+		if err := binding.MapFormWithTag(obj, taggedValues(obj, "header", headers, strings.ToLower), "header"); err != nil {
+			return err
+		}
+	}
+
+	// 路径参数，只绑定声明了uri标签的字段
+	if len(c.Params) > 0 {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(obj, taggedValues(obj, "uri", params, nil), "uri"); err != nil {
+			return err
+		}
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// taggedValues 只保留结构体中显式声明了tag标签的字段对应的值，
+// 未声明标签的字段按字段名取值，不能让请求头、路径参数覆盖请求体绑定的字段；normalize为空时按原样查找
+func taggedValues(obj any, tag string, values map[string][]string, normalize func(string) string) map[string][]string {
+	tagged := make(map[string][]string)
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && ft.Kind() == reflect.Struct {
+				walk(ft)
+				continue
+			}
+			name := tagName(f, tag)
+			if name == "" {
+				continue
+			}
+			key := name
+			if normalize != nil {
+				key = normalize(name)
+			}
+			if v, ok := values[key]; ok {
+				tagged[name] = v
+			}
+		}
+	}
+	if t.Kind() == reflect.Struct {
+		walk(t)
+	}
+	return tagged
+}
+
+// requestContext 获取处理函数使用的ctx，保证其中包含RequestContext
+// 未使用ContextMiddleware时创建一个只包含基础信息的RequestContext
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if rc := content.FromContext(ctx); rc != nil {
+		rc.GinContext = c
+		return ctx
+	}
+
+	rc := &content.RequestContext{
+		GinContext: c,
+		ClientIP:   realip.FromGin(c),
+		Peer:       content.NewPeerIdentity(c.Request.TLS),
+		CustomData: make(map[string]any),
+	}
+	ctx = content.NewContext(ctx, rc)
+	c.Request = c.Request.WithContext(ctx)
+	return ctx
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/boloc/go-frame-server/pkg/frame/router"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsOption 定义文档路由选项函数类型
+type DocsOption func(*docsConfig)
+
+// docsConfig 文档路由配置
+type docsConfig struct {
+	specPath  string // OpenAPI文档路径
+	swaggerUI string // Swagger UI路径，为空则不开启
+	spec      *Spec
+}
+
+// WithSpecPath 设置OpenAPI文档路径，默认 /openapi.json
+func WithSpecPath(specPath string) DocsOption {
+	return func(c *docsConfig) {
+		c.specPath = specPath
+	}
+}
+
+// WithSwaggerUI 开启Swagger UI，如 /swagger
+func WithSwaggerUI(uiPath string) DocsOption {
+	return func(c *docsConfig) {
+		c.swaggerUI = uiPath
+	}
+}
+
+// WithSpec 使用指定的文档注册表，默认DefaultSpec()
+func WithSpec(spec *Spec) DocsOption {
+	return func(c *docsConfig) {
+		c.spec = spec
+	}
+}
+
+// DocsModule 文档路由模块，挂载 /openapi.json 及可选的Swagger UI
+// 文档在请求时生成，包含所有通过Handle注册的接口
+// 例: router.Register(api.DocsModule(api.WithSwaggerUI("/swagger")))
+func DocsModule(opts ...DocsOption) router.RouteModule {
+	conf := &docsConfig{
+		specPath: "/openapi.json",
+		spec:     DefaultSpec(),
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return router.NewModule("openapi", "", func(group *gin.RouterGroup) {
+		group.GET(conf.specPath, func(c *gin.Context) {
+			c.JSON(http.StatusOK, conf.spec.Document())
+		})
+		if conf.swaggerUI != "" {
+			group.GET(conf.swaggerUI, func(c *gin.Context) {
+				c.Header("Content-Type", "text/html; charset=utf-8")
+				if err := swaggerTemplate.Execute(c.Writer, map[string]string{
+					"Title":   conf.spec.Document().Info.Title,
+					"SpecURL": conf.specPath,
+				}); err != nil {
+					_ = c.Error(fmt.Errorf("failed to render swagger ui: %v", err))
+				}
+			})
+		}
+	})
+}
+
+// swaggerTemplate Swagger UI页面(静态资源使用CDN)
+var swaggerTemplate = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({ url: "{{.SpecURL}}", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`))
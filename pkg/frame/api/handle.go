@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerFunc 类型化的处理函数
+// ctx 为c.Request.Context()，可通过content.FromContext(ctx)获取RequestContext
+type HandlerFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// Handle 注册类型化接口
+// 请求绑定及响应规则同Wrap，同时生成OpenAPI文档
+// 例: api.Handle(group, http.MethodPost, "/orders", createOrder, api.WithSummary("创建订单"))
+func Handle[Req, Resp any](group *gin.RouterGroup, method, relativePath string, fn HandlerFunc[Req, Resp], opts ...OperationOption) {
+	fullPath := joinPath(group.BasePath(), relativePath)
+	DefaultSpec().AddOperation(method, fullPath, reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf((*Resp)(nil)).Elem(), opts...)
+	group.Handle(strings.ToUpper(method), relativePath, Wrap(fn))
+}
+
+// joinPath 拼接路由路径，保留结尾的"/"
+func joinPath(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
@@ -0,0 +1,462 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/boloc/go-frame-server/pkg/frame/pagination"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+)
+
+// OpenAPIVersion 生成的文档版本
+const OpenAPIVersion = "3.0.3"
+
+// validationCode 参数绑定或校验失败时返回的业务码，取自Wrap实际返回的throw.ValidationException
+var validationCode, _ = response.ErrorCode(context.Background(), throw.ValidationException(errors.New("validation")))
+
+// Document OpenAPI 3文档
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info 文档信息
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server 服务地址
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag 接口分组
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components 公共组件
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation 接口
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter 路径、查询、请求头参数
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path、query、header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType 内容类型
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema 数据结构
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Example              any                `json:"example,omitempty"`
+	// 枚举值说明(业务码等)
+	EnumDescriptions []string `json:"x-enum-descriptions,omitempty"`
+}
+
+// OperationOption 定义接口文档选项函数类型
+type OperationOption func(*Operation, *operationMeta)
+
+// operationMeta 接口文档的附加信息
+type operationMeta struct {
+	errorCodes []int
+	hidden     bool
+}
+
+// WithSummary 设置接口摘要
+func WithSummary(summary string) OperationOption {
+	return func(op *Operation, _ *operationMeta) {
+		op.Summary = summary
+	}
+}
+
+// WithDescription 设置接口描述
+func WithDescription(description string) OperationOption {
+	return func(op *Operation, _ *operationMeta) {
+		op.Description = description
+	}
+}
+
+// WithTags 设置接口分组，默认使用路由前缀
+func WithTags(tags ...string) OperationOption {
+	return func(op *Operation, _ *operationMeta) {
+		op.Tags = tags
+	}
+}
+
+// WithOperationID 设置接口ID，默认根据方法和路径生成
+func WithOperationID(id string) OperationOption {
+	return func(op *Operation, _ *operationMeta) {
+		op.OperationID = id
+	}
+}
+
+// WithDeprecated 标记接口已废弃
+func WithDeprecated() OperationOption {
+	return func(op *Operation, _ *operationMeta) {
+		op.Deprecated = true
+	}
+}
+
+// WithErrorCodes 声明接口可能返回的throw业务码(如enum.NOT_FOUND)
+func WithErrorCodes(codes ...int) OperationOption {
+	return func(_ *Operation, meta *operationMeta) {
+		meta.errorCodes = append(meta.errorCodes, codes...)
+	}
+}
+
+// WithHidden 不在文档中展示该接口
+func WithHidden() OperationOption {
+	return func(_ *Operation, meta *operationMeta) {
+		meta.hidden = true
+	}
+}
+
+// Spec 接口文档注册表，Handle注册的接口会自动加入默认注册表
+type Spec struct {
+	info       Info
+	servers    []Server
+	operations map[string]map[string]*Operation
+	schemas    *schemaRegistry
+	mu         sync.RWMutex
+}
+
+var defaultSpec = NewSpec()
+
+// DefaultSpec 获取默认接口文档注册表
+func DefaultSpec() *Spec {
+	return defaultSpec
+}
+
+// NewSpec 创建接口文档注册表
+func NewSpec() *Spec {
+	s := &Spec{
+		info:       Info{Title: "API", Version: "1.0.0"},
+		operations: make(map[string]map[string]*Operation),
+		schemas:    newSchemaRegistry(),
+	}
+
+	// 公共结构
+	s.schemas.named("ErrorCode", errorCodeSchema())
+	s.schemas.named("ErrorResponse", &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Ref: schemaRef("ErrorCode")},
+			"message": {Type: "string"},
+			"data":    {Nullable: true, Description: "always null"},
+		},
+		Required: []string{"code", "message"},
+	})
+	s.schemas.schemaOf(reflect.TypeOf(pagination.PageRequest{}))
+	s.schemas.schemaOf(reflect.TypeOf(pagination.PageResponse{}))
+	return s
+}
+
+// SetInfo 设置文档信息
+func (s *Spec) SetInfo(info Info) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+}
+
+// AddServer 添加服务地址
+func (s *Spec) AddServer(servers ...Server) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers = append(s.servers, servers...)
+}
+
+// AddOperation 添加接口
+// ginPath 为gin格式的完整路径，如 /v1/orders/:id
+func (s *Spec) AddOperation(method, ginPath string, reqType, respType reflect.Type, opts ...OperationOption) {
+	op := &Operation{Responses: make(map[string]*Response)}
+	meta := &operationMeta{}
+	for _, opt := range opts {
+		opt(op, meta)
+	}
+	if meta.hidden {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	method = strings.ToLower(method)
+	openapiPath := toOpenAPIPath(ginPath)
+	if op.OperationID == "" {
+		op.OperationID = operationID(method, ginPath)
+	}
+	if len(op.Tags) == 0 {
+		if tag := defaultTag(ginPath); tag != "" {
+			op.Tags = []string{tag}
+		}
+	}
+
+	// 请求参数
+	if reqType != nil {
+		params, body := s.schemas.request(reqType, method)
+		op.Parameters = params
+		if body != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: body},
+				},
+			}
+		}
+	}
+	// 路径参数必须声明
+	op.Parameters = ensurePathParams(op.Parameters, ginPath)
+
+	// 成功响应，使用统一响应结构
+	data := &Schema{Nullable: true}
+	if respType != nil {
+		data = s.schemas.schemaOf(respType)
+	}
+	op.Responses["200"] = &Response{
+		Description: enum.GetMessage(enum.SUCCESS),
+		Content: map[string]MediaType{
+			"application/json": {Schema: envelopeSchema(data)},
+		},
+	}
+
+	// 失败响应，参数绑定或校验失败和服务器错误默认存在
+	codes := append([]int{validationCode, enum.SERVER_ERROR}, meta.errorCodes...)
+	for status, desc := range errorResponses(codes) {
+		op.Responses[status] = &Response{
+			Description: desc,
+			Content: map[string]MediaType{
+				"application/json": {Schema: &Schema{Ref: schemaRef("ErrorResponse")}},
+			},
+		}
+	}
+
+	if s.operations[openapiPath] == nil {
+		s.operations[openapiPath] = make(map[string]*Operation)
+	}
+	s.operations[openapiPath][method] = op
+}
+
+// Document 生成OpenAPI文档
+// 返回注册表的快照，之后注册的接口不影响已生成的文档，Operation注册后不再修改，可共享
+func (s *Spec) Document() *Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc := &Document{
+		OpenAPI:    OpenAPIVersion,
+		Info:       s.info,
+		Servers:    append([]Server(nil), s.servers...),
+		Paths:      make(map[string]map[string]*Operation, len(s.operations)),
+		Components: Components{Schemas: s.schemas.all()},
+	}
+
+	// 复制接口并汇总接口分组
+	tags := make(map[string]struct{})
+	for path, methods := range s.operations {
+		doc.Paths[path] = make(map[string]*Operation, len(methods))
+		for method, op := range methods {
+			doc.Paths[path][method] = op
+			for _, tag := range op.Tags {
+				tags[tag] = struct{}{}
+			}
+		}
+	}
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc
+}
+
+// envelopeSchema 统一响应结构
+func envelopeSchema(data *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "integer", Example: enum.SUCCESS},
+			"message": {Type: "string", Example: enum.GetMessage(enum.SUCCESS)},
+			"data":    data,
+		},
+		Required: []string{"code", "message", "data"},
+	}
+}
+
+// errorCodeSchema throw业务码枚举
+func errorCodeSchema() *Schema {
+	schema := &Schema{Type: "integer", Description: "业务码，0为成功"}
+	for _, code := range enum.ApiCodes() {
+		schema.Enum = append(schema.Enum, code.Code)
+		schema.EnumDescriptions = append(schema.EnumDescriptions, code.Message)
+	}
+	return schema
+}
+
+// errorResponses 按HTTP状态码汇总业务码说明
+func errorResponses(codes []int) map[string]string {
+	grouped := make(map[int][]int)
+	for _, code := range codes {
+		status := response.HTTPStatus(code)
+		exists := false
+		for _, c := range grouped[status] {
+			if c == code {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			grouped[status] = append(grouped[status], code)
+		}
+	}
+
+	result := make(map[string]string, len(grouped))
+	for status, list := range grouped {
+		sort.Ints(list)
+		desc := make([]string, 0, len(list))
+		for _, code := range list {
+			message := enum.GetMessage(code)
+			if code == validationCode {
+				message += "(参数绑定或校验失败，message为校验错误信息)"
+			}
+			desc = append(desc, fmt.Sprintf("%d %s", code, message))
+		}
+		result[strconv.Itoa(status)] = strings.Join(desc, "; ")
+	}
+	return result
+}
+
+// toOpenAPIPath 将gin路径转换为OpenAPI路径 /orders/:id/*path -> /orders/{id}/{path}
+func toOpenAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParamNames 获取gin路径中的参数名
+func pathParamNames(ginPath string) []string {
+	var names []string
+	for _, seg := range strings.Split(ginPath, "/") {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			names = append(names, seg[1:])
+		}
+	}
+	return names
+}
+
+// ensurePathParams 补充请求结构中未声明的路径参数
+func ensurePathParams(params []*Parameter, ginPath string) []*Parameter {
+	for _, name := range pathParamNames(ginPath) {
+		found := false
+		for _, p := range params {
+			if p.In == "path" && p.Name == name {
+				p.Required = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return params
+}
+
+// operationID 根据方法和路径生成接口ID，如 get_v1_orders_id
+func operationID(method, ginPath string) string {
+	replacer := strings.NewReplacer("/", "_", ":", "", "*", "", "-", "_", ".", "_")
+	return strings.ToLower(method) + strings.TrimRight(replacer.Replace(ginPath), "_")
+}
+
+// defaultTag 默认分组，取路径中第一个非版本、非参数的片段
+func defaultTag(ginPath string) string {
+	for _, seg := range strings.Split(ginPath, "/") {
+		if seg == "" || strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			continue
+		}
+		if len(seg) > 1 && seg[0] == 'v' {
+			if _, err := strconv.Atoi(seg[1:]); err == nil {
+				continue
+			}
+		}
+		return seg
+	}
+	return ""
+}
+
+// methodHasBody 方法是否携带请求体
+func methodHasBody(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	invalidNameChars  = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+)
+
+// schemaRegistry 根据Go类型生成Schema，具名结构体放入components复用
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// newSchemaRegistry 创建Schema注册表
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// named 注册具名Schema
+func (r *schemaRegistry) named(name string, schema *Schema) {
+	r.schemas[name] = schema
+}
+
+// all 获取所有具名Schema
+func (r *schemaRegistry) all() map[string]*Schema {
+	schemas := make(map[string]*Schema, len(r.schemas))
+	for name, schema := range r.schemas {
+		schemas[name] = schema
+	}
+	return schemas
+}
+
+// schemaRef components中Schema的引用路径
+func schemaRef(name string) string {
+	return "#/components/schemas/" + name
+}
+
+// schemaOf 获取类型对应的Schema
+func (r *schemaRegistry) schemaOf(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := r.schemaOfType(t)
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+// schemaOfType 获取非指针类型对应的Schema
+func (r *schemaRegistry) schemaOfType(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{Description: "any json"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte 及 datatypes.JSON 等自定义序列化类型
+			if t.Implements(jsonMarshalerType) {
+				return &Schema{Description: "any json"}
+			}
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return r.structSchema(t)
+	default:
+		// interface{} 等无法确定的类型
+		return &Schema{}
+	}
+}
+
+// structSchema 结构体Schema，具名结构体注册到components并返回引用
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return r.buildStruct(t)
+	}
+
+	if name, ok := r.names[t]; ok {
+		return &Schema{Ref: schemaRef(name)}
+	}
+
+	name := r.schemaName(t)
+	r.names[t] = name
+	// 先占位，避免递归结构体死循环
+	r.schemas[name] = &Schema{Type: "object"}
+	r.schemas[name] = r.buildStruct(t)
+	return &Schema{Ref: schemaRef(name)}
+}
+
+// schemaName 生成不冲突的Schema名称
+func (r *schemaRegistry) schemaName(t reflect.Type) string {
+	name := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	name = strings.Trim(name, "_")
+	if _, exists := r.schemas[name]; !exists {
+		return name
+	}
+	// 同名类型使用包名区分
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name = pkg + "." + name
+	for i := 2; ; i++ {
+		if _, exists := r.schemas[name]; !exists {
+			return name
+		}
+		name = strings.TrimRight(name, "0123456789") + strconv.Itoa(i)
+	}
+}
+
+// buildStruct 生成结构体Schema(json字段)
+func (r *schemaRegistry) buildStruct(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.collectFields(t, schema, func(f reflect.StructField) (string, bool) {
+		return jsonName(f)
+	})
+	return schema
+}
+
+// collectFields 收集结构体字段，匿名嵌入的结构体字段展开
+func (r *schemaRegistry) collectFields(t reflect.Type, schema *Schema, nameOf func(reflect.StructField) (string, bool)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			r.collectFields(ft, schema, nameOf)
+			continue
+		}
+
+		name, ok := nameOf(f)
+		if !ok {
+			continue
+		}
+		prop := r.schemaOf(f.Type)
+		required := applyValidation(prop, f)
+		if desc := f.Tag.Get("description"); desc != "" && prop.Ref == "" {
+			prop.Description = desc
+		}
+		if example := f.Tag.Get("example"); example != "" && prop.Ref == "" {
+			prop.Example = example
+		}
+		schema.Properties[name] = prop
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// request 生成请求参数和请求体Schema
+// uri标签为路径参数，header标签为请求头参数；无请求体的方法form标签为查询参数，
+// 有请求体的方法json标签字段组成请求体，仅有form标签的字段为查询参数
+func (r *schemaRegistry) request(t reflect.Type, method string) ([]*Parameter, *Schema) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	hasBody := methodHasBody(method)
+	var params []*Parameter
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() && !f.Anonymous {
+				continue
+			}
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && ft.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+				walk(ft)
+				continue
+			}
+
+			if name := tagName(f, "uri"); name != "" {
+				params = append(params, r.parameter(f, name, "path"))
+				continue
+			}
+			if name := tagName(f, "header"); name != "" {
+				params = append(params, r.parameter(f, name, "header"))
+				continue
+			}
+
+			jsonField, hasJSON := jsonName(f)
+			formName := tagName(f, "form")
+			if hasBody && hasJSON && f.Tag.Get("json") != "" {
+				prop := r.schemaOf(f.Type)
+				if applyValidation(prop, f) {
+					body.Required = append(body.Required, jsonField)
+				}
+				if desc := f.Tag.Get("description"); desc != "" && prop.Ref == "" {
+					prop.Description = desc
+				}
+				body.Properties[jsonField] = prop
+				continue
+			}
+			if formName != "" {
+				params = append(params, r.parameter(f, formName, "query"))
+			}
+		}
+	}
+	walk(t)
+
+	if !hasBody || len(body.Properties) == 0 {
+		return params, nil
+	}
+	return params, body
+}
+
+// parameter 生成参数
+func (r *schemaRegistry) parameter(f reflect.StructField, name, in string) *Parameter {
+	schema := r.schemaOf(f.Type)
+	required := applyValidation(schema, f)
+	return &Parameter{
+		Name:        name,
+		In:          in,
+		Description: f.Tag.Get("description"),
+		Required:    required || in == "path",
+		Schema:      schema,
+	}
+}
+
+// jsonName 获取字段的json名称
+func jsonName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		if !f.IsExported() {
+			return "", false
+		}
+		name = f.Name
+	}
+	return name, true
+}
+
+// tagName 获取form/uri/header标签名称
+func tagName(f reflect.StructField, key string) string {
+	tag := f.Tag.Get(key)
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+// applyValidation 根据binding/validate标签补充约束，返回是否必填
+func applyValidation(schema *Schema, f reflect.StructField) bool {
+	tag := f.Tag.Get("binding")
+	if tag == "" {
+		tag = f.Tag.Get("validate")
+	}
+	if tag == "" {
+		return false
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "gte":
+			setBound(schema, param, true)
+		case "max", "lte":
+			setBound(schema, param, false)
+		case "len":
+			setBound(schema, param, true)
+			setBound(schema, param, false)
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, v))
+			}
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		}
+	}
+	return required
+}
+
+// setBound 设置数值、字符串、数组的上下限
+func setBound(schema *Schema, param string, min bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if min {
+			schema.Minimum = &n
+		} else {
+			schema.Maximum = &n
+		}
+	case "string":
+		v := int(n)
+		if min {
+			schema.MinLength = &v
+		} else {
+			schema.MaxLength = &v
+		}
+	case "array":
+		v := int(n)
+		if min {
+			schema.MinItems = &v
+		} else {
+			schema.MaxItems = &v
+		}
+	}
+}
+
+// enumValue 枚举值按类型转换
+func enumValue(schemaType, v string) any {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
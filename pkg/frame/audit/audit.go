@@ -0,0 +1,630 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/logger"
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
+	"go.uber.org/zap"
+)
+
+// 常用操作
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+var (
+	// ErrNotRunning 审计组件未启动或已停止
+	ErrNotRunning = errors.New("audit: auditor is not running")
+	// ErrQueueFull 队列已满，等待超时后丢弃
+	ErrQueueFull = errors.New("audit: queue is full")
+)
+
+// Entry 审计记录
+// 同一链(Chain)中每条记录的Hash包含上一条记录的Hash，修改或删除任一条记录都会使Verify校验失败
+type Entry struct {
+	Chain      string          `json:"chain"`            // 链名称，默认主机名，多实例各自成链
+	Seq        uint64          `json:"seq"`              // 链内序号，从1开始连续递增
+	Time       time.Time       `json:"time"`             // 精确到毫秒
+	Actor      string          `json:"actor"`            // 操作人，为空时使用RequestContext.Identity()
+	Action     string          `json:"action"`           // 操作，例: create、update、delete、login
+	Resource   string          `json:"resource"`         // 资源类型，GORM回调中为表名或Auditable.AuditResource()
+	ResourceID string          `json:"resource_id"`      // 资源ID，GORM回调中为主键，联合主键以逗号分隔
+	Before     json.RawMessage `json:"before,omitempty"` // 修改前的数据
+	After      json.RawMessage `json:"after,omitempty"`  // 修改后的数据
+	Diff       json.RawMessage `json:"diff,omitempty"`   // 变化的字段，见Change
+	ClientIP   string          `json:"client_ip"`
+	RequestID  string          `json:"request_id"`
+	PrevHash   string          `json:"prev_hash"` // 上一条记录的Hash，链的第一条为空
+	Hash       string          `json:"hash"`      // sha256(PrevHash及本条内容)
+}
+
+// ComputeHash 计算记录的Hash，各字段带长度前缀，避免拼接歧义
+func (e *Entry) ComputeHash() string {
+	h := sha256.New()
+	for _, field := range []string{
+		e.PrevHash, e.Chain, strconv.FormatUint(e.Seq, 10), e.Time.UTC().Format(time.RFC3339Nano),
+		e.Actor, e.Action, e.Resource, e.ResourceID,
+		string(e.Before), string(e.After), string(e.Diff),
+		e.ClientIP, e.RequestID,
+	} {
+		h.Write([]byte(strconv.Itoa(len(field)) + ":" + field))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 字段最大长度(字符数)，与MySQLSchema的列宽一致，超出的部分在计算Hash前截断
+const (
+	maxChainLength      = 64
+	maxActorLength      = 128
+	maxActionLength     = 64
+	maxResourceLength   = 128
+	maxResourceIDLength = 128
+	maxClientIPLength   = 64
+	maxRequestIDLength  = 128
+)
+
+// Change 字段变化
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Option 定义审计选项函数类型
+type Option func(*Auditor)
+
+// WithChain 设置链名称，为空使用主机名；多个实例写入同一张表时需要不同的链名称
+func WithChain(chain string) Option {
+	return func(a *Auditor) {
+		if chain != "" {
+			a.chain = chain
+		}
+	}
+}
+
+// WithCreateTable 设置启动时是否按Store的表结构创建表(CREATE TABLE IF NOT EXISTS)，默认不创建
+func WithCreateTable(create bool) Option {
+	return func(a *Auditor) {
+		a.createTable = create
+	}
+}
+
+// WithBufferSize 设置队列长度，默认1024
+func WithBufferSize(size int) Option {
+	return func(a *Auditor) {
+		a.bufferSize = size
+	}
+}
+
+// WithBatch 设置单批最多条数和刷新间隔，默认100条/1秒
+func WithBatch(batchSize int, flushInterval time.Duration) Option {
+	return func(a *Auditor) {
+		a.batchSize = batchSize
+		a.flushInterval = flushInterval
+	}
+}
+
+// WithEnqueueTimeout 设置队列满时Record的最长等待时间，默认1秒，超时后丢弃并返回ErrQueueFull
+func WithEnqueueTimeout(timeout time.Duration) Option {
+	return func(a *Auditor) {
+		a.enqueueTimeout = timeout
+	}
+}
+
+// WithWriteRetries 设置同一批记录写入Store的最多尝试次数，默认3，
+// 都失败时丢弃该批记录(计入Dropped)，并从Store读取链的最后一条记录继续链接
+func WithWriteRetries(retries int) Option {
+	return func(a *Auditor) {
+		a.writeRetries = retries
+	}
+}
+
+// WithMask 设置是否对修改前后的数据脱敏(密码等字段)，默认开启，masker为空使用mask.Default()
+func WithMask(enable bool, masker *mask.Masker) Option {
+	return func(a *Auditor) {
+		a.mask = enable
+		a.masker = masker
+	}
+}
+
+// Stats 审计统计
+type Stats struct {
+	Chain   string `json:"chain"`
+	Seq     uint64 `json:"seq"`     // 已分配的最大序号
+	Queued  int    `json:"queued"`  // 队列中的条数
+	Written uint64 `json:"written"` // 写入成功条数
+	Dropped uint64 `json:"dropped"` // 队列满或停止时丢弃的条数
+}
+
+// Auditor 审计组件，记录异步批量写入Store
+// Start时从Store读取链的最后一条记录继续链接，Store所在的组件需要先启动
+type Auditor struct {
+	store          Store
+	chain          string
+	bufferSize     int
+	batchSize      int
+	flushInterval  time.Duration
+	enqueueTimeout time.Duration
+	writeTimeout   time.Duration
+	writeRetries   int
+	mask           bool
+	masker         *mask.Masker
+	createTable    bool
+
+	mu      sync.Mutex
+	running atomic.Bool
+	queue   chan *Entry
+	done    chan struct{}
+	closed  chan struct{}
+
+	// 以下由写入协程维护
+	seq      atomic.Uint64
+	lastHash string
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// New 创建审计组件，例:
+// auditor := audit.New(audit.NewMySQLStore(mysqlComponent, "audit_logs"))
+// f.RegisterComponent(auditor) // 在MySQL组件之后注册
+func New(store Store, opts ...Option) *Auditor {
+	hostname, _ := os.Hostname()
+	a := &Auditor{
+		store:          store,
+		chain:          hostname,
+		bufferSize:     1024,
+		batchSize:      100,
+		flushInterval:  time.Second,
+		enqueueTimeout: time.Second,
+		writeTimeout:   5 * time.Second,
+		writeRetries:   3,
+		mask:           true,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.chain == "" {
+		a.chain = "default"
+	}
+	a.chain = truncate(a.chain, maxChainLength)
+	if a.writeRetries <= 0 {
+		a.writeRetries = 3
+	}
+	if a.batchSize <= 0 {
+		a.batchSize = 100
+	}
+	if a.flushInterval <= 0 {
+		a.flushInterval = time.Second
+	}
+	return a
+}
+
+// Name 组件名称
+func (a *Auditor) Name() string {
+	return "audit/" + a.store.Name()
+}
+
+// Chain 链名称
+func (a *Auditor) Chain() string {
+	return a.chain
+}
+
+// Start 读取链的最后一条记录并启动写入协程
+// 上次Stop超时时先等待之前的写入协程退出，避免两个协程同时写入使链分叉
+func (a *Auditor) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.running.Load() {
+		return nil
+	}
+	if a.closed != nil {
+		select {
+		case <-a.closed:
+		case <-ctx.Done():
+			return fmt.Errorf("audit: previous writer of chain %s is still running: %w", a.chain, ctx.Err())
+		}
+	}
+
+	if creator, ok := a.store.(interface{ EnsureTable(context.Context) error }); ok && a.createTable {
+		if err := creator.EnsureTable(ctx); err != nil {
+			return fmt.Errorf("audit: create table: %w", err)
+		}
+	}
+	seq, hash, err := a.store.Last(ctx, a.chain)
+	if err != nil {
+		return fmt.Errorf("audit: load last entry of chain %s: %w", a.chain, err)
+	}
+	a.seq.Store(seq)
+	a.lastHash = hash
+	a.queue = make(chan *Entry, a.bufferSize)
+	a.done = make(chan struct{})
+	a.closed = make(chan struct{})
+	go a.run()
+	a.running.Store(true)
+
+	fmt.Printf("Audit [%s] started, chain: %s, seq: %d\n", a.store.Name(), a.chain, seq)
+	return nil
+}
+
+// Stop 写出队列中的记录后停止，ctx超时时写入协程继续在后台写出
+func (a *Auditor) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.running.Load() {
+		return nil
+	}
+	a.running.Store(false)
+	close(a.done)
+	select {
+	case <-a.closed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit: %w", ctx.Err())
+	}
+}
+
+// Stats 审计统计
+func (a *Auditor) Stats() Stats {
+	stats := Stats{
+		Chain:   a.chain,
+		Seq:     a.seq.Load(),
+		Written: a.written.Load(),
+		Dropped: a.dropped.Load(),
+	}
+	if queue := a.queue; queue != nil {
+		stats.Queued = len(queue)
+	}
+	return stats
+}
+
+// Record 记录审计日志，Actor、ClientIP和RequestID为空时从ctx中的RequestContext获取
+// 只在写入队列后返回，不等待写入Store
+func (a *Auditor) Record(ctx context.Context, entry Entry) error {
+	if !a.running.Load() {
+		a.dropped.Add(1)
+		return ErrNotRunning
+	}
+	a.fill(ctx, &entry)
+
+	timer := time.NewTimer(a.enqueueTimeout)
+	defer timer.Stop()
+	select {
+	case a.queue <- &entry:
+		return nil
+	case <-a.done:
+		a.dropped.Add(1)
+		return ErrNotRunning
+	case <-timer.C:
+		a.dropped.Add(1)
+		return ErrQueueFull
+	case <-ctx.Done():
+		a.dropped.Add(1)
+		return ctx.Err()
+	}
+}
+
+// Log 记录一次操作，before、after为修改前后的数据(结构体、map等，可为nil)，自动计算变化的字段
+// 例: auditor.Log(ctx, "update", "user", "1", oldUser, newUser)
+func (a *Auditor) Log(ctx context.Context, action, resource, resourceID string, before, after any) error {
+	beforeMap, err := toMap(before)
+	if err != nil {
+		return err
+	}
+	afterMap, err := toMap(after)
+	if err != nil {
+		return err
+	}
+	return a.Record(ctx, newEntry(action, resource, resourceID, beforeMap, afterMap))
+}
+
+// Query 查询审计记录，按时间倒序
+func (a *Auditor) Query(ctx context.Context, query *Query) ([]*Entry, int64, error) {
+	return a.store.Query(ctx, query)
+}
+
+// VerifyResult 链校验结果
+type VerifyResult struct {
+	Chain    string `json:"chain"`
+	Checked  uint64 `json:"checked"`             // 校验通过的条数
+	Valid    bool   `json:"valid"`               // 是否完整
+	BrokenAt uint64 `json:"broken_at,omitempty"` // 第一条异常记录的序号
+	Reason   string `json:"reason,omitempty"`
+}
+
+// verifyPageSize 校验时每次读取的条数
+const verifyPageSize = 500
+
+// Verify 按序号校验链的完整性，chain为空时校验本实例的链
+func (a *Auditor) Verify(ctx context.Context, chain string) (*VerifyResult, error) {
+	if chain == "" {
+		chain = a.chain
+	}
+	result := &VerifyResult{Chain: chain, Valid: true}
+	prevHash := ""
+	next := uint64(1)
+	for {
+		entries, err := a.store.Range(ctx, chain, next, verifyPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			switch {
+			case entry.Seq != next:
+				result.Reason = fmt.Sprintf("missing entries %d-%d", next, entry.Seq-1)
+			case entry.PrevHash != prevHash:
+				result.Reason = "prev_hash does not match previous entry"
+			case entry.ComputeHash() != entry.Hash:
+				result.Reason = "hash does not match content"
+			}
+			if result.Reason != "" {
+				result.Valid = false
+				result.BrokenAt = next
+				return result, nil
+			}
+			prevHash = entry.Hash
+			result.Checked++
+			next++
+		}
+		if len(entries) < verifyPageSize {
+			return result, nil
+		}
+	}
+}
+
+// fill 补充时间和请求信息，并脱敏
+func (a *Auditor) fill(ctx context.Context, entry *Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	// 数据库中精确到毫秒，Hash需要与读取的值一致
+	entry.Time = entry.Time.Truncate(time.Millisecond)
+	if entry.Actor == "" {
+		entry.Actor = ActorFromContext(ctx)
+	}
+	if rc := content.FromContext(ctx); rc != nil {
+		if entry.ClientIP == "" {
+			entry.ClientIP = rc.ClientIP
+		}
+		if entry.RequestID == "" {
+			entry.RequestID = rc.RequestID
+		}
+	}
+	entry.Actor = truncate(entry.Actor, maxActorLength)
+	entry.Action = truncate(entry.Action, maxActionLength)
+	entry.Resource = truncate(entry.Resource, maxResourceLength)
+	entry.ResourceID = truncate(entry.ResourceID, maxResourceIDLength)
+	entry.ClientIP = truncate(entry.ClientIP, maxClientIPLength)
+	entry.RequestID = truncate(entry.RequestID, maxRequestIDLength)
+	entry.Before = a.maskJSON(entry.Before)
+	entry.After = a.maskJSON(entry.After)
+	entry.Diff = a.maskJSON(entry.Diff)
+}
+
+// truncate 截断为最多n个字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
+
+// maskJSON 脱敏，null按空处理
+func (a *Auditor) maskJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	if !a.mask {
+		return data
+	}
+	masker := a.masker
+	if masker == nil {
+		masker = mask.Default()
+	}
+	return masker.JSON(data)
+}
+
+// run 后台批量写入，写入失败时保留已分配序号的记录并在下次刷新时重试，保证链不中断；
+// 连续失败writeRetries次后丢弃该批记录，避免一条无法写入的记录使整条链停止
+func (a *Auditor) run() {
+	defer close(a.closed)
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	var (
+		pending  []*Entry
+		failures int
+	)
+	add := func(entry *Entry) {
+		entry.Chain = a.chain
+		entry.Seq = a.seq.Add(1)
+		entry.PrevHash = a.lastHash
+		entry.Hash = entry.ComputeHash()
+		a.lastHash = entry.Hash
+		pending = append(pending, entry)
+	}
+	write := func() {
+		if len(pending) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), a.writeTimeout)
+		err := a.store.Write(ctx, pending)
+		cancel()
+		if err != nil {
+			failures++
+			if failures < a.writeRetries {
+				logger.Named("audit").Error("write audit entries failed, will retry",
+					zap.String("store", a.store.Name()), zap.Int("pending", len(pending)), zap.Int("failures", failures), zap.Error(err))
+				return
+			}
+			a.discard(pending, err)
+			pending, failures = nil, 0
+			return
+		}
+		a.written.Add(uint64(len(pending)))
+		pending, failures = nil, 0
+	}
+
+	for {
+		// 写入失败积压时不再读取队列，由Record等待超时后丢弃
+		queue := a.queue
+		if len(pending) >= a.batchSize {
+			queue = nil
+		}
+		select {
+		case entry := <-queue:
+			add(entry)
+			if len(pending) >= a.batchSize {
+				write()
+			}
+		case <-ticker.C:
+			write()
+		case <-a.done:
+		drain:
+			for {
+				select {
+				case entry := <-a.queue:
+					add(entry)
+				default:
+					break drain
+				}
+			}
+			write()
+			if len(pending) > 0 {
+				a.dropped.Add(uint64(len(pending)))
+				logger.Named("audit").Error("audit entries lost on stop",
+					zap.String("store", a.store.Name()), zap.Uint64("from_seq", pending[0].Seq), zap.Int("count", len(pending)))
+			}
+			return
+		}
+	}
+}
+
+// discard 丢弃多次写入失败的记录，从Store读取链的最后一条记录继续链接
+// 读取失败时认为该批记录都未写入，从该批第一条之前继续
+func (a *Auditor) discard(pending []*Entry, err error) {
+	a.dropped.Add(uint64(len(pending)))
+	logger.Named("audit").Error("audit entries discarded after repeated write failures",
+		zap.String("store", a.store.Name()), zap.Uint64("from_seq", pending[0].Seq), zap.Int("count", len(pending)),
+		zap.Int("attempts", a.writeRetries), zap.Error(err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.writeTimeout)
+	defer cancel()
+	seq, hash, lastErr := a.store.Last(ctx, a.chain)
+	if lastErr != nil {
+		seq, hash = pending[0].Seq-1, pending[0].PrevHash
+		logger.Named("audit").Error("load last audit entry failed, continue from discarded entries",
+			zap.String("store", a.store.Name()), zap.Uint64("seq", seq), zap.Error(lastErr))
+	}
+	a.seq.Store(seq)
+	a.lastHash = hash
+}
+
+// newEntry 根据修改前后的数据创建记录
+func newEntry(action, resource, resourceID string, before, after map[string]any) Entry {
+	entry := Entry{Action: action, Resource: resource, ResourceID: resourceID}
+	if before != nil {
+		entry.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		entry.After, _ = json.Marshal(after)
+	}
+	if before != nil && after != nil {
+		if changes := Diff(before, after); len(changes) > 0 {
+			entry.Diff, _ = json.Marshal(changes)
+		}
+	}
+	return entry
+}
+
+// Diff 比较修改前后的字段，返回变化的字段
+func Diff(before, after map[string]any) map[string]Change {
+	changes := make(map[string]Change)
+	for key, oldValue := range before {
+		newValue, ok := after[key]
+		if !ok || !equal(oldValue, newValue) {
+			changes[key] = Change{Before: oldValue, After: newValue}
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = Change{After: newValue}
+		}
+	}
+	return changes
+}
+
+// equal 按JSON编码比较，数据库读取的[]byte、数值类型等与结构体字段一致
+func equal(a, b any) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(x) == string(y)
+}
+
+// toMap 转换为map，nil返回nil
+func toMap(value any) (map[string]any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if m, ok := value.(map[string]any); ok {
+		return m, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+	// 保留大整数精度
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var m map[string]any
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("audit: %T is not an object: %w", value, err)
+	}
+	return m, nil
+}
+
+type actorKey struct{}
+
+// ContextWithActor 设置操作人，用于定时任务等没有RequestContext的场景，例: audit.ContextWithActor(ctx, "cron:cleanup")
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext 获取操作人，优先使用ContextWithActor设置的值，其次为RequestContext.Identity()
+func ActorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	if rc := content.FromContext(ctx); rc != nil {
+		return rc.Identity()
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ClickHouseSchema 审计表结构，%s为表名；ReplacingMergeTree按(chain, seq)去除重试产生的重复记录
+const ClickHouseSchema = `CREATE TABLE IF NOT EXISTS %s (
+	chain       LowCardinality(String),
+	seq         UInt64,
+	time        DateTime64(3),
+	actor       String,
+	action      LowCardinality(String),
+	resource    LowCardinality(String),
+	resource_id String,
+	before      String,
+	after       String,
+	diff        String,
+	client_ip   String,
+	request_id  String,
+	prev_hash   String,
+	hash        String
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(time)
+ORDER BY (chain, seq)`
+
+// clickHouseColumns 读写的列
+const clickHouseColumns = "chain, seq, time, actor, action, resource, resource_id, before, after, diff, client_ip, request_id, prev_hash, hash"
+
+// ClickHouseStore 使用ClickHouse存储审计记录
+type ClickHouseStore struct {
+	component *components.ClickHouseComponent
+	table     string
+}
+
+// NewClickHouseStore 创建ClickHouse存储，表结构见ClickHouseSchema
+func NewClickHouseStore(component *components.ClickHouseComponent, table string) *ClickHouseStore {
+	return &ClickHouseStore{component: component, table: table}
+}
+
+// Name 存储名称
+func (s *ClickHouseStore) Name() string {
+	return s.component.Name() + "/" + s.table
+}
+
+// conn 获取连接
+func (s *ClickHouseStore) conn() (driver.Conn, error) {
+	conn := s.component.GetConn()
+	if conn == nil {
+		return nil, fmt.Errorf("%s is not started", s.component.Name())
+	}
+	return conn, nil
+}
+
+// EnsureTable 表不存在时按ClickHouseSchema创建
+func (s *ClickHouseStore) EnsureTable(ctx context.Context) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+	return conn.Exec(ctx, fmt.Sprintf(ClickHouseSchema, s.table))
+}
+
+// Last 获取链的最后一条记录
+func (s *ClickHouseStore) Last(ctx context.Context, chain string) (uint64, string, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return 0, "", err
+	}
+	rows, err := conn.Query(ctx, "SELECT seq, hash FROM "+s.table+" WHERE chain = ? ORDER BY seq DESC LIMIT 1", chain)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rows.Close()
+	var seq uint64
+	var hash string
+	if rows.Next() {
+		if err := rows.Scan(&seq, &hash); err != nil {
+			return 0, "", err
+		}
+	}
+	return seq, hash, rows.Err()
+}
+
+// Write 批量写入
+func (s *ClickHouseStore) Write(ctx context.Context, entries []*Entry) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO "+s.table+" ("+clickHouseColumns+")")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := batch.Append(e.Chain, e.Seq, e.Time, e.Actor, e.Action, e.Resource, e.ResourceID,
+			string(e.Before), string(e.After), string(e.Diff), e.ClientIP, e.RequestID, e.PrevHash, e.Hash); err != nil {
+			_ = batch.Abort()
+			return err
+		}
+	}
+	return batch.Send()
+}
+
+// Range 按序号顺序读取，FINAL去除未合并的重复记录
+func (s *ClickHouseStore) Range(ctx context.Context, chain string, fromSeq uint64, limit int) ([]*Entry, error) {
+	return s.selectEntries(ctx, "SELECT "+clickHouseColumns+" FROM "+s.table+" FINAL WHERE chain = ? AND seq >= ? ORDER BY seq LIMIT ?",
+		chain, fromSeq, limit)
+}
+
+// Query 分页查询
+func (s *ClickHouseStore) Query(ctx context.Context, query *Query) ([]*Entry, int64, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, 0, err
+	}
+	conditions, values := query.conditions()
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total uint64
+	if err := conn.QueryRow(ctx, "SELECT count() FROM "+s.table+" FINAL"+where, values...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	page, pageSize := query.GetPageInfo()
+	values = append(values, pageSize, (page-1)*pageSize)
+	entries, err := s.selectEntries(ctx, "SELECT "+clickHouseColumns+" FROM "+s.table+" FINAL"+where+" ORDER BY time DESC, seq DESC LIMIT ? OFFSET ?", values...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, int64(total), nil
+}
+
+// selectEntries 查询并转换为审计记录
+func (s *ClickHouseStore) selectEntries(ctx context.Context, query string, args ...any) ([]*Entry, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*Entry
+	for rows.Next() {
+		var (
+			e                   Entry
+			t                   time.Time
+			before, after, diff string
+		)
+		if err := rows.Scan(&e.Chain, &e.Seq, &t, &e.Actor, &e.Action, &e.Resource, &e.ResourceID,
+			&before, &after, &diff, &e.ClientIP, &e.RequestID, &e.PrevHash, &e.Hash); err != nil {
+			return nil, err
+		}
+		e.Time = t
+		e.Before, e.After, e.Diff = rawJSON(&before), rawJSON(&after), rawJSON(&diff)
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
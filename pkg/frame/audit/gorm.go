@@ -0,0 +1,468 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/boloc/go-frame-server/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Auditable 需要审计的模型，例:
+// func (User) AuditResource() string { return "user" }
+type Auditable interface {
+	// AuditResource 资源类型
+	AuditResource() string
+}
+
+// GormOption 定义GORM插件选项函数类型
+type GormOption func(*gormPlugin)
+
+// WithGormTables 审计指定表(资源类型为表名)，实现了Auditable的模型始终审计
+func WithGormTables(tables ...string) GormOption {
+	return func(p *gormPlugin) {
+		for _, table := range tables {
+			p.tables[table] = true
+		}
+	}
+}
+
+// WithGormMaxRows 设置单条语句最多审计的行数，默认100，批量修改超出的行不记录并输出警告日志
+func WithGormMaxRows(maxRows int) GormOption {
+	return func(p *gormPlugin) {
+		p.maxRows = maxRows
+	}
+}
+
+// gormPlugin 通过回调记录新增、修改、删除
+type gormPlugin struct {
+	auditor *Auditor
+	tables  map[string]bool
+	maxRows int
+}
+
+// instance中保存修改前数据的key
+const gormBeforeKey = "audit:before"
+
+// GormPlugin 创建GORM插件，记录模型新增、修改、删除前后的数据，例:
+// mysqlComponent.Use(auditor.GormPlugin(audit.WithGormTables("gm_users")))
+// 操作人、客户端IP和请求ID需要通过db.WithContext(ctx)传入；修改前后的数据各多查询一次；
+// 事务中(包括GORM默认为单条语句开启的事务)的审计在提交后写入，回滚时丢弃，
+// 嵌套事务回滚到保存点时已收集的审计不会撤销
+func (a *Auditor) GormPlugin(opts ...GormOption) gorm.Plugin {
+	p := &gormPlugin{auditor: a, tables: make(map[string]bool), maxRows: 100}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Name 插件名称
+func (p *gormPlugin) Name() string {
+	return "audit"
+}
+
+// Initialize 包装连接池并注册回调
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	// 事务由包装后的连接池开启，提交时才写入收集的审计
+	if _, ok := db.ConnPool.(*gormConnPool); !ok {
+		db.ConnPool = &gormConnPool{ConnPool: db.ConnPool}
+	}
+	db.Statement.ConnPool = db.ConnPool
+	if err := db.Callback().Create().After("gorm:create").Register("audit:after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("audit:before_update", p.before); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("audit:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("audit:before_delete", p.before); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("audit:after_delete", p.afterDelete)
+}
+
+// resource 获取资源类型，不需要审计时返回空
+func (p *gormPlugin) resource(stmt *gorm.Statement) string {
+	if stmt.Schema == nil || len(stmt.Schema.PrimaryFields) == 0 {
+		return ""
+	}
+	if auditable, ok := stmt.Model.(Auditable); ok {
+		return auditable.AuditResource()
+	}
+	if auditable, ok := reflect.New(stmt.Schema.ModelType).Interface().(Auditable); ok {
+		return auditable.AuditResource()
+	}
+	if p.tables[stmt.Table] {
+		return stmt.Table
+	}
+	return ""
+}
+
+// afterCreate 记录新增的行
+func (p *gormPlugin) afterCreate(db *gorm.DB) {
+	resource := p.resource(db.Statement)
+	if resource == "" || db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	keys := primaryKeys(db.Statement)
+	if len(keys) == 0 {
+		return
+	}
+	rows, err := p.fetch(db, keys, false)
+	if err != nil {
+		p.fail(db, err)
+		return
+	}
+	for _, row := range rows {
+		p.record(db, newEntry(ActionCreate, resource, resourceID(db.Statement.Schema, row), nil, row))
+	}
+}
+
+// before 修改、删除前读取受影响的行
+func (p *gormPlugin) before(db *gorm.DB) {
+	if p.resource(db.Statement) == "" || db.Error != nil {
+		return
+	}
+	rows, err := p.fetch(db, primaryKeys(db.Statement), true)
+	if err != nil {
+		p.fail(db, err)
+		return
+	}
+	db.InstanceSet(gormBeforeKey, rows)
+}
+
+// afterUpdate 按主键重新读取修改后的行，记录有变化的行
+func (p *gormPlugin) afterUpdate(db *gorm.DB) {
+	resource := p.resource(db.Statement)
+	beforeRows := p.beforeRows(db)
+	if resource == "" || db.Error != nil || db.RowsAffected == 0 || len(beforeRows) == 0 {
+		return
+	}
+	keys := make([][]any, len(beforeRows))
+	for i, row := range beforeRows {
+		keys[i] = primaryKeyValues(db.Statement.Schema, row)
+	}
+	afterRows, err := p.fetch(db, keys, false)
+	if err != nil {
+		p.fail(db, err)
+		return
+	}
+	afterByID := make(map[string]map[string]any, len(afterRows))
+	for _, row := range afterRows {
+		afterByID[resourceID(db.Statement.Schema, row)] = row
+	}
+	for _, before := range beforeRows {
+		id := resourceID(db.Statement.Schema, before)
+		after, ok := afterByID[id]
+		if !ok {
+			continue
+		}
+		entry := newEntry(ActionUpdate, resource, id, before, after)
+		if len(entry.Diff) == 0 {
+			continue
+		}
+		p.record(db, entry)
+	}
+}
+
+// afterDelete 记录删除的行
+func (p *gormPlugin) afterDelete(db *gorm.DB) {
+	resource := p.resource(db.Statement)
+	if resource == "" || db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	for _, row := range p.beforeRows(db) {
+		p.record(db, newEntry(ActionDelete, resource, resourceID(db.Statement.Schema, row), row, nil))
+	}
+}
+
+// beforeRows 获取修改、删除前的行
+func (p *gormPlugin) beforeRows(db *gorm.DB) []map[string]any {
+	if value, ok := db.InstanceGet(gormBeforeKey); ok {
+		rows, _ := value.([]map[string]any)
+		return rows
+	}
+	return nil
+}
+
+// fetch 读取行，keys为主键值；withWhere为true时同时使用语句的WHERE条件
+// 使用同一连接(事务中读取事务内的数据)，不触发回调；软删除的行与GORM修改时一样不读取
+func (p *gormPlugin) fetch(db *gorm.DB, keys [][]any, withWhere bool) ([]map[string]any, error) {
+	stmt := db.Statement
+	// 需要模型解析WHERE条件中的主键，例: db.Delete(&User{}, 1)
+	tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Model(reflect.New(stmt.Schema.ModelType).Interface()).Table(stmt.Table)
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+
+	hasCondition := false
+	if withWhere {
+		if where, ok := stmt.Clauses["WHERE"]; ok && where.Expression != nil {
+			tx = tx.Clauses(where.Expression)
+			hasCondition = true
+		}
+	}
+	if len(keys) > 0 {
+		columns := make([]clause.Column, len(stmt.Schema.PrimaryFields))
+		for i, field := range stmt.Schema.PrimaryFields {
+			columns[i] = clause.Column{Name: field.DBName}
+		}
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			if len(key) == 1 {
+				values[i] = key[0]
+			} else {
+				values[i] = key
+			}
+		}
+		tx = tx.Clauses(clause.IN{Column: primaryColumn(columns), Values: values})
+		hasCondition = true
+	}
+	// 没有条件的语句会被GORM拒绝执行，不读取全表
+	if !hasCondition {
+		return nil, nil
+	}
+
+	// 多读取一行用于判断是否超出
+	var rows []map[string]any
+	if err := tx.Limit(p.maxRows + 1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) > p.maxRows {
+		rows = rows[:p.maxRows]
+		logger.Named("audit").Warn("audit gorm statement exceeds max rows, remaining rows are not recorded",
+			zap.String("table", stmt.Table), zap.Int("max_rows", p.maxRows))
+	}
+	for _, row := range rows {
+		for key, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[key] = string(b)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// record 写入审计，事务中收集到事务提交时写入
+func (p *gormPlugin) record(db *gorm.DB, entry Entry) {
+	if tx := gormTxOf(db.Statement.ConnPool); tx != nil {
+		tx.add(p.auditor, db.Statement, entry)
+		return
+	}
+	if err := p.auditor.Record(db.Statement.Context, entry); err != nil {
+		p.fail(db, err)
+	}
+}
+
+// fail 审计失败只记录日志，不影响业务语句
+func (p *gormPlugin) fail(db *gorm.DB, err error) {
+	logger.Named("audit").Error("audit gorm statement failed",
+		zap.String("table", db.Statement.Table), zap.Error(err))
+}
+
+// primaryKeys 获取模型中非零的主键值
+func primaryKeys(stmt *gorm.Statement) [][]any {
+	if stmt.Schema == nil || !stmt.ReflectValue.IsValid() {
+		return nil
+	}
+	var keys [][]any
+	collect := func(rv reflect.Value) {
+		key := make([]any, 0, len(stmt.Schema.PrimaryFields))
+		for _, field := range stmt.Schema.PrimaryFields {
+			value, zero := field.ValueOf(stmt.Context, rv)
+			if zero {
+				return
+			}
+			key = append(key, value)
+		}
+		keys = append(keys, key)
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			rv := reflect.Indirect(stmt.ReflectValue.Index(i))
+			if rv.Kind() == reflect.Struct {
+				collect(rv)
+			}
+		}
+	case reflect.Struct:
+		collect(stmt.ReflectValue)
+	}
+	return keys
+}
+
+// primaryKeyValues 获取行中的主键值
+func primaryKeyValues(s *schema.Schema, row map[string]any) []any {
+	values := make([]any, len(s.PrimaryFields))
+	for i, field := range s.PrimaryFields {
+		values[i] = row[field.DBName]
+	}
+	return values
+}
+
+// resourceID 主键值，联合主键以逗号分隔
+func resourceID(s *schema.Schema, row map[string]any) string {
+	values := primaryKeyValues(s, row)
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, ",")
+}
+
+// primaryColumn 单个主键返回列本身，联合主键返回(col1, col2)
+func primaryColumn(columns []clause.Column) any {
+	if len(columns) == 1 {
+		return columns[0]
+	}
+	return clause.Expr{SQL: "(?)", Vars: []any{columns}}
+}
+
+// gormConnPool 包装GORM连接池，开启的事务为gormTx
+type gormConnPool struct {
+	gorm.ConnPool
+}
+
+// BeginTx 开启事务
+func (p *gormConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &gormTx{ConnPool: tx, pool: p}, nil
+}
+
+// GetDBConn 获取底层连接池，用于db.DB()
+func (p *gormConnPool) GetDBConn() (*sql.DB, error) {
+	if sqlDB, ok := p.ConnPool.(*sql.DB); ok {
+		return sqlDB, nil
+	}
+	if connector, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// Ping 检查连接
+func (p *gormConnPool) Ping() error {
+	if pinger, ok := p.ConnPool.(interface{ Ping() error }); ok {
+		return pinger.Ping()
+	}
+	return nil
+}
+
+// gormTx 事务，收集事务中的审计，提交成功后写入，回滚时丢弃
+type gormTx struct {
+	gorm.ConnPool
+	pool *gormConnPool
+
+	mu      sync.Mutex
+	pending []gormPending
+}
+
+// gormPending 等待事务提交的审计
+type gormPending struct {
+	auditor *Auditor
+	ctx     context.Context
+	table   string
+	entry   Entry
+}
+
+// gormTxOf 获取语句所在的事务，不在事务中返回nil
+func gormTxOf(pool gorm.ConnPool) *gormTx {
+	switch tx := pool.(type) {
+	case *gormTx:
+		return tx
+	case *gorm.PreparedStmtTX:
+		return gormTxOf(tx.Tx)
+	}
+	return nil
+}
+
+// add 收集审计，请求信息在收集时确定
+func (t *gormTx) add(auditor *Auditor, stmt *gorm.Statement, entry Entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, gormPending{
+		auditor: auditor,
+		ctx:     context.WithoutCancel(stmt.Context),
+		table:   stmt.Table,
+		entry:   entry,
+	})
+}
+
+// take 取出收集的审计
+func (t *gormTx) take() []gormPending {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending
+	t.pending = nil
+	return pending
+}
+
+// Commit 提交事务，成功后写入收集的审计
+func (t *gormTx) Commit() error {
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	if err := committer.Commit(); err != nil {
+		t.take()
+		return err
+	}
+	for _, pending := range t.take() {
+		if err := pending.auditor.Record(pending.ctx, pending.entry); err != nil {
+			logger.Named("audit").Error("audit gorm statement failed",
+				zap.String("table", pending.table), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Rollback 回滚事务，丢弃收集的审计
+func (t *gormTx) Rollback() error {
+	t.take()
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	return committer.Rollback()
+}
+
+// StmtContext 实现gorm.Tx，开启PrepareStmt时使用
+func (t *gormTx) StmtContext(ctx context.Context, stmt *sql.Stmt) *sql.Stmt {
+	if tx, ok := t.ConnPool.(interface {
+		StmtContext(context.Context, *sql.Stmt) *sql.Stmt
+	}); ok {
+		return tx.StmtContext(ctx, stmt)
+	}
+	return stmt
+}
+
+// GetDBConn 获取底层连接池，用于tx.DB()
+func (t *gormTx) GetDBConn() (*sql.DB, error) {
+	return t.pool.GetDBConn()
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"github.com/boloc/go-frame-server/pkg/frame/pagination"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 注册审计查询接口，例: auditor.RegisterRoutes(adminComponent.Engine().Group("/audit"))
+// GET  /      分页查询，参数见Query
+// GET  /verify?chain=  校验链的完整性，chain为空校验本实例的链
+// GET  /stats  写入统计
+func (a *Auditor) RegisterRoutes(group gin.IRouter) {
+	group.GET("", a.QueryHandler())
+	group.GET("/verify", a.VerifyHandler())
+	group.GET("/stats", func(c *gin.Context) {
+		response.Success(c, a.Stats())
+	})
+}
+
+// QueryHandler 分页查询审计记录
+func (a *Auditor) QueryHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query Query
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.Fail(c, enum.BAD_REQUEST_VALIDATION, err.Error())
+			return
+		}
+		entries, total, err := a.Query(c.Request.Context(), &query)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		page, pageSize := query.GetPageInfo()
+		response.Success(c, pagination.NewPageResponse(entries, total, page, pageSize))
+	}
+}
+
+// VerifyHandler 校验链的完整性
+func (a *Auditor) VerifyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := a.Verify(c.Request.Context(), c.Query("chain"))
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		response.Success(c, result)
+	}
+}
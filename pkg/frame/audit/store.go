@@ -0,0 +1,272 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store 审计记录存储
+type Store interface {
+	// Name 名称
+	Name() string
+	// Last 获取链的最后一条记录的序号和Hash，没有记录时返回0和空字符串
+	Last(ctx context.Context, chain string) (uint64, string, error)
+	// Write 批量写入，失败重试时可能重复写入，需要按(chain, seq)去重
+	Write(ctx context.Context, entries []*Entry) error
+	// Range 按序号顺序读取链中从fromSeq开始的最多limit条记录
+	Range(ctx context.Context, chain string, fromSeq uint64, limit int) ([]*Entry, error)
+	// Query 按条件分页查询，按时间倒序
+	Query(ctx context.Context, query *Query) ([]*Entry, int64, error)
+}
+
+// Query 查询条件，字段为空时不过滤
+type Query struct {
+	pagination.PageRequest
+	Chain      string    `form:"chain" json:"chain"`
+	Actor      string    `form:"actor" json:"actor"`
+	Action     string    `form:"action" json:"action"`
+	Resource   string    `form:"resource" json:"resource"`
+	ResourceID string    `form:"resource_id" json:"resource_id"`
+	RequestID  string    `form:"request_id" json:"request_id"`
+	ClientIP   string    `form:"client_ip" json:"client_ip"`
+	StartTime  time.Time `form:"start_time" json:"start_time" time_format:"2006-01-02 15:04:05"` // 包含
+	EndTime    time.Time `form:"end_time" json:"end_time" time_format:"2006-01-02 15:04:05"`     // 不包含
+}
+
+// conditions 查询条件，列名 => 值
+func (q *Query) conditions() ([]string, []any) {
+	var columns []string
+	var values []any
+	for _, condition := range []struct {
+		column string
+		value  string
+	}{
+		{"chain", q.Chain},
+		{"actor", q.Actor},
+		{"action", q.Action},
+		{"resource", q.Resource},
+		{"resource_id", q.ResourceID},
+		{"request_id", q.RequestID},
+		{"client_ip", q.ClientIP},
+	} {
+		if condition.value != "" {
+			columns = append(columns, condition.column+" = ?")
+			values = append(values, condition.value)
+		}
+	}
+	if !q.StartTime.IsZero() {
+		columns = append(columns, "time >= ?")
+		values = append(values, q.StartTime)
+	}
+	if !q.EndTime.IsZero() {
+		columns = append(columns, "time < ?")
+		values = append(values, q.EndTime)
+	}
+	return columns, values
+}
+
+// MySQLSchema 审计表结构，%s为表名
+const MySQLSchema = "CREATE TABLE IF NOT EXISTS `%s` (" + `
+	chain       VARCHAR(64)  NOT NULL,
+	seq         BIGINT UNSIGNED NOT NULL,
+	time        DATETIME(3)  NOT NULL,
+	actor       VARCHAR(128) NOT NULL DEFAULT '',
+	action      VARCHAR(64)  NOT NULL DEFAULT '',
+	resource    VARCHAR(128) NOT NULL DEFAULT '',
+	resource_id VARCHAR(128) NOT NULL DEFAULT '',
+	` + "`before`" + `      MEDIUMTEXT   NULL,
+	` + "`after`" + `       MEDIUMTEXT   NULL,
+	diff        MEDIUMTEXT   NULL,
+	client_ip   VARCHAR(64)  NOT NULL DEFAULT '',
+	request_id  VARCHAR(128) NOT NULL DEFAULT '',
+	prev_hash   CHAR(64)     NOT NULL DEFAULT '',
+	hash        CHAR(64)     NOT NULL,
+	PRIMARY KEY (chain, seq),
+	KEY idx_time (time),
+	KEY idx_actor (actor, time),
+	KEY idx_resource (resource, resource_id, time),
+	KEY idx_request_id (request_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+
+// mysqlRow 审计表的行
+type mysqlRow struct {
+	Chain      string    `gorm:"column:chain;primaryKey"`
+	Seq        uint64    `gorm:"column:seq;primaryKey;autoIncrement:false"`
+	Time       time.Time `gorm:"column:time"`
+	Actor      string    `gorm:"column:actor"`
+	Action     string    `gorm:"column:action"`
+	Resource   string    `gorm:"column:resource"`
+	ResourceID string    `gorm:"column:resource_id"`
+	Before     *string   `gorm:"column:before"`
+	After      *string   `gorm:"column:after"`
+	Diff       *string   `gorm:"column:diff"`
+	ClientIP   string    `gorm:"column:client_ip"`
+	RequestID  string    `gorm:"column:request_id"`
+	PrevHash   string    `gorm:"column:prev_hash"`
+	Hash       string    `gorm:"column:hash"`
+}
+
+// MySQLStore 使用MySQL主库存储审计记录
+type MySQLStore struct {
+	component *components.MySQLComponent
+	table     string
+}
+
+// NewMySQLStore 创建MySQL存储，table为完整表名(不加前缀)，表结构见MySQLSchema
+func NewMySQLStore(component *components.MySQLComponent, table string) *MySQLStore {
+	return &MySQLStore{component: component, table: table}
+}
+
+// Name 存储名称
+func (s *MySQLStore) Name() string {
+	return s.component.Name() + "/" + s.table
+}
+
+// Table 表名
+func (s *MySQLStore) Table() string {
+	return s.table
+}
+
+// db 获取主库连接，不执行模型钩子；GORM回调仍会执行，审计表的行未实现Auditable，不会被GormPlugin审计
+func (s *MySQLStore) db(ctx context.Context) (*gorm.DB, error) {
+	db := s.component.Master()
+	if db == nil {
+		return nil, fmt.Errorf("%s is not started", s.component.Name())
+	}
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true, Context: ctx}).Table(s.table), nil
+}
+
+// EnsureTable 表不存在时按MySQLSchema创建
+func (s *MySQLStore) EnsureTable(ctx context.Context) error {
+	db, err := s.db(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Exec(fmt.Sprintf(MySQLSchema, s.table)).Error
+}
+
+// Last 获取链的最后一条记录
+func (s *MySQLStore) Last(ctx context.Context, chain string) (uint64, string, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	var row mysqlRow
+	err = db.Select("seq", "hash").Where("chain = ?", chain).Order("seq DESC").Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, "", nil
+	}
+	return row.Seq, row.Hash, err
+}
+
+// Write 批量写入，已存在的(chain, seq)忽略
+func (s *MySQLStore) Write(ctx context.Context, entries []*Entry) error {
+	db, err := s.db(ctx)
+	if err != nil {
+		return err
+	}
+	rows := make([]mysqlRow, len(entries))
+	for i, entry := range entries {
+		rows[i] = mysqlRow{
+			Chain:      entry.Chain,
+			Seq:        entry.Seq,
+			Time:       entry.Time,
+			Actor:      entry.Actor,
+			Action:     entry.Action,
+			Resource:   entry.Resource,
+			ResourceID: entry.ResourceID,
+			Before:     nullableJSON(entry.Before),
+			After:      nullableJSON(entry.After),
+			Diff:       nullableJSON(entry.Diff),
+			ClientIP:   entry.ClientIP,
+			RequestID:  entry.RequestID,
+			PrevHash:   entry.PrevHash,
+			Hash:       entry.Hash,
+		}
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// Range 按序号顺序读取
+func (s *MySQLStore) Range(ctx context.Context, chain string, fromSeq uint64, limit int) ([]*Entry, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rows []mysqlRow
+	if err := db.Where("chain = ? AND seq >= ?", chain, fromSeq).Order("seq").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return mysqlEntries(rows), nil
+}
+
+// Query 分页查询
+func (s *MySQLStore) Query(ctx context.Context, query *Query) ([]*Entry, int64, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	conditions, values := query.conditions()
+	for i, condition := range conditions {
+		db = db.Where(condition, values[i])
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	page, pageSize := query.GetPageInfo()
+	var rows []mysqlRow
+	if err := db.Order("time DESC, seq DESC").Scopes(pagination.Paginate(page, pageSize)).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return mysqlEntries(rows), total, nil
+}
+
+// mysqlEntries 转换为审计记录
+func mysqlEntries(rows []mysqlRow) []*Entry {
+	entries := make([]*Entry, len(rows))
+	for i, row := range rows {
+		entries[i] = &Entry{
+			Chain:      row.Chain,
+			Seq:        row.Seq,
+			Time:       row.Time,
+			Actor:      row.Actor,
+			Action:     row.Action,
+			Resource:   row.Resource,
+			ResourceID: row.ResourceID,
+			Before:     rawJSON(row.Before),
+			After:      rawJSON(row.After),
+			Diff:       rawJSON(row.Diff),
+			ClientIP:   row.ClientIP,
+			RequestID:  row.RequestID,
+			PrevHash:   row.PrevHash,
+			Hash:       row.Hash,
+		}
+	}
+	return entries
+}
+
+// nullableJSON 空值存为NULL
+func nullableJSON(data []byte) *string {
+	if len(data) == 0 {
+		return nil
+	}
+	s := string(data)
+	return &s
+}
+
+// rawJSON NULL读取为空
+func rawJSON(s *string) []byte {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return []byte(*s)
+}
@@ -12,6 +12,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
@@ -25,14 +27,25 @@ const defaultContentType = "application/octet-stream"
 // R2Config 保存 Cloudflare R2 的配置信息
 type R2Config struct {
 	AccountID       string // 账户ID
-	AccessKeyID     string // 访问密钥ID
-	AccessKeySecret string // 访问密钥密钥
+	AccessKeyID     string `log:"mask"` // 访问密钥ID
+	AccessKeySecret string `log:"mask"` // 访问密钥密钥
 	BucketName      string // 存储桶名称
 	Region          string // 区域
 	Endpoint        string // 端点
 	CustomDomain    string // 自定义显示用的域名
 }
 
+// String 打印配置时隐藏密钥
+func (c R2Config) String() string {
+	return fmt.Sprintf("{AccountID:%s AccessKeyID:%s AccessKeySecret:%s BucketName:%s Region:%s Endpoint:%s CustomDomain:%s}",
+		c.AccountID, mask.Placeholder, mask.Placeholder, c.BucketName, c.Region, c.Endpoint, c.CustomDomain)
+}
+
+// GoString 使用%#v打印配置时隐藏密钥
+func (c R2Config) GoString() string {
+	return "client.R2Config" + c.String()
+}
+
 // R2Client 处理 Cloudflare R2 的文件上传
 type R2Client struct {
 	client     *s3.Client
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // ClickHouseConfig ClickHouse配置
@@ -26,10 +27,15 @@ type ClickHouseConfig struct {
 	Debug           bool                    // 调试
 	Protocol        string                  // 协议类型：native 或 http
 	DSN             string                  // 直接设置DSN连接字符串
+	ConnWrappers    []ClickHouseConnWrapper // 连接包装，按注册顺序包装
 }
 
+// ClickHouseConnWrapper 包装连接以拦截查询，component为组件名称，例: 链路追踪tracing.WrapClickHouse
+type ClickHouseConnWrapper func(component string, conn driver.Conn) driver.Conn
+
 // ClickHouseComponent ClickHouse组件
 type ClickHouseComponent struct {
+	name   string
 	conn   driver.Conn // 连接
 	config *ClickHouseConfig
 	mu     sync.RWMutex
@@ -139,6 +145,13 @@ func WithClickHouseDSN(dsn string) ClickHouseOption {
 	}
 }
 
+// WithClickHouseConnWrapper 添加连接包装，GetConn返回包装后的连接
+func WithClickHouseConnWrapper(wrapper ClickHouseConnWrapper) ClickHouseOption {
+	return func(c *ClickHouseConfig) {
+		c.ConnWrappers = append(c.ConnWrappers, wrapper)
+	}
+}
+
 // NewClickHouseComponent 创建ClickHouse组件
 func NewClickHouseComponent(name string, isDefault bool, opts ...ClickHouseOption) *ClickHouseComponent {
 	clickhouseMu.Lock()
@@ -172,6 +185,7 @@ func NewClickHouseComponent(name string, isDefault bool, opts ...ClickHouseOptio
 		}
 
 		c := &ClickHouseComponent{
+			name:   name,
 			config: config,
 		}
 
@@ -269,7 +283,13 @@ func (c *ClickHouseComponent) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to ping ClickHouse: %v", err)
 	}
 
+	// 记录查询耗时
+	conn = &clickhouseMetricsConn{Conn: conn, component: c.Name()}
+	for _, wrap := range c.config.ConnWrappers {
+		conn = wrap(c.Name(), conn)
+	}
 	c.conn = conn
+	addMetricsSource(c.Name(), c)
 	return nil
 }
 
@@ -279,11 +299,37 @@ func (c *ClickHouseComponent) Stop(ctx context.Context) error {
 	defer c.mu.Unlock()
 
 	if c.conn != nil {
+		removeMetricsSource(c.Name())
 		return c.conn.Close()
 	}
 	return nil
 }
 
+// Name 组件名称
+func (c *ClickHouseComponent) Name() string {
+	return "clickhouse/" + c.name
+}
+
+// Health 检查ClickHouse连接
+func (c *ClickHouseComponent) Health(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.conn == nil {
+		return fmt.Errorf("clickhouse [%s] is not started", c.name)
+	}
+	return c.conn.Ping(ctx)
+}
+
+// collectMetrics 输出连接池指标
+func (c *ClickHouseComponent) collectMetrics(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.conn != nil {
+		metrics.collectClickHouseStats(ch, c.conn.Stats(), c.Name())
+	}
+}
+
 // GetConn 获取ClickHouse连接
 func (c *ClickHouseComponent) GetConn() driver.Conn {
 	c.mu.RLock()
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"gorm.io/driver/clickhouse"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -20,10 +21,17 @@ type ClickHouseGORMConfig struct {
 	ConnMaxLifetime time.Duration   // 连接最大生命周期
 	LogLevel        logger.LogLevel // 日志级别
 	Prefix          string          // 表前缀
+	// SQL日志(写入zap，模块名clickhouse)
+	SlowThreshold time.Duration     // 慢查询阈值，0使用默认值200ms，负数不检测
+	RedactParams  bool              // 日志中不输出SQL参数
+	SlowObserver  SlowQueryObserver // 慢查询回调，例: monitor.ObserveSlowQuery
+	// GORM插件，例: 链路追踪tracing.GormPlugin()
+	Plugins []gorm.Plugin
 }
 
 // ClickHouseGORMComponent ClickHouse GORM组件
 type ClickHouseGORMComponent struct {
+	name   string
 	db     *gorm.DB
 	config *ClickHouseGORMConfig
 	mu     sync.RWMutex
@@ -62,6 +70,7 @@ func NewClickHouseGORMComponent(name string, config *ClickHouseGORMConfig, isDef
 		// 打印日志等级
 		fmt.Printf("ClickHouse GORM组件[%s]日志等级: %v\n", name, config.LogLevel)
 		c := &ClickHouseGORMComponent{
+			name:   name,
 			config: config,
 		}
 
@@ -92,7 +101,13 @@ func (c *ClickHouseGORMComponent) Start(ctx context.Context) error {
 
 	// 创建GORM配置
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(c.config.LogLevel),
+		Logger: NewGormLogger("clickhouse", &GormLoggerConfig{
+			Component:     c.Name(),
+			LogLevel:      c.config.LogLevel,
+			SlowThreshold: c.config.SlowThreshold,
+			RedactParams:  c.config.RedactParams,
+			SlowObserver:  c.config.SlowObserver,
+		}),
 	}
 
 	// 判断是否需要前缀
@@ -107,6 +122,15 @@ func (c *ClickHouseGORMComponent) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to connect to ClickHouse: %v", err)
 	}
+	// 记录查询耗时
+	if err := db.Use(gormMetricsPlugin{}); err != nil {
+		return err
+	}
+	for _, plugin := range c.config.Plugins {
+		if err := db.Use(plugin); err != nil {
+			return fmt.Errorf("use plugin %s: %w", plugin.Name(), err)
+		}
+	}
 
 	// 设置连接池
 	sqlDB, err := db.DB()
@@ -123,7 +147,8 @@ func (c *ClickHouseGORMComponent) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to ping ClickHouse: %v", err)
 	}
 
-	c.db = db
+	c.db = withGormInstance(db, c.Name(), GormRoleMaster)
+	addMetricsSource(c.Name(), c)
 
 	// 输出连接信息
 	fmt.Printf("ClickHouse GORM连接成功: maxIdleConn:%d, maxOpenConn:%d\n",
@@ -137,6 +162,7 @@ func (c *ClickHouseGORMComponent) Stop(ctx context.Context) error {
 	defer c.mu.Unlock()
 
 	if c.db != nil {
+		removeMetricsSource(c.Name())
 		sqlDB, err := c.db.DB()
 		if err != nil {
 			return fmt.Errorf("failed to get sql.DB: %v", err)
@@ -149,6 +175,35 @@ func (c *ClickHouseGORMComponent) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Name 组件名称
+func (c *ClickHouseGORMComponent) Name() string {
+	return "clickhouse-gorm/" + c.name
+}
+
+// Health 检查ClickHouse连接
+func (c *ClickHouseGORMComponent) Health(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.db == nil {
+		return fmt.Errorf("clickhouse gorm [%s] is not started", c.name)
+	}
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// collectMetrics 输出连接池指标
+func (c *ClickHouseGORMComponent) collectMetrics(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.db != nil {
+		metrics.collectSQLStats(ch, c.db, c.Name(), GormRoleMaster, 0)
+	}
+}
+
 // DB 获取GORM DB实例
 func (c *ClickHouseGORMComponent) DB() *gorm.DB {
 	c.mu.RLock()
@@ -2,15 +2,26 @@ package components
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/boloc/go-frame-server/pkg/constant"
+	"github.com/boloc/go-frame-server/pkg/frame/realip"
+	"github.com/boloc/go-frame-server/pkg/frame/router"
+	"github.com/boloc/go-frame-server/pkg/frame/sse"
+	"github.com/boloc/go-frame-server/pkg/util/mask"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // GinOption 定义Gin选项函数类型
@@ -18,13 +29,35 @@ type GinOption func(*GinComponent)
 
 // GinComponent Gin组件
 type GinComponent struct {
-	engine *gin.Engine
-	server *http.Server
-	config *GinConfig
+	engine  *gin.Engine
+	servers []*http.Server
+	config  *GinConfig
+	// 证书热加载
+	certReloader *certReloader
+	// 客户端IP解析
+	ipResolver    *realip.Resolver
+	ipResolverErr error
+	// 进行中的SSE流，停止时通知其结束
+	sseHub *sse.Hub
+	// 是否可以接收流量(readiness)
+	ready atomic.Bool
+	// 正在处理的请求数
+	inFlight atomic.Int64
 	// 路由注册函数
 	routerRegistrar func(*gin.Engine)
+	// 路由模块
+	routeModules []router.RouteModule
 	// 全局中间件
 	middlewares []gin.HandlerFunc
+	// 路由是否已注册，停止后重新启动时不再重复注册
+	routesRegistered bool
+	// 注册失败后重试时跳过已完成的步骤，避免重复注册路由
+	registeredSteps map[string]bool
+	// 已挂载的路由模块数及其路由
+	mountedModules int
+	moduleRoutes   []router.RouteInfo
+	// 监听的unix socket文件，停止时删除
+	unixSockets []string
 }
 
 // GinConfig Gin配置
@@ -32,6 +65,26 @@ type GinConfig struct {
 	Port            string
 	Mode            string
 	ShutdownTimeout time.Duration
+	TLS             *GinTLSConfig // TLS配置，为空则使用明文HTTP
+	H2C             bool          // 明文监听是否开启h2c
+	Listeners       []GinListener // 监听地址列表，为空则监听Port
+	TrustedProxies  []string      // 受信任代理(CIDR或IP)，为空则不信任任何代理
+	RemoteIPHeaders []string      // 获取客户端IP的请求头(按顺序)，为空则只使用X-Forwarded-For
+	TrustUnixSocket bool          // 是否信任unix socket连接的请求头
+	// http.Server参数
+	ReadTimeout       time.Duration // 读取整个请求的超时时间
+	ReadHeaderTimeout time.Duration // 读取请求头的超时时间
+	WriteTimeout      time.Duration // 写响应的超时时间
+	IdleTimeout       time.Duration // keep-alive空闲超时时间
+	MaxHeaderBytes    int           // 请求头最大字节数
+	// 优雅关闭
+	PreStopDelay  time.Duration // 停止前等待摘除流量的时间
+	ReadinessPath string        // readiness探针路径
+	LivenessPath  string        // liveness探针路径
+	// 路由
+	VersionHeader  string // 基于请求头的API版本(如 X-API-Version)，为空则只支持路径版本(/v1)
+	DefaultVersion string // 请求头未携带版本时使用的默认版本
+	PrintRoutes    bool   // 启动时是否打印路由表
 }
 
 // GinModeForEnv 根据环境变量设置Gin模式
@@ -69,6 +122,30 @@ func WithGinShutdownTimeout(timeout time.Duration) GinOption {
 	}
 }
 
+// WithGinTrustedProxies 设置受信任代理
+// 只有直连地址属于受信任代理时，才会从X-Forwarded-For等请求头中获取客户端IP
+func WithGinTrustedProxies(proxies ...string) GinOption {
+	return func(g *GinComponent) {
+		g.config.TrustedProxies = append(g.config.TrustedProxies, proxies...)
+	}
+}
+
+// WithGinRemoteIPHeaders 设置获取客户端IP的请求头(按顺序检查)，默认只使用X-Forwarded-For
+// CF-Connecting-IP、X-Real-IP、Forwarded只能在受信任代理都会覆盖该请求头时开启
+// 支持 X-Forwarded-For、X-Real-IP、CF-Connecting-IP、Forwarded(RFC 7239)
+func WithGinRemoteIPHeaders(headers ...string) GinOption {
+	return func(g *GinComponent) {
+		g.config.RemoteIPHeaders = headers
+	}
+}
+
+// WithGinTrustUnixSocket 设置是否信任unix socket连接的请求头(socket文件只允许本机代理访问时开启)
+func WithGinTrustUnixSocket(trust bool) GinOption {
+	return func(g *GinComponent) {
+		g.config.TrustUnixSocket = trust
+	}
+}
+
 // WithGinRouter 设置路由注册函数
 func WithGinRouter(routerRegistrar func(*gin.Engine)) GinOption {
 	return func(g *GinComponent) {
@@ -76,6 +153,30 @@ func WithGinRouter(routerRegistrar func(*gin.Engine)) GinOption {
 	}
 }
 
+// WithGinRouteModules 添加路由模块
+// 除此之外，通过router.Register全局注册的模块也会在启动时挂载
+func WithGinRouteModules(modules ...router.RouteModule) GinOption {
+	return func(g *GinComponent) {
+		g.routeModules = append(g.routeModules, modules...)
+	}
+}
+
+// WithGinVersionHeader 开启基于请求头的API版本路由
+// 例: header为"X-API-Version"时，GET /orders + X-API-Version: v2 等同于 GET /v2/orders
+func WithGinVersionHeader(header, defaultVersion string) GinOption {
+	return func(g *GinComponent) {
+		g.config.VersionHeader = header
+		g.config.DefaultVersion = defaultVersion
+	}
+}
+
+// WithGinPrintRoutes 设置启动时是否打印路由表
+func WithGinPrintRoutes(print bool) GinOption {
+	return func(g *GinComponent) {
+		g.config.PrintRoutes = print
+	}
+}
+
 // WithGinMiddleware 添加全局中间件
 func WithGinMiddleware(middleware ...gin.HandlerFunc) GinOption {
 	return func(g *GinComponent) {
@@ -98,8 +199,18 @@ func NewGinComponent(opts ...GinOption) *GinComponent {
 			Port:            "8080",
 			Mode:            gin.DebugMode,
 			ShutdownTimeout: 5 * time.Second,
+			// 默认超时，防止慢连接耗尽资源
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+			ReadinessPath:     DefaultReadinessPath,
+			LivenessPath:      DefaultLivenessPath,
+			PrintRoutes:       true,
 		},
 		middlewares: make([]gin.HandlerFunc, 0),
+		sseHub:      sse.NewHub(),
 	}
 
 	for _, opt := range opts {
@@ -112,12 +223,24 @@ func NewGinComponent(opts ...GinOption) *GinComponent {
 	g.engine = gin.New()
 
 	if g.config.Mode == gin.DebugMode {
-		// 添加日志中间件
-		g.engine.Use(gin.Logger())
+		// 添加日志中间件，路径中的查询参数脱敏
+		g.engine.Use(gin.LoggerWithFormatter(maskedLogFormatter))
 	}
 	// 添加恢复中间件，但不添加日志中间件
 	g.engine.Use(gin.Recovery())
 
+	// 解析客户端真实IP
+	g.ipResolver, g.ipResolverErr = realip.NewResolver(g.config.TrustedProxies, g.config.RemoteIPHeaders,
+		realip.WithTrustUnixSocket(g.config.TrustUnixSocket))
+	if g.ipResolverErr == nil {
+		g.engine.Use(realip.Middleware(g.ipResolver))
+		// gin默认还会读取X-Real-IP，保持c.ClientIP()与解析器使用相同的请求头
+		g.engine.RemoteIPHeaders = g.ipResolver.Headers()
+	}
+
+	// 登记SSE流，停止时通知其结束
+	g.engine.Use(sse.Middleware(g.sseHub))
+
 	// 应用用户配置的中间件
 	if len(g.middlewares) > 0 {
 		g.engine.Use(g.middlewares...)
@@ -128,44 +251,300 @@ func NewGinComponent(opts ...GinOption) *GinComponent {
 
 // Start 启动Gin组件
 func (g *GinComponent) Start(ctx context.Context) error {
-	// 注册路由
-	if g.routerRegistrar != nil {
-		g.routerRegistrar(g.engine)
+	// 注册路由，路由冲突时启动失败
+	if !g.routesRegistered {
+		if err := g.registerRoutes(); err != nil {
+			return err
+		}
+		g.routesRegistered = true
 	}
 
-	// 在端口前面拼接":"
-	serverPort := ":" + g.config.Port
-	// 创建HTTP服务器
-	g.server = &http.Server{
-		Addr:    serverPort, // 设置监听地址
-		Handler: g.engine,   // 设置处理请求的handler
+	// 受信任代理配置错误时直接启动失败
+	if g.ipResolverErr != nil {
+		return g.ipResolverErr
+	}
+	// 同步设置gin的受信任代理，保证c.ClientIP()与解析结果一致
+	if err := g.engine.SetTrustedProxies(g.config.TrustedProxies); err != nil {
+		return fmt.Errorf("failed to set trusted proxies: %v", err)
 	}
 
-	// 设置受信任的代理
-	g.engine.SetTrustedProxies([]string{"0.0.0.0/0"})
+	// 重启时重新接受SSE流
+	g.sseHub.Reset()
+
+	listeners := g.listeners()
+
+	// 有TLS监听时加载证书
+	var tlsConfig *tls.Config
+	for _, l := range listeners {
+		if l.TLS {
+			conf, err := g.buildTLSConfig()
+			if err != nil {
+				return err
+			}
+			tlsConfig = conf
+			break
+		}
+	}
+
+	// 先完成所有端口的监听，任意一个失败则启动失败
+	type serving struct {
+		listener net.Listener
+		server   *http.Server
+		conf     GinListener
+	}
+	servings := make([]serving, 0, len(listeners))
+	closeAll := func() {
+		for _, s := range servings {
+			_ = s.listener.Close()
+			// 删除本次创建的unix socket文件
+			if s.conf.Network == ListenerNetworkUnix {
+				_ = os.Remove(s.conf.Address)
+			}
+		}
+		if g.certReloader != nil {
+			g.certReloader.close()
+			g.certReloader = nil
+		}
+	}
+	for _, l := range listeners {
+		ln, err := listen(l)
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("failed to listen on %s %s: %v", l.Network, l.Address, err)
+		}
+		servings = append(servings, serving{
+			listener: ln,
+			server:   g.newServer(l, tlsConfig, listeners),
+			conf:     l,
+		})
+	}
 
 	// 启动HTTP服务器
-	go func() {
-		//启动服务
-		fmt.Printf("server start - port %s\n", serverPort)
-		if err := g.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Printf("Gin server error: %v", err)
+	g.ready.Store(true)
+	for _, s := range servings {
+		g.servers = append(g.servers, s.server)
+		if s.conf.Network == ListenerNetworkUnix {
+			g.unixSockets = append(g.unixSockets, s.conf.Address)
 		}
-	}()
+		go func(s serving) {
+			//启动服务
+			fmt.Printf("server start - %s %s%s\n", s.conf.Network, s.conf.Address, listenerDesc(s.conf))
+			var err error
+			if s.conf.TLS {
+				err = s.server.ServeTLS(s.listener, "", "")
+			} else {
+				err = s.server.Serve(s.listener)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Gin server error: %v", err)
+			}
+		}(s)
+	}
+
+	return nil
+}
+
+// registerRoutes 注册健康检查、路由函数及路由模块
+// 失败后再次调用(如重新Start)时跳过已完成的步骤和已挂载的模块，从失败处继续
+func (g *GinComponent) registerRoutes() error {
+	if g.registeredSteps == nil {
+		g.registeredSteps = make(map[string]bool)
+	}
+	step := func(name string, register func()) error {
+		if g.registeredSteps[name] {
+			return nil
+		}
+		if err := router.Safe(name, register); err != nil {
+			return err
+		}
+		g.registeredSteps[name] = true
+		return nil
+	}
+
+	// 注册健康检查路由
+	if err := step("health", g.registerHealthRoutes); err != nil {
+		return err
+	}
+
+	// 注册路由
+	if g.routerRegistrar != nil {
+		if err := step("router", func() { g.routerRegistrar(g.engine) }); err != nil {
+			return err
+		}
+	}
+
+	// 挂载路由模块，全局注册的模块只追加一次
+	if !g.registeredSteps["modules"] {
+		g.routeModules = append(g.routeModules, router.Modules()...)
+		g.registeredSteps["modules"] = true
+	}
+	for g.mountedModules < len(g.routeModules) {
+		routes, err := router.Mount(g.engine, g.routeModules[g.mountedModules])
+		if err != nil {
+			return err
+		}
+		g.moduleRoutes = append(g.moduleRoutes, routes...)
+		g.mountedModules++
+	}
 
+	if g.config.PrintRoutes {
+		router.PrintRoutes(g.engine, g.moduleRoutes)
+	}
 	return nil
 }
 
-// Stop 停止Gin组件
+// handler 获取处理请求的handler
+func (g *GinComponent) handler() http.Handler {
+	var handler http.Handler = g.engine
+	if g.config.VersionHeader != "" {
+		handler = router.VersionHeaderHandler(handler, g.config.VersionHeader, g.config.DefaultVersion, g.routeModules)
+	}
+	return g.trackInFlight(handler)
+}
+
+// listeners 获取监听列表，未配置时按Port生成默认监听
+func (g *GinComponent) listeners() []GinListener {
+	if len(g.config.Listeners) == 0 {
+		return []GinListener{{
+			Network: ListenerNetworkTCP,
+			Address: ":" + g.config.Port, // 在端口前面拼接":"
+			TLS:     g.config.TLS != nil,
+		}}
+	}
+
+	listeners := make([]GinListener, 0, len(g.config.Listeners))
+	for _, l := range g.config.Listeners {
+		if l.Network == "" {
+			l.Network = ListenerNetworkTCP
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners
+}
+
+// newServer 根据监听配置创建HTTP服务器
+func (g *GinComponent) newServer(l GinListener, tlsConfig *tls.Config, all []GinListener) *http.Server {
+	var handler http.Handler
+	switch {
+	case l.RedirectHTTPS:
+		// 跳转端口未设置时，使用第一个TLS监听的端口
+		port := l.RedirectPort
+		if port == "" {
+			for _, other := range all {
+				if other.TLS && other.Network == ListenerNetworkTCP {
+					port = listenerPort(other.Address)
+					break
+				}
+			}
+		}
+		handler = redirectHTTPSHandler(port)
+	case !l.TLS && g.config.H2C:
+		handler = h2c.NewHandler(g.handler(), &http2.Server{})
+	default:
+		handler = g.handler()
+	}
+
+	server := &http.Server{
+		Addr:              l.Address,                  // 设置监听地址
+		Handler:           handler,                    // 设置处理请求的handler
+		ReadTimeout:       g.config.ReadTimeout,       // 读取超时时间
+		ReadHeaderTimeout: g.config.ReadHeaderTimeout, // 读取请求头超时时间
+		WriteTimeout:      g.config.WriteTimeout,      // 写响应超时时间
+		IdleTimeout:       g.config.IdleTimeout,       // 空闲超时时间
+		MaxHeaderBytes:    g.config.MaxHeaderBytes,    // 请求头最大字节数
+	}
+	if l.TLS {
+		server.TLSConfig = tlsConfig.Clone()
+		if g.config.TLS.DisableHTTP2 {
+			// 非nil的空map会关闭HTTP/2自动协商
+			server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		}
+	}
+	return server
+}
+
+// listenerDesc 监听描述
+func listenerDesc(l GinListener) string {
+	switch {
+	case l.RedirectHTTPS:
+		return " (redirect https)"
+	case l.TLS:
+		return " (tls)"
+	default:
+		return ""
+	}
+}
+
+// Stop 停止Gin组件，停止后可以重新启动
 func (g *GinComponent) Stop(ctx context.Context) error {
-	fmt.Printf("server stop - port %s\n", g.config.Port)
-	if g.server != nil {
-		// 创建带超时的上下文
-		shutdownCtx, cancel := context.WithTimeout(ctx, g.config.ShutdownTimeout)
-		defer cancel()
+	if g.certReloader != nil {
+		g.certReloader.close()
+		g.certReloader = nil
+	}
+	if len(g.servers) == 0 {
+		return nil
+	}
+	defer func() {
+		// 删除unix socket文件，下次启动重新创建
+		for _, path := range g.unixSockets {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("Gin remove unix socket(%s) failed: %v", path, err)
+			}
+		}
+		g.servers, g.unixSockets = nil, nil
+	}()
+
+	// readiness置为失败，等待负载均衡摘除流量
+	g.drain(ctx)
+
+	// 创建带超时的上下文
+	shutdownCtx, cancel := context.WithTimeout(ctx, g.config.ShutdownTimeout)
+	defer cancel()
+
+	// 关闭过程中输出剩余请求数
+	done := make(chan struct{})
+	defer close(done)
+	go g.reportInFlight(done)
 
-		// 优雅关闭
-		return g.server.Shutdown(shutdownCtx)
+	// 通知SSE等长连接结束，否则Shutdown会一直等待到超时
+	if err := g.sseHub.Shutdown(shutdownCtx); err != nil {
+		fmt.Printf("server stop - %d sse streams not finished: %v\n", g.sseHub.Active(), err)
+	}
+
+	// 优雅关闭所有监听
+	var (
+		wg      sync.WaitGroup
+		errMu   sync.Mutex
+		lastErr error
+	)
+	for _, server := range g.servers {
+		fmt.Printf("server stop - %s\n", server.Addr)
+		wg.Add(1)
+		go func(server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				errMu.Lock()
+				lastErr = err
+				errMu.Unlock()
+			}
+		}(server)
+	}
+	wg.Wait()
+	if inFlight := g.InFlight(); inFlight > 0 {
+		fmt.Printf("server stop - %d requests still in-flight after shutdown timeout\n", inFlight)
+	}
+	return lastErr
+}
+
+// Name 组件名称
+func (g *GinComponent) Name() string {
+	return "gin"
+}
+
+// Health 检查是否可以接收流量
+func (g *GinComponent) Health(ctx context.Context) error {
+	if !g.IsReady() {
+		return errors.New("gin server is not ready")
 	}
 	return nil
 }
@@ -174,3 +553,26 @@ func (g *GinComponent) Stop(ctx context.Context) error {
 func (g *GinComponent) GetEngine() *gin.Engine {
 	return g.engine
 }
+
+// maskedLogFormatter 与gin默认格式相同，路径中的敏感查询参数(token、password等)和手机号、邮箱等脱敏
+func maskedLogFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		mask.Default().URL(param.Path),
+		param.ErrorMessage,
+	)
+}
@@ -0,0 +1,134 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认健康检查路径
+const (
+	DefaultReadinessPath = "/readyz"
+	DefaultLivenessPath  = "/livez"
+)
+
+// WithGinReadTimeout 设置读取整个请求(含请求体)的超时时间
+func WithGinReadTimeout(timeout time.Duration) GinOption {
+	return func(g *GinComponent) {
+		g.config.ReadTimeout = timeout
+	}
+}
+
+// WithGinReadHeaderTimeout 设置读取请求头的超时时间(防止slowloris攻击)
+func WithGinReadHeaderTimeout(timeout time.Duration) GinOption {
+	return func(g *GinComponent) {
+		g.config.ReadHeaderTimeout = timeout
+	}
+}
+
+// WithGinWriteTimeout 设置写响应的超时时间
+func WithGinWriteTimeout(timeout time.Duration) GinOption {
+	return func(g *GinComponent) {
+		g.config.WriteTimeout = timeout
+	}
+}
+
+// WithGinIdleTimeout 设置keep-alive空闲连接超时时间
+func WithGinIdleTimeout(timeout time.Duration) GinOption {
+	return func(g *GinComponent) {
+		g.config.IdleTimeout = timeout
+	}
+}
+
+// WithGinMaxHeaderBytes 设置请求头最大字节数
+func WithGinMaxHeaderBytes(maxHeaderBytes int) GinOption {
+	return func(g *GinComponent) {
+		g.config.MaxHeaderBytes = maxHeaderBytes
+	}
+}
+
+// WithGinPreStopDelay 设置停止前的等待时间
+// Stop时先将readiness置为失败，等待负载均衡摘除流量后再关闭服务
+func WithGinPreStopDelay(delay time.Duration) GinOption {
+	return func(g *GinComponent) {
+		g.config.PreStopDelay = delay
+	}
+}
+
+// WithGinHealthPath 设置readiness/liveness探针路径，为空则不注册
+func WithGinHealthPath(readinessPath, livenessPath string) GinOption {
+	return func(g *GinComponent) {
+		g.config.ReadinessPath = readinessPath
+		g.config.LivenessPath = livenessPath
+	}
+}
+
+// IsReady 是否可以接收流量
+func (g *GinComponent) IsReady() bool {
+	return g.ready.Load()
+}
+
+// InFlight 当前正在处理的请求数
+func (g *GinComponent) InFlight() int64 {
+	return g.inFlight.Load()
+}
+
+// registerHealthRoutes 注册健康检查路由
+func (g *GinComponent) registerHealthRoutes() {
+	if g.config.ReadinessPath != "" {
+		g.engine.GET(g.config.ReadinessPath, func(c *gin.Context) {
+			if !g.IsReady() {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining", "in_flight": g.InFlight()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"status": "ready"})
+		})
+	}
+	if g.config.LivenessPath != "" {
+		g.engine.GET(g.config.LivenessPath, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "alive"})
+		})
+	}
+}
+
+// trackInFlight 统计正在处理的请求数
+func (g *GinComponent) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.inFlight.Add(1)
+		defer g.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// drain 摘除流量并等待preStop时间
+func (g *GinComponent) drain(ctx context.Context) {
+	g.ready.Store(false)
+	if g.config.PreStopDelay <= 0 {
+		return
+	}
+
+	fmt.Printf("server draining - wait %s before shutdown, in-flight %d\n", g.config.PreStopDelay, g.InFlight())
+	timer := time.NewTimer(g.config.PreStopDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// reportInFlight 关闭过程中定期输出剩余请求数，直到done关闭
+func (g *GinComponent) reportInFlight(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			fmt.Printf("server draining - in-flight %d\n", g.InFlight())
+		}
+	}
+}
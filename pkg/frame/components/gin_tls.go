@@ -0,0 +1,276 @@
+package components
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 监听类型
+const (
+	ListenerNetworkTCP  = "tcp"
+	ListenerNetworkUnix = "unix"
+)
+
+// GinTLSConfig TLS配置
+type GinTLSConfig struct {
+	CertFile string // 证书文件
+	KeyFile  string // 私钥文件
+	// 客户端证书校验(mTLS)
+	ClientCAFile     string // 客户端CA证书文件，为空则不校验客户端证书
+	ClientCertVerify bool   // true: 必须提供并校验客户端证书 false: 客户端提供时才校验
+	MinVersion       uint16 // 最低TLS版本，默认TLS1.2
+	DisableHTTP2     bool   // 是否关闭HTTP/2(默认TLS下自动协商h2)
+}
+
+// GinListener 监听地址配置
+type GinListener struct {
+	Network string // tcp 或 unix
+	Address string // tcp: ":8443" unix: "/var/run/app.sock"
+	TLS     bool   // 是否使用TLS(需要配置证书)
+	// 是否作为HTTP->HTTPS跳转入口(为true时不处理业务路由，仅返回301跳转)
+	RedirectHTTPS bool
+	// 跳转目标端口，为空时使用第一个TLS监听的端口
+	RedirectPort string
+}
+
+// WithGinTLS 设置TLS证书，证书文件变化时自动重新加载
+func WithGinTLS(certFile, keyFile string) GinOption {
+	return func(g *GinComponent) {
+		if g.config.TLS == nil {
+			g.config.TLS = &GinTLSConfig{}
+		}
+		g.config.TLS.CertFile = certFile
+		g.config.TLS.KeyFile = keyFile
+	}
+}
+
+// WithGinClientCA 设置客户端CA证书(mTLS)
+// required为true时客户端必须提供合法证书，否则仅在提供时校验
+func WithGinClientCA(caFile string, required bool) GinOption {
+	return func(g *GinComponent) {
+		if g.config.TLS == nil {
+			g.config.TLS = &GinTLSConfig{}
+		}
+		g.config.TLS.ClientCAFile = caFile
+		g.config.TLS.ClientCertVerify = required
+	}
+}
+
+// WithGinTLSConfig 直接设置完整的TLS配置
+func WithGinTLSConfig(tlsConfig *GinTLSConfig) GinOption {
+	return func(g *GinComponent) {
+		g.config.TLS = tlsConfig
+	}
+}
+
+// WithGinH2C 设置是否开启h2c(明文HTTP/2)，适用于内网服务间调用
+func WithGinH2C(enable bool) GinOption {
+	return func(g *GinComponent) {
+		g.config.H2C = enable
+	}
+}
+
+// WithGinListeners 设置监听地址，设置后将忽略Port配置
+func WithGinListeners(listeners ...GinListener) GinOption {
+	return func(g *GinComponent) {
+		g.config.Listeners = append(g.config.Listeners, listeners...)
+	}
+}
+
+// certReloader 证书热加载
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	watcher  *fsnotify.Watcher
+	done     chan struct{}
+	mu       sync.RWMutex
+}
+
+// newCertReloader 创建证书热加载器
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		done:     make(chan struct{}),
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload 重新加载证书
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls certificate: %v", err)
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate 实现tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// watch 监听证书文件变化
+// PS:监听的是证书所在目录，兼容k8s secret通过软链接整体替换的方式
+func (r *certReloader) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create certificate watcher: %v", err)
+	}
+
+	dirs := map[string]struct{}{
+		filepath.Dir(r.certFile): {},
+		filepath.Dir(r.keyFile):  {},
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return fmt.Errorf("failed to watch certificate dir(%s): %v", dir, err)
+		}
+	}
+	r.watcher = watcher
+
+	go func() {
+		for {
+			select {
+			case <-r.done:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// 只关心写入、创建、重命名事件
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+				if err := r.reload(); err != nil {
+					// 新证书可能还未写完，保留旧证书，等待下一次事件
+					log.Printf("Gin tls certificate reload failed: %v", err)
+					continue
+				}
+				fmt.Printf("server tls certificate reloaded - %s\n", r.certFile)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Gin tls certificate watcher error: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// close 停止监听
+func (r *certReloader) close() {
+	select {
+	case <-r.done:
+		return
+	default:
+		close(r.done)
+	}
+	if r.watcher != nil {
+		_ = r.watcher.Close()
+	}
+}
+
+// buildTLSConfig 根据配置构建tls.Config
+func (g *GinComponent) buildTLSConfig() (*tls.Config, error) {
+	conf := g.config.TLS
+	if conf == nil || conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, fmt.Errorf("tls listener requires cert file and key file")
+	}
+
+	minVersion := conf.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	tlsConfig := &tls.Config{MinVersion: minVersion}
+
+	// 配置客户端证书校验(mTLS)，在启动证书监听之前完成，失败时不需要清理
+	if conf.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(conf.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client ca file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("failed to parse client ca file: %s", conf.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		if conf.ClientCertVerify {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	reloader, err := newCertReloader(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := reloader.watch(); err != nil {
+		return nil, err
+	}
+	g.certReloader = reloader
+	tlsConfig.GetCertificate = reloader.GetCertificate
+
+	return tlsConfig, nil
+}
+
+// listen 创建监听
+func listen(l GinListener) (net.Listener, error) {
+	switch l.Network {
+	case "", ListenerNetworkTCP:
+		return net.Listen(ListenerNetworkTCP, l.Address)
+	case ListenerNetworkUnix:
+		// 清理上次异常退出残留的socket文件
+		if err := os.Remove(l.Address); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale unix socket(%s): %v", l.Address, err)
+		}
+		return net.Listen(ListenerNetworkUnix, l.Address)
+	default:
+		return nil, fmt.Errorf("unsupported listener network: %s", l.Network)
+	}
+}
+
+// redirectHTTPSHandler HTTP跳转HTTPS
+func redirectHTTPSHandler(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
+
+// listenerPort 获取监听地址中的端口
+func listenerPort(address string) string {
+	if _, port, err := net.SplitHostPort(address); err == nil {
+		return port
+	}
+	return strings.TrimPrefix(address, ":")
+}
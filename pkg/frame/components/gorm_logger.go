@@ -0,0 +1,170 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	frameLogger "github.com/boloc/go-frame-server/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// DefaultSlowThreshold 默认慢查询阈值
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// SlowQueryObserver 慢查询回调，例: monitor.ObserveSlowQuery
+type SlowQueryObserver func(component, table, operation string, elapsed time.Duration)
+
+// GormLoggerConfig GORM日志配置
+type GormLoggerConfig struct {
+	Component     string            // 组件名称，如 mysql/frame_server
+	LogLevel      logger.LogLevel   // GORM日志级别
+	SlowThreshold time.Duration     // 慢查询阈值，0使用默认值，负数不检测
+	RedactParams  bool              // 日志中不输出SQL参数，只输出占位符
+	SlowObserver  SlowQueryObserver // 慢查询回调
+}
+
+// gormLogger 将GORM日志写入zap，带上请求ID
+type gormLogger struct {
+	config *GormLoggerConfig
+	log    *zap.Logger
+}
+
+// NewGormLogger 创建GORM日志适配器，module为日志模块名(logger.Named)，可通过模块日志级别单独调整
+// SQL按GORM日志级别输出: 普通SQL为info，慢查询为warn，错误为error
+func NewGormLogger(module string, config *GormLoggerConfig) logger.Interface {
+	if config.SlowThreshold == 0 {
+		config.SlowThreshold = DefaultSlowThreshold
+	}
+	return &gormLogger{
+		config: config,
+		// 调用位置由GORM计算，zap的调用位置指向GORM内部没有意义
+		log: frameLogger.Named(module).WithOptions(zap.WithCaller(false)),
+	}
+}
+
+// LogMode 设置日志级别
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	config := *l.config
+	config.LogLevel = level
+	return &gormLogger{config: &config, log: l.log}
+}
+
+// Info 输出info日志
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.config.LogLevel >= logger.Info {
+		l.log.Info(fmt.Sprintf(msg, data...), l.fields(ctx)...)
+	}
+}
+
+// Warn 输出warn日志
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.config.LogLevel >= logger.Warn {
+		l.log.Warn(fmt.Sprintf(msg, data...), l.fields(ctx)...)
+	}
+}
+
+// Error 输出error日志
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.config.LogLevel >= logger.Error {
+		l.log.Error(fmt.Sprintf(msg, data...), l.fields(ctx)...)
+	}
+}
+
+// Trace 输出SQL，慢查询按表和操作类型回调
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	slow := l.config.SlowThreshold > 0 && elapsed >= l.config.SlowThreshold
+
+	// 慢查询统计不受日志级别影响
+	var (
+		sql  string
+		rows int64
+		done bool
+	)
+	if slow && l.config.SlowObserver != nil {
+		sql, rows = fc()
+		done = true
+		operation, table := ParseSQLTable(sql)
+		l.config.SlowObserver(l.config.Component, table, operation, elapsed)
+	}
+
+	var level logger.LogLevel
+	switch {
+	case err != nil && !errors.Is(err, logger.ErrRecordNotFound):
+		level = logger.Error
+	case slow:
+		level = logger.Warn
+	default:
+		level = logger.Info
+	}
+	if l.config.LogLevel < level {
+		return
+	}
+	if !done {
+		sql, rows = fc()
+	}
+
+	fields := append(l.fields(ctx),
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Float64("elapsed_ms", float64(elapsed.Nanoseconds())/1e6),
+		zap.String("source", utils.FileWithLineNum()),
+	)
+	switch level {
+	case logger.Error:
+		l.log.Error("sql error", append(fields, zap.Error(err))...)
+	case logger.Warn:
+		l.log.Warn("slow sql", append(fields, zap.Duration("threshold", l.config.SlowThreshold))...)
+	default:
+		l.log.Info("sql", fields...)
+	}
+}
+
+// ParamsFilter 开启参数脱敏时SQL只保留占位符
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if l.config.RedactParams {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// fields 公共字段
+func (l *gormLogger) fields(ctx context.Context) []zap.Field {
+	fields := make([]zap.Field, 0, 6)
+	fields = append(fields, zap.String("component", l.config.Component))
+	if requestID := content.RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	return append(fields, frameLogger.ContextFields(ctx)...)
+}
+
+// sqlTablePattern 匹配SQL中的表名
+var sqlTablePattern = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE|JOIN|TABLE)\\s+([`\"\\w.]+)")
+
+// ParseSQLTable 从SQL中解析操作类型和表名，解析不到时为unknown
+func ParseSQLTable(sql string) (operation, table string) {
+	operation, table = "unknown", "unknown"
+	trimmed := strings.TrimSpace(sql)
+	if fields := strings.Fields(trimmed); len(fields) > 0 {
+		operation = strings.ToLower(fields[0])
+	}
+	if match := sqlTablePattern.FindStringSubmatch(trimmed); match != nil {
+		name := strings.NewReplacer("`", "", `"`, "").Replace(match[1])
+		// 去掉库名
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if name != "" {
+			table = name
+		}
+	}
+	return operation, table
+}
@@ -0,0 +1,330 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	frameLogger "github.com/boloc/go-frame-server/pkg/logger"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 组件指标，组件启动时自动加入、停止时移除
+// 连接池指标在采集时读取，查询耗时在每次查询后记录
+var (
+	metricsMu         sync.Mutex
+	metricsRegisterer prometheus.Registerer = prometheus.DefaultRegisterer
+	metricsRegistered bool
+	metricsSources    sync.Map // 组件名称 => metricsSource
+	metrics           = newComponentCollector()
+)
+
+// metricsSource 提供连接池指标的组件
+type metricsSource interface {
+	collectMetrics(ch chan<- prometheus.Metric)
+}
+
+// SetMetricsRegisterer 设置组件指标的注册器，默认为Prometheus全局注册器，nil不注册
+// 已注册时从原注册器移除，例: 指标组件使用独立的Registry
+func SetMetricsRegisterer(registerer prometheus.Registerer) error {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if metricsRegistered {
+		metricsRegisterer.Unregister(metrics)
+		metricsRegistered = false
+	}
+	metricsRegisterer = registerer
+	return registerMetricsLocked()
+}
+
+// addMetricsSource 组件启动后加入指标采集
+func addMetricsSource(name string, source metricsSource) {
+	metricsSources.Store(name, source)
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if err := registerMetricsLocked(); err != nil {
+		frameLogger.Named("metrics").Warn("register component metrics failed", zap.String("component", name), zap.Error(err))
+	}
+}
+
+// removeMetricsSource 组件停止后移除指标采集
+func removeMetricsSource(name string) {
+	metricsSources.Delete(name)
+}
+
+// registerMetricsLocked 注册到当前注册器，需持有metricsMu
+func registerMetricsLocked() error {
+	if metricsRegistered || metricsRegisterer == nil {
+		return nil
+	}
+	if err := metricsRegisterer.Register(metrics); err != nil {
+		return err
+	}
+	metricsRegistered = true
+	return nil
+}
+
+// componentCollector 组件连接池和查询耗时指标
+type componentCollector struct {
+	// database/sql连接池(MySQL主从库、ClickHouse GORM)
+	sqlMaxOpen      *prometheus.Desc
+	sqlOpen         *prometheus.Desc
+	sqlInUse        *prometheus.Desc
+	sqlIdle         *prometheus.Desc
+	sqlWaitCount    *prometheus.Desc
+	sqlWaitDuration *prometheus.Desc
+	sqlClosed       *prometheus.Desc
+	// go-redis连接池
+	redisHits       *prometheus.Desc
+	redisMisses     *prometheus.Desc
+	redisTimeouts   *prometheus.Desc
+	redisTotalConns *prometheus.Desc
+	redisIdleConns  *prometheus.Desc
+	redisStaleConns *prometheus.Desc
+	// ClickHouse原生连接池
+	clickhouseMaxOpen *prometheus.Desc
+	clickhouseMaxIdle *prometheus.Desc
+	clickhouseOpen    *prometheus.Desc
+	clickhouseIdle    *prometheus.Desc
+	// 查询耗时
+	queryDuration *prometheus.HistogramVec
+	redisDuration *prometheus.HistogramVec
+}
+
+// newComponentCollector 创建组件指标
+func newComponentCollector() *componentCollector {
+	sqlLabels := []string{"component", "role", "index"}
+	poolLabels := []string{"component"}
+	buckets := []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+	return &componentCollector{
+		sqlMaxOpen:      prometheus.NewDesc("db_pool_max_open_connections", "数据库连接池最大连接数", sqlLabels, nil),
+		sqlOpen:         prometheus.NewDesc("db_pool_open_connections", "数据库连接池当前连接数(使用中+空闲)", sqlLabels, nil),
+		sqlInUse:        prometheus.NewDesc("db_pool_in_use_connections", "数据库连接池使用中的连接数", sqlLabels, nil),
+		sqlIdle:         prometheus.NewDesc("db_pool_idle_connections", "数据库连接池空闲连接数", sqlLabels, nil),
+		sqlWaitCount:    prometheus.NewDesc("db_pool_wait_total", "数据库连接池等待连接的次数", sqlLabels, nil),
+		sqlWaitDuration: prometheus.NewDesc("db_pool_wait_duration_seconds_total", "数据库连接池等待连接的总时间（秒）", sqlLabels, nil),
+		sqlClosed:       prometheus.NewDesc("db_pool_closed_total", "数据库连接池关闭的连接数，reason为max_idle/max_idle_time/max_lifetime", append(sqlLabels, "reason"), nil),
+
+		redisHits:       prometheus.NewDesc("redis_pool_hits_total", "Redis连接池命中空闲连接的次数", poolLabels, nil),
+		redisMisses:     prometheus.NewDesc("redis_pool_misses_total", "Redis连接池没有空闲连接的次数", poolLabels, nil),
+		redisTimeouts:   prometheus.NewDesc("redis_pool_timeouts_total", "Redis连接池等待连接超时的次数", poolLabels, nil),
+		redisTotalConns: prometheus.NewDesc("redis_pool_total_connections", "Redis连接池当前连接数", poolLabels, nil),
+		redisIdleConns:  prometheus.NewDesc("redis_pool_idle_connections", "Redis连接池空闲连接数", poolLabels, nil),
+		redisStaleConns: prometheus.NewDesc("redis_pool_stale_connections_total", "Redis连接池移除的失效连接数", poolLabels, nil),
+
+		clickhouseMaxOpen: prometheus.NewDesc("clickhouse_pool_max_open_connections", "ClickHouse连接池最大连接数", poolLabels, nil),
+		clickhouseMaxIdle: prometheus.NewDesc("clickhouse_pool_max_idle_connections", "ClickHouse连接池最大空闲连接数", poolLabels, nil),
+		clickhouseOpen:    prometheus.NewDesc("clickhouse_pool_open_connections", "ClickHouse连接池当前连接数", poolLabels, nil),
+		clickhouseIdle:    prometheus.NewDesc("clickhouse_pool_idle_connections", "ClickHouse连接池空闲连接数", poolLabels, nil),
+
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "数据库查询耗时（秒），operation为GORM操作类型或SQL的第一个单词",
+			Buckets: buckets,
+		}, []string{"component", "role", "operation"}),
+		redisDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "redis_command_duration_seconds",
+			Help:    "Redis命令耗时（秒）",
+			Buckets: buckets,
+		}, []string{"component", "command"}),
+	}
+}
+
+// Describe 实现prometheus.Collector
+func (c *componentCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.sqlMaxOpen, c.sqlOpen, c.sqlInUse, c.sqlIdle, c.sqlWaitCount, c.sqlWaitDuration, c.sqlClosed,
+		c.redisHits, c.redisMisses, c.redisTimeouts, c.redisTotalConns, c.redisIdleConns, c.redisStaleConns,
+		c.clickhouseMaxOpen, c.clickhouseMaxIdle, c.clickhouseOpen, c.clickhouseIdle,
+	} {
+		ch <- desc
+	}
+	c.queryDuration.Describe(ch)
+	c.redisDuration.Describe(ch)
+}
+
+// Collect 实现prometheus.Collector
+func (c *componentCollector) Collect(ch chan<- prometheus.Metric) {
+	metricsSources.Range(func(_, value any) bool {
+		value.(metricsSource).collectMetrics(ch)
+		return true
+	})
+	c.queryDuration.Collect(ch)
+	c.redisDuration.Collect(ch)
+}
+
+// collectSQLStats 输出database/sql连接池指标
+func (c *componentCollector) collectSQLStats(ch chan<- prometheus.Metric, db *gorm.DB, component, role string, index int) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	stats := sqlDB.Stats()
+	labels := []string{component, role, strconv.Itoa(index)}
+	ch <- prometheus.MustNewConstMetric(c.sqlMaxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections), labels...)
+	ch <- prometheus.MustNewConstMetric(c.sqlOpen, prometheus.GaugeValue, float64(stats.OpenConnections), labels...)
+	ch <- prometheus.MustNewConstMetric(c.sqlInUse, prometheus.GaugeValue, float64(stats.InUse), labels...)
+	ch <- prometheus.MustNewConstMetric(c.sqlIdle, prometheus.GaugeValue, float64(stats.Idle), labels...)
+	ch <- prometheus.MustNewConstMetric(c.sqlWaitCount, prometheus.CounterValue, float64(stats.WaitCount), labels...)
+	ch <- prometheus.MustNewConstMetric(c.sqlWaitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), labels...)
+	for reason, value := range map[string]int64{
+		"max_idle":      stats.MaxIdleClosed,
+		"max_idle_time": stats.MaxIdleTimeClosed,
+		"max_lifetime":  stats.MaxLifetimeClosed,
+	} {
+		ch <- prometheus.MustNewConstMetric(c.sqlClosed, prometheus.CounterValue, float64(value), append(labels, reason)...)
+	}
+}
+
+// collectRedisStats 输出go-redis连接池指标
+func (c *componentCollector) collectRedisStats(ch chan<- prometheus.Metric, stats *redis.PoolStats, component string) {
+	ch <- prometheus.MustNewConstMetric(c.redisHits, prometheus.CounterValue, float64(stats.Hits), component)
+	ch <- prometheus.MustNewConstMetric(c.redisMisses, prometheus.CounterValue, float64(stats.Misses), component)
+	ch <- prometheus.MustNewConstMetric(c.redisTimeouts, prometheus.CounterValue, float64(stats.Timeouts), component)
+	ch <- prometheus.MustNewConstMetric(c.redisTotalConns, prometheus.GaugeValue, float64(stats.TotalConns), component)
+	ch <- prometheus.MustNewConstMetric(c.redisIdleConns, prometheus.GaugeValue, float64(stats.IdleConns), component)
+	ch <- prometheus.MustNewConstMetric(c.redisStaleConns, prometheus.CounterValue, float64(stats.StaleConns), component)
+}
+
+// collectClickHouseStats 输出ClickHouse原生连接池指标
+func (c *componentCollector) collectClickHouseStats(ch chan<- prometheus.Metric, stats driver.Stats, component string) {
+	ch <- prometheus.MustNewConstMetric(c.clickhouseMaxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConns), component)
+	ch <- prometheus.MustNewConstMetric(c.clickhouseMaxIdle, prometheus.GaugeValue, float64(stats.MaxIdleConns), component)
+	ch <- prometheus.MustNewConstMetric(c.clickhouseOpen, prometheus.GaugeValue, float64(stats.Open), component)
+	ch <- prometheus.MustNewConstMetric(c.clickhouseIdle, prometheus.GaugeValue, float64(stats.Idle), component)
+}
+
+// gormMetricsPlugin 记录GORM语句耗时，组件创建连接时自动注册
+type gormMetricsPlugin struct{}
+
+// instance中保存语句开始时间的key
+const gormMetricsStartKey = "frame:metrics_start"
+
+// Name 插件名称
+func (gormMetricsPlugin) Name() string {
+	return "frame:metrics"
+}
+
+// Initialize 注册回调
+func (p gormMetricsPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("frame:metrics_before_create", p.before),
+		callbacks.Create().After("gorm:create").Register("frame:metrics_after_create", p.after("create")),
+		callbacks.Query().Before("gorm:query").Register("frame:metrics_before_query", p.before),
+		callbacks.Query().After("gorm:query").Register("frame:metrics_after_query", p.after("query")),
+		callbacks.Update().Before("gorm:update").Register("frame:metrics_before_update", p.before),
+		callbacks.Update().After("gorm:update").Register("frame:metrics_after_update", p.after("update")),
+		callbacks.Delete().Before("gorm:delete").Register("frame:metrics_before_delete", p.before),
+		callbacks.Delete().After("gorm:delete").Register("frame:metrics_after_delete", p.after("delete")),
+		callbacks.Row().Before("gorm:row").Register("frame:metrics_before_row", p.before),
+		callbacks.Row().After("gorm:row").Register("frame:metrics_after_row", p.after("row")),
+		callbacks.Raw().Before("gorm:raw").Register("frame:metrics_before_raw", p.before),
+		callbacks.Raw().After("gorm:raw").Register("frame:metrics_after_raw", p.after("raw")),
+	)
+}
+
+// before 记录开始时间
+func (gormMetricsPlugin) before(db *gorm.DB) {
+	db.InstanceSet(gormMetricsStartKey, time.Now())
+}
+
+// after 记录耗时，DryRun等未执行的语句不记录
+func (gormMetricsPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormMetricsStartKey)
+		if !ok || db.DryRun {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		component, role := GormInstance(db)
+		metrics.queryDuration.WithLabelValues(component, role, operation).Observe(time.Since(start).Seconds())
+	}
+}
+
+// redisMetricsHook 记录Redis命令耗时，组件启动时自动添加
+type redisMetricsHook struct {
+	component string
+}
+
+// DialHook 不记录建立连接
+func (h redisMetricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 记录单条命令耗时
+func (h redisMetricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		metrics.redisDuration.WithLabelValues(h.component, cmd.Name()).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// ProcessPipelineHook 记录pipeline耗时，命令为pipeline
+func (h redisMetricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		metrics.redisDuration.WithLabelValues(h.component, "pipeline").Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// clickhouseMetricsConn 记录ClickHouse原生连接的查询耗时，组件启动时自动包装
+// Query只统计到返回结果集为止，不包含读取结果的时间
+type clickhouseMetricsConn struct {
+	driver.Conn
+	component string
+}
+
+// observe 记录耗时
+func (c *clickhouseMetricsConn) observe(query string, start time.Time) {
+	operation := "unknown"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToLower(fields[0])
+	}
+	metrics.queryDuration.WithLabelValues(c.component, "", operation).Observe(time.Since(start).Seconds())
+}
+
+// Select 查询到结构体切片
+func (c *clickhouseMetricsConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	defer c.observe(query, time.Now())
+	return c.Conn.Select(ctx, dest, query, args...)
+}
+
+// Query 查询
+func (c *clickhouseMetricsConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	defer c.observe(query, time.Now())
+	return c.Conn.Query(ctx, query, args...)
+}
+
+// QueryRow 查询单行
+func (c *clickhouseMetricsConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	defer c.observe(query, time.Now())
+	return c.Conn.QueryRow(ctx, query, args...)
+}
+
+// Exec 执行
+func (c *clickhouseMetricsConn) Exec(ctx context.Context, query string, args ...any) error {
+	defer c.observe(query, time.Now())
+	return c.Conn.Exec(ctx, query, args...)
+}
+
+// AsyncInsert 异步写入
+func (c *clickhouseMetricsConn) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	defer c.observe(query, time.Now())
+	return c.Conn.AsyncInsert(ctx, query, wait, args...)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/boloc/go-frame-server/pkg/constant"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -23,6 +24,36 @@ type MySQLConfig struct {
 	ConnMaxLifetime time.Duration
 	LogLevel        logger.LogLevel
 	Prefix          string
+	// SQL日志(写入zap，模块名mysql)
+	SlowThreshold time.Duration     // 慢查询阈值，0使用默认值200ms，负数不检测
+	RedactParams  bool              // 日志中不输出SQL参数
+	SlowObserver  SlowQueryObserver // 慢查询回调，例: monitor.ObserveSlowQuery
+	// GORM插件，主库和从库均注册，例: 审计auditor.GormPlugin()
+	Plugins []gorm.Plugin
+}
+
+// GORM连接上保存的组件信息，插件回调中通过GormInstance读取，例: 链路追踪、监控区分主从库
+const (
+	GormComponentKey = "frame:component" // 组件名称，如 mysql/frame_server
+	GormRoleKey      = "frame:role"      // 连接角色
+	GormRoleMaster   = "master"          // 主库
+	GormRoleReplica  = "replica"         // 从库
+)
+
+// withGormInstance 在连接上保存组件名称和角色，返回的连接每次调用都会复制这些设置
+func withGormInstance(db *gorm.DB, component, role string) *gorm.DB {
+	return db.Set(GormComponentKey, component).Set(GormRoleKey, role).Session(&gorm.Session{})
+}
+
+// GormInstance 获取连接所属的组件名称和角色，不是组件创建的连接(或使用了NewDB会话)时返回空
+func GormInstance(db *gorm.DB) (component, role string) {
+	if value, ok := db.Get(GormComponentKey); ok {
+		component, _ = value.(string)
+	}
+	if value, ok := db.Get(GormRoleKey); ok {
+		role, _ = value.(string)
+	}
+	return component, role
 }
 
 // GormLogLevelForEnv 根据环境变量设置Gorm日志级别
@@ -45,6 +76,7 @@ func GormLogLevelForEnv(env string) logger.LogLevel {
 
 // MySQLComponent MySQL组件
 type MySQLComponent struct {
+	name     string
 	master   *gorm.DB
 	replicas []*gorm.DB
 	config   *MySQLConfig
@@ -83,6 +115,7 @@ func NewMySQLComponent(name string, config *MySQLConfig, isDefault bool) *MySQLC
 		}
 
 		m := &MySQLComponent{
+			name:   name,
 			config: config,
 		}
 
@@ -112,7 +145,7 @@ func (m *MySQLComponent) Start(ctx context.Context) error {
 	}
 
 	// 连接主库
-	master, err := m.connectDB(m.config.MasterDSN)
+	master, err := m.connectDB(m.config.MasterDSN, GormRoleMaster)
 	if err != nil {
 		return fmt.Errorf("failed to connect to master: %v", err)
 	}
@@ -120,20 +153,27 @@ func (m *MySQLComponent) Start(ctx context.Context) error {
 
 	// 连接从库们
 	for _, slaveDSN := range m.config.SlavesDSN {
-		replica, err := m.connectDB(slaveDSN)
+		replica, err := m.connectDB(slaveDSN, GormRoleReplica)
 		if err != nil {
 			return fmt.Errorf("failed to connect to slave(%s): %v", slaveDSN, err)
 		}
 		m.replicas = append(m.replicas, replica)
 	}
 
+	addMetricsSource(m.Name(), m)
 	return nil
 }
 
-// connectDB 连接数据库
-func (m *MySQLComponent) connectDB(dsn string) (*gorm.DB, error) {
+// connectDB 连接数据库，role为master或replica
+func (m *MySQLComponent) connectDB(dsn, role string) (*gorm.DB, error) {
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(m.config.LogLevel),
+		Logger: NewGormLogger("mysql", &GormLoggerConfig{
+			Component:     m.Name(),
+			LogLevel:      m.config.LogLevel,
+			SlowThreshold: m.config.SlowThreshold,
+			RedactParams:  m.config.RedactParams,
+			SlowObserver:  m.config.SlowObserver,
+		}),
 	}
 	// 判断是否需要前缀
 	if m.config.Prefix != "" {
@@ -146,6 +186,15 @@ func (m *MySQLComponent) connectDB(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	// 记录查询耗时
+	if err := db.Use(gormMetricsPlugin{}); err != nil {
+		return nil, err
+	}
+	for _, plugin := range m.config.Plugins {
+		if err := db.Use(plugin); err != nil {
+			return nil, fmt.Errorf("use plugin %s: %w", plugin.Name(), err)
+		}
+	}
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
@@ -158,13 +207,14 @@ func (m *MySQLComponent) connectDB(dsn string) (*gorm.DB, error) {
 	if err := sqlDB.Ping(); err != nil {
 		return nil, err
 	}
-	return db, nil
+	return withGormInstance(db, m.Name(), role), nil
 }
 
 // Stop 停止MySQL组件
 func (m *MySQLComponent) Stop(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	removeMetricsSource(m.Name())
 
 	// 关闭主库
 	if m.master != nil {
@@ -182,6 +232,65 @@ func (m *MySQLComponent) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Name 组件名称
+func (m *MySQLComponent) Name() string {
+	return "mysql/" + m.name
+}
+
+// Health 检查主库及所有从库连接
+func (m *MySQLComponent) Health(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.master == nil {
+		return fmt.Errorf("mysql [%s] is not started", m.name)
+	}
+	dbs := append([]*gorm.DB{m.master}, m.replicas...)
+	for i, db := range dbs {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			if i == 0 {
+				return fmt.Errorf("master: %v", err)
+			}
+			return fmt.Errorf("replica %d: %v", i-1, err)
+		}
+	}
+	return nil
+}
+
+// collectMetrics 输出主库和各从库的连接池指标
+func (m *MySQLComponent) collectMetrics(ch chan<- prometheus.Metric) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.master != nil {
+		metrics.collectSQLStats(ch, m.master, m.Name(), GormRoleMaster, 0)
+	}
+	for i, replica := range m.replicas {
+		metrics.collectSQLStats(ch, replica, m.Name(), GormRoleReplica, i)
+	}
+}
+
+// Use 注册GORM插件(主库和从库)，已启动时立即生效，例: mysqlComponent.Use(auditor.GormPlugin())
+func (m *MySQLComponent) Use(plugins ...gorm.Plugin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Plugins = append(m.config.Plugins, plugins...)
+	if m.master == nil {
+		return nil
+	}
+	for _, db := range append([]*gorm.DB{m.master}, m.replicas...) {
+		for _, plugin := range plugins {
+			if err := db.Use(plugin); err != nil {
+				return fmt.Errorf("use plugin %s: %w", plugin.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
 // Master 获取主库连接
 func (m *MySQLComponent) Master() *gorm.DB {
 	m.mu.RLock()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -16,8 +17,17 @@ type RedisOption func(*RedisComponent)
 
 // RedisComponent Redis组件
 type RedisComponent struct {
+	name   string
 	client *redis.Client
 	config *redis.Options
+	hooks  []redis.Hook
+}
+
+// WithRedisName 设置实例名称，用于组件名称和指标的component标签，默认为"地址/数据库"
+func WithRedisName(name string) RedisOption {
+	return func(r *RedisComponent) {
+		r.name = name
+	}
 }
 
 // WithRedisAddr 设置Redis地址
@@ -97,6 +107,13 @@ func WithRedisMaxRetryBackoff(maxRetryBackoff time.Duration) RedisOption {
 	}
 }
 
+// WithRedisHooks 添加命令钩子，例: 链路追踪tracing.RedisHook()
+func WithRedisHooks(hooks ...redis.Hook) RedisOption {
+	return func(r *RedisComponent) {
+		r.hooks = append(r.hooks, hooks...)
+	}
+}
+
 // NewRedisComponent 创建Redis组件
 func NewRedisComponent(opts ...RedisOption) *RedisComponent {
 	r := &RedisComponent{
@@ -114,6 +131,9 @@ func NewRedisComponent(opts ...RedisOption) *RedisComponent {
 	for _, opt := range opts {
 		opt(r)
 	}
+	if r.name == "" {
+		r.name = fmt.Sprintf("%s/%d", r.config.Addr, r.config.DB)
+	}
 	GlobalRedisComponent = r
 	return r
 }
@@ -121,21 +141,55 @@ func NewRedisComponent(opts ...RedisOption) *RedisComponent {
 // Start 启动Redis组件
 func (r *RedisComponent) Start(ctx context.Context) error {
 	r.client = redis.NewClient(r.config)
+	r.client.AddHook(redisMetricsHook{component: r.Name()})
+	for _, hook := range r.hooks {
+		r.client.AddHook(hook)
+	}
 	if err := r.client.Ping(ctx).Err(); err != nil {
 		return fmt.Errorf("failed to connect to redis: %v", err)
 	}
+	addMetricsSource(r.Name(), r)
 	return nil
 }
 
 // Stop 停止Redis组件
 func (r *RedisComponent) Stop(ctx context.Context) error {
 	if r.client != nil {
+		removeMetricsSource(r.Name())
 		return r.client.Close()
 	}
 	return nil
 }
 
+// Name 组件名称，例: redis/localhost:6379/0
+func (r *RedisComponent) Name() string {
+	return "redis/" + r.name
+}
+
+// Health 检查Redis连接
+func (r *RedisComponent) Health(ctx context.Context) error {
+	if r.client == nil {
+		return fmt.Errorf("redis is not started")
+	}
+	return r.client.Ping(ctx).Err()
+}
+
+// collectMetrics 输出连接池指标
+func (r *RedisComponent) collectMetrics(ch chan<- prometheus.Metric) {
+	if r.client != nil {
+		metrics.collectRedisStats(ch, r.client.PoolStats(), r.Name())
+	}
+}
+
 // GetClient 获取Redis客户端
 func (r *RedisComponent) GetClient() *redis.Client {
 	return r.client
 }
+
+// UniversalClient 获取通用Redis客户端(与集群组件使用同一接口)
+func (r *RedisComponent) UniversalClient() redis.UniversalClient {
+	if r.client == nil {
+		return nil
+	}
+	return r.client
+}
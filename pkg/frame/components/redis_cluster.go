@@ -3,8 +3,10 @@ package components
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -16,8 +18,17 @@ type RedisClusterOption func(*RedisClusterComponent)
 
 // RedisClusterComponent Redis集群组件
 type RedisClusterComponent struct {
+	name   string
 	client *redis.ClusterClient
 	config *redis.ClusterOptions
+	hooks  []redis.Hook
+}
+
+// WithClusterName 设置实例名称，用于组件名称和指标的component标签，默认为逗号分隔的集群地址
+func WithClusterName(name string) RedisClusterOption {
+	return func(r *RedisClusterComponent) {
+		r.name = name
+	}
 }
 
 // WithClusterAddrs 设置Redis集群地址
@@ -104,6 +115,13 @@ func WithClusterMaxRetryBackoff(maxRetryBackoff time.Duration) RedisClusterOptio
 	}
 }
 
+// WithClusterHooks 添加命令钩子，例: 链路追踪tracing.RedisHook()
+func WithClusterHooks(hooks ...redis.Hook) RedisClusterOption {
+	return func(r *RedisClusterComponent) {
+		r.hooks = append(r.hooks, hooks...)
+	}
+}
+
 // NewRedisClusterComponent 创建Redis集群组件
 func NewRedisClusterComponent(opts ...RedisClusterOption) *RedisClusterComponent {
 	r := &RedisClusterComponent{
@@ -120,6 +138,9 @@ func NewRedisClusterComponent(opts ...RedisClusterOption) *RedisClusterComponent
 	for _, opt := range opts {
 		opt(r)
 	}
+	if r.name == "" {
+		r.name = strings.Join(r.config.Addrs, ",")
+	}
 
 	GlobalRedisClusterComponent = r
 	return r
@@ -128,22 +149,64 @@ func NewRedisClusterComponent(opts ...RedisClusterOption) *RedisClusterComponent
 // Start 启动Redis集群组件
 func (r *RedisClusterComponent) Start(ctx context.Context) error {
 	r.client = redis.NewClusterClient(r.config)
+	r.client.AddHook(redisMetricsHook{component: r.Name()})
+	for _, hook := range r.hooks {
+		r.client.AddHook(hook)
+	}
 	if err := r.client.Ping(ctx).Err(); err != nil {
 		fmt.Println("打印错误ctx", ctx)
 		return fmt.Errorf("failed to connect to redis cluster: %v", err)
 	}
+	addMetricsSource(r.Name(), r)
 	return nil
 }
 
 // Stop 停止Redis集群组件
 func (r *RedisClusterComponent) Stop(ctx context.Context) error {
 	if r.client != nil {
+		removeMetricsSource(r.Name())
 		return r.client.Close()
 	}
 	return nil
 }
 
+// Name 组件名称，例: redis-cluster/10.0.0.1:7000,10.0.0.2:7000
+func (r *RedisClusterComponent) Name() string {
+	return "redis-cluster/" + r.name
+}
+
+// Health 检查Redis集群所有主节点连接
+func (r *RedisClusterComponent) Health(ctx context.Context) error {
+	if r.client == nil {
+		return fmt.Errorf("redis cluster is not started")
+	}
+	return r.client.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// collectMetrics 输出连接池指标(所有节点汇总)
+func (r *RedisClusterComponent) collectMetrics(ch chan<- prometheus.Metric) {
+	if r.client != nil {
+		metrics.collectRedisStats(ch, r.client.PoolStats(), r.Name())
+	}
+}
+
 // GetClient 获取Redis集群客户端
 func (r *RedisClusterComponent) GetClient() *redis.ClusterClient {
 	return r.client
 }
+
+// UniversalClient 获取通用Redis客户端(与单机组件使用同一接口)
+func (r *RedisClusterComponent) UniversalClient() redis.UniversalClient {
+	if r.client == nil {
+		return nil
+	}
+	return r.client
+}
+
+// RedisProvider Redis客户端提供者，RedisComponent和RedisClusterComponent均已实现
+// 依赖Redis的组件在Start时再获取客户端，因此需要在Redis组件之后注册
+type RedisProvider interface {
+	UniversalClient() redis.UniversalClient
+}
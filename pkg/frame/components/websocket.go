@@ -0,0 +1,664 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/realip"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/frame/router"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// 全局WebSocket组件
+var GlobalWebSocketComponent *WebSocketComponent
+
+// ErrWebSocketUnauthorized 未通过认证的连接请求
+var ErrWebSocketUnauthorized = errors.New("websocket unauthorized")
+
+// WebSocketOption 定义WebSocket选项函数类型
+type WebSocketOption func(*WebSocketComponent)
+
+// WebSocketAuthenticator 连接认证函数，返回连接的用户标识，返回错误(如ErrWebSocketUnauthorized)则拒绝升级
+type WebSocketAuthenticator func(c *gin.Context) (identity string, err error)
+
+// WebSocketRoomAuthorizer 房间订阅授权函数，返回false则拒绝客户端订阅该房间
+type WebSocketRoomAuthorizer func(client *WebSocketClient, room string) bool
+
+// WebSocketMessageHandler 客户端消息处理函数
+type WebSocketMessageHandler func(client *WebSocketClient, messageType int, data []byte)
+
+// WebSocketConfig WebSocket配置
+type WebSocketConfig struct {
+	Path            string        // 挂载路径
+	ReadBufferSize  int           // 读缓冲区大小
+	WriteBufferSize int           // 写缓冲区大小
+	MaxMessageSize  int64         // 客户端单条消息最大字节数
+	SendBufferSize  int           // 每个连接的发送队列长度
+	PingInterval    time.Duration // 心跳间隔
+	PongTimeout     time.Duration // 心跳超时时间，超时未收到pong则断开
+	WriteTimeout    time.Duration // 单次写入超时时间
+	DropOnFull      bool          // 发送队列满时丢弃消息，默认断开慢连接
+	AllowAnonymous  bool          // 是否允许未认证连接
+	RedisChannel    string        // 跨实例广播的Redis频道
+}
+
+// WebSocketComponent WebSocket组件
+// 连接按房间(主题)分组，通过Redis发布订阅在多个实例之间广播消息
+type WebSocketComponent struct {
+	config        *WebSocketConfig
+	upgrader      websocket.Upgrader
+	authenticator WebSocketAuthenticator
+	authorizeRoom WebSocketRoomAuthorizer
+	onMessage     WebSocketMessageHandler
+	onConnect     func(client *WebSocketClient)
+	onDisconnect  func(client *WebSocketClient)
+	redis         RedisProvider
+	nodeID        string
+
+	mu      sync.RWMutex
+	clients map[string]*WebSocketClient            // 连接ID -> 连接
+	rooms   map[string]map[string]*WebSocketClient // 房间 -> 连接
+	users   map[string]map[string]*WebSocketClient // 用户标识 -> 连接
+
+	pubsub  *redis.PubSub
+	closing atomic.Bool
+	wg      sync.WaitGroup
+	dropped atomic.Int64
+}
+
+// WebSocketClient WebSocket连接
+type WebSocketClient struct {
+	ID       string
+	Identity string // 认证后的用户标识
+	ClientIP string
+
+	hub       *WebSocketComponent
+	conn      *websocket.Conn
+	send      chan wsFrame
+	rooms     map[string]struct{}
+	mu        sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// wsFrame 待发送的消息
+type wsFrame struct {
+	messageType int
+	data        []byte
+}
+
+// wsEnvelope 跨实例广播的消息
+type wsEnvelope struct {
+	Node        string `json:"node"`
+	Room        string `json:"room,omitempty"`
+	User        string `json:"user,omitempty"`
+	MessageType int    `json:"type"`
+	Data        []byte `json:"data"`
+}
+
+// wsCommand 默认消息处理支持的订阅命令
+type wsCommand struct {
+	Action string `json:"action"` // subscribe/unsubscribe
+	Room   string `json:"room"`
+}
+
+// WithWebSocketPath 设置挂载路径
+func WithWebSocketPath(path string) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.config.Path = path
+	}
+}
+
+// WithWebSocketBufferSize 设置读写缓冲区大小
+func WithWebSocketBufferSize(readBufferSize, writeBufferSize int) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.config.ReadBufferSize = readBufferSize
+		w.config.WriteBufferSize = writeBufferSize
+	}
+}
+
+// WithWebSocketMaxMessageSize 设置客户端单条消息最大字节数
+func WithWebSocketMaxMessageSize(size int64) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.config.MaxMessageSize = size
+	}
+}
+
+// WithWebSocketSendBuffer 设置每个连接的发送队列长度及队列满时的处理方式
+// dropOnFull为true时丢弃新消息，否则断开消费过慢的连接
+func WithWebSocketSendBuffer(size int, dropOnFull bool) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.config.SendBufferSize = size
+		w.config.DropOnFull = dropOnFull
+	}
+}
+
+// WithWebSocketHeartbeat 设置心跳间隔和超时时间
+func WithWebSocketHeartbeat(pingInterval, pongTimeout time.Duration) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.config.PingInterval = pingInterval
+		w.config.PongTimeout = pongTimeout
+	}
+}
+
+// WithWebSocketWriteTimeout 设置单次写入超时时间
+func WithWebSocketWriteTimeout(timeout time.Duration) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.config.WriteTimeout = timeout
+	}
+}
+
+// WithWebSocketCheckOrigin 设置跨域校验，默认仅允许同源
+func WithWebSocketCheckOrigin(checkOrigin func(r *http.Request) bool) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.upgrader.CheckOrigin = checkOrigin
+	}
+}
+
+// WithWebSocketAuthenticator 设置连接认证函数，默认使用RequestContext中的用户标识
+func WithWebSocketAuthenticator(authenticator WebSocketAuthenticator) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.authenticator = authenticator
+	}
+}
+
+// WithWebSocketRoomAuthorizer 设置客户端订阅房间的授权函数
+// 默认拒绝所有客户端发起的订阅，服务端调用Join加入房间不受影响
+// 例: 只允许订阅自己的房间 func(client *WebSocketClient, room string) bool { return room == "user:"+client.Identity }
+func WithWebSocketRoomAuthorizer(authorizer WebSocketRoomAuthorizer) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.authorizeRoom = authorizer
+	}
+}
+
+// WithWebSocketAllowAnonymous 允许未认证的连接
+func WithWebSocketAllowAnonymous(allow bool) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.config.AllowAnonymous = allow
+	}
+}
+
+// WithWebSocketOnMessage 设置客户端消息处理函数
+// 默认处理 {"action":"subscribe|unsubscribe","room":"..."} 订阅命令，订阅需通过WithWebSocketRoomAuthorizer授权
+func WithWebSocketOnMessage(handler WebSocketMessageHandler) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.onMessage = handler
+	}
+}
+
+// WithWebSocketOnConnect 设置连接建立回调
+func WithWebSocketOnConnect(fn func(client *WebSocketClient)) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.onConnect = fn
+	}
+}
+
+// WithWebSocketOnDisconnect 设置连接断开回调
+func WithWebSocketOnDisconnect(fn func(client *WebSocketClient)) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.onDisconnect = fn
+	}
+}
+
+// WithWebSocketRedis 设置跨实例广播使用的Redis(RedisComponent或RedisClusterComponent)
+// 未设置时仅在本实例内广播
+func WithWebSocketRedis(provider RedisProvider, channel string) WebSocketOption {
+	return func(w *WebSocketComponent) {
+		w.redis = provider
+		if channel != "" {
+			w.config.RedisChannel = channel
+		}
+	}
+}
+
+// NewWebSocketComponent 创建WebSocket组件
+func NewWebSocketComponent(opts ...WebSocketOption) *WebSocketComponent {
+	w := &WebSocketComponent{
+		config: &WebSocketConfig{
+			Path:            "/ws",
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			MaxMessageSize:  64 << 10,
+			SendBufferSize:  256,
+			PingInterval:    30 * time.Second,
+			PongTimeout:     60 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			RedisChannel:    "frame:websocket",
+		},
+		nodeID:  uuid.NewString(),
+		clients: make(map[string]*WebSocketClient),
+		rooms:   make(map[string]map[string]*WebSocketClient),
+		users:   make(map[string]map[string]*WebSocketClient),
+	}
+	w.authenticator = defaultWebSocketAuthenticator
+	w.authorizeRoom = denyWebSocketRoom
+	w.onMessage = w.handleCommand
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.upgrader.ReadBufferSize = w.config.ReadBufferSize
+	w.upgrader.WriteBufferSize = w.config.WriteBufferSize
+	GlobalWebSocketComponent = w
+	return w
+}
+
+// defaultWebSocketAuthenticator 使用认证中间件写入RequestContext的用户标识
+func defaultWebSocketAuthenticator(c *gin.Context) (string, error) {
+	if rc := content.FromContext(c.Request.Context()); rc != nil {
+		return rc.Identity(), nil
+	}
+	return "", nil
+}
+
+// denyWebSocketRoom 默认的房间授权，拒绝客户端订阅
+func denyWebSocketRoom(*WebSocketClient, string) bool {
+	return false
+}
+
+// Module 获取挂载WebSocket的路由模块，通过WithGinRouteModules挂载到GinComponent
+func (w *WebSocketComponent) Module(middlewares ...gin.HandlerFunc) router.RouteModule {
+	return router.NewModule("websocket", w.config.Path, func(group *gin.RouterGroup) {
+		group.GET("", w.Handler())
+	}, router.WithMiddleware(middlewares...))
+}
+
+// Start 启动WebSocket组件，配置了Redis时订阅跨实例广播频道
+func (w *WebSocketComponent) Start(ctx context.Context) error {
+	w.closing.Store(false)
+	if w.redis == nil {
+		return nil
+	}
+	client := w.redis.UniversalClient()
+	if client == nil {
+		return fmt.Errorf("websocket redis client is not started, register redis component before websocket")
+	}
+
+	w.pubsub = client.Subscribe(ctx, w.config.RedisChannel)
+	if _, err := w.pubsub.Receive(ctx); err != nil {
+		_ = w.pubsub.Close()
+		return fmt.Errorf("failed to subscribe websocket channel: %v", err)
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for msg := range w.pubsub.Channel() {
+			var env wsEnvelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				continue
+			}
+			w.deliver(env)
+		}
+	}()
+	return nil
+}
+
+// Stop 停止WebSocket组件，向所有连接发送关闭帧后断开
+func (w *WebSocketComponent) Stop(ctx context.Context) error {
+	w.closing.Store(true)
+	if w.pubsub != nil {
+		_ = w.pubsub.Close()
+	}
+
+	w.mu.RLock()
+	clients := make([]*WebSocketClient, 0, len(w.clients))
+	for _, client := range w.clients {
+		clients = append(clients, client)
+	}
+	w.mu.RUnlock()
+	for _, client := range clients {
+		client.Close(websocket.CloseGoingAway, "server shutdown")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("websocket stop: %d connections not closed: %w", w.Count(), ctx.Err())
+	}
+}
+
+// Name 组件名称
+func (w *WebSocketComponent) Name() string {
+	return "websocket"
+}
+
+// Handler 获取WebSocket升级处理函数
+func (w *WebSocketComponent) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if w.closing.Load() {
+			response.Abort(c, enum.SERVICE_UNAVAILABLE)
+			return
+		}
+
+		identity, err := w.authenticator(c)
+		if err != nil || (identity == "" && !w.config.AllowAnonymous) {
+			response.Abort(c, enum.UNAUTHORIZED)
+			return
+		}
+
+		conn, err := w.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade失败时已写入错误响应
+			c.Abort()
+			return
+		}
+
+		client := &WebSocketClient{
+			ID:       uuid.NewString(),
+			Identity: identity,
+			ClientIP: realip.FromGin(c),
+			hub:      w,
+			conn:     conn,
+			send:     make(chan wsFrame, w.config.SendBufferSize),
+			rooms:    make(map[string]struct{}),
+			done:     make(chan struct{}),
+		}
+		w.register(client)
+
+		w.wg.Add(2)
+		go client.writePump()
+		go client.readPump()
+	}
+}
+
+// register 登记连接
+func (w *WebSocketComponent) register(client *WebSocketClient) {
+	w.mu.Lock()
+	w.clients[client.ID] = client
+	if client.Identity != "" {
+		addMember(w.users, client.Identity, client)
+	}
+	w.mu.Unlock()
+
+	if w.onConnect != nil {
+		w.onConnect(client)
+	}
+}
+
+// unregister 移除连接及其房间
+func (w *WebSocketComponent) unregister(client *WebSocketClient) {
+	w.mu.Lock()
+	if _, ok := w.clients[client.ID]; !ok {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.clients, client.ID)
+	if client.Identity != "" {
+		removeMember(w.users, client.Identity, client.ID)
+	}
+	client.mu.Lock()
+	for room := range client.rooms {
+		removeMember(w.rooms, room, client.ID)
+	}
+	client.mu.Unlock()
+	w.mu.Unlock()
+
+	if w.onDisconnect != nil {
+		w.onDisconnect(client)
+	}
+}
+
+// Join 加入房间
+func (w *WebSocketComponent) Join(client *WebSocketClient, room string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.clients[client.ID]; !ok {
+		return
+	}
+	addMember(w.rooms, room, client)
+	client.mu.Lock()
+	client.rooms[room] = struct{}{}
+	client.mu.Unlock()
+}
+
+// Leave 离开房间
+func (w *WebSocketComponent) Leave(client *WebSocketClient, room string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	removeMember(w.rooms, room, client.ID)
+	client.mu.Lock()
+	delete(client.rooms, room)
+	client.mu.Unlock()
+}
+
+// Broadcast 向房间广播消息，room为空则广播给所有连接
+func (w *WebSocketComponent) Broadcast(ctx context.Context, room string, data []byte) error {
+	return w.publish(ctx, wsEnvelope{Room: room, MessageType: websocket.TextMessage, Data: data})
+}
+
+// BroadcastJSON 向房间广播JSON消息
+func (w *WebSocketComponent) BroadcastJSON(ctx context.Context, room string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.Broadcast(ctx, room, data)
+}
+
+// SendToUser 向指定用户的所有连接发送消息(包括其他实例上的连接)
+func (w *WebSocketComponent) SendToUser(ctx context.Context, identity string, data []byte) error {
+	if identity == "" {
+		return errors.New("websocket: identity is empty")
+	}
+	return w.publish(ctx, wsEnvelope{User: identity, MessageType: websocket.TextMessage, Data: data})
+}
+
+// Count 当前实例的连接数
+func (w *WebSocketComponent) Count() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.clients)
+}
+
+// Dropped 因发送队列已满被丢弃的消息数
+func (w *WebSocketComponent) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// publish 配置了Redis时发布到频道由各实例投递，否则直接在本实例投递
+func (w *WebSocketComponent) publish(ctx context.Context, env wsEnvelope) error {
+	env.Node = w.nodeID
+	if w.pubsub == nil {
+		w.deliver(env)
+		return nil
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return w.redis.UniversalClient().Publish(ctx, w.config.RedisChannel, payload).Err()
+}
+
+// deliver 投递到本实例的连接
+func (w *WebSocketComponent) deliver(env wsEnvelope) {
+	w.mu.RLock()
+	var targets []*WebSocketClient
+	switch {
+	case env.User != "":
+		targets = collect(w.users[env.User])
+	case env.Room != "":
+		targets = collect(w.rooms[env.Room])
+	default:
+		targets = collect(w.clients)
+	}
+	w.mu.RUnlock()
+
+	for _, client := range targets {
+		client.enqueue(wsFrame{messageType: env.MessageType, data: env.Data})
+	}
+}
+
+// handleCommand 默认消息处理，支持订阅和取消订阅房间，订阅前校验房间授权
+func (w *WebSocketComponent) handleCommand(client *WebSocketClient, messageType int, data []byte) {
+	var cmd wsCommand
+	if messageType != websocket.TextMessage || json.Unmarshal(data, &cmd) != nil || cmd.Room == "" {
+		return
+	}
+	switch cmd.Action {
+	case "subscribe":
+		if w.authorizeRoom == nil || !w.authorizeRoom(client, cmd.Room) {
+			return
+		}
+		w.Join(client, cmd.Room)
+	case "unsubscribe":
+		w.Leave(client, cmd.Room)
+	}
+}
+
+// Send 向连接发送文本消息
+func (c *WebSocketClient) Send(data []byte) bool {
+	return c.enqueue(wsFrame{messageType: websocket.TextMessage, data: data})
+}
+
+// SendJSON 向连接发送JSON消息
+func (c *WebSocketClient) SendJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if !c.Send(data) {
+		return errors.New("websocket: send buffer is full or connection closed")
+	}
+	return nil
+}
+
+// Rooms 获取连接已加入的房间
+func (c *WebSocketClient) Rooms() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Close 发送关闭帧并断开连接
+func (c *WebSocketClient) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		deadline := time.Now().Add(c.hub.config.WriteTimeout)
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+// enqueue 放入发送队列，队列满时按配置丢弃消息或断开连接
+func (c *WebSocketClient) enqueue(frame wsFrame) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- frame:
+		return true
+	default:
+		c.hub.dropped.Add(1)
+		if !c.hub.config.DropOnFull {
+			go c.Close(websocket.ClosePolicyViolation, "send buffer overflow")
+		}
+		return false
+	}
+}
+
+// readPump 读取客户端消息，收到pong时延长读取截止时间
+func (c *WebSocketClient) readPump() {
+	defer c.hub.wg.Done()
+	defer func() {
+		c.hub.unregister(c)
+		c.Close(websocket.CloseNormalClosure, "")
+	}()
+
+	c.conn.SetReadLimit(c.hub.config.MaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.hub.config.PongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.hub.config.PongTimeout))
+	})
+
+	for {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if c.hub.onMessage != nil {
+			c.hub.onMessage(c, messageType, data)
+		}
+	}
+}
+
+// writePump 发送队列中的消息并定时发送ping
+func (c *WebSocketClient) writePump() {
+	defer c.hub.wg.Done()
+	ticker := time.NewTicker(c.hub.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case frame := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(c.hub.config.WriteTimeout))
+			if err := c.conn.WriteMessage(frame.messageType, frame.data); err != nil {
+				c.Close(websocket.CloseInternalServerErr, "write failed")
+				return
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(c.hub.config.WriteTimeout)
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				c.Close(websocket.CloseInternalServerErr, "write failed")
+				return
+			}
+		}
+	}
+}
+
+// addMember 添加分组成员
+func addMember(groups map[string]map[string]*WebSocketClient, key string, client *WebSocketClient) {
+	members, ok := groups[key]
+	if !ok {
+		members = make(map[string]*WebSocketClient)
+		groups[key] = members
+	}
+	members[client.ID] = client
+}
+
+// removeMember 移除分组成员，分组为空时删除
+func removeMember(groups map[string]map[string]*WebSocketClient, key, clientID string) {
+	members, ok := groups[key]
+	if !ok {
+		return
+	}
+	delete(members, clientID)
+	if len(members) == 0 {
+		delete(groups, key)
+	}
+}
+
+// collect 复制连接列表，避免持锁发送
+func collect(members map[string]*WebSocketClient) []*WebSocketClient {
+	clients := make([]*WebSocketClient, 0, len(members))
+	for _, client := range members {
+		clients = append(clients, client)
+	}
+	return clients
+}
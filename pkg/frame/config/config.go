@@ -8,6 +8,7 @@ import (
 
 	"github.com/boloc/go-frame-server/pkg/constant"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
@@ -77,6 +78,14 @@ func (c *ConfigComponent) GetViper() *viper.Viper {
 	return c.viper
 }
 
+// OnChange 监听配置文件变化，文件修改后重新加载并回调(如热更新日志级别)
+func (c *ConfigComponent) OnChange(fn func(c *ConfigComponent)) {
+	c.viper.OnConfigChange(func(fsnotify.Event) {
+		fn(c)
+	})
+	c.viper.WatchConfig()
+}
+
 // Get 获取配置
 // @param key string 配置名
 // @return interface{} 配置值
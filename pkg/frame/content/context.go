@@ -7,10 +7,22 @@ import (
 	"gorm.io/datatypes"
 )
 
+// RequestIDHeader 请求ID默认请求头/响应头
+const RequestIDHeader = "X-Request-ID"
+
 type RequestContext struct {
-	GinContext   *gin.Context    // gin上下文
+	GinContext   *gin.Context    `json:"-"` // gin上下文
 	RequestQuery *datatypes.JSON `json:"request_query"`
-	RequestBody  *datatypes.JSON `json:"request_body"`
+	// 请求体(默认脱敏)，ContextMiddleware在处理器执行前写入，超出记录上限时只保存前N个字节
+	RequestBody *datatypes.JSON `json:"request_body"`
+	// 请求体是否因超出记录上限被截断
+	RequestBodyTruncated bool `json:"request_body_truncated,omitempty"`
+	// 请求ID，来自X-Request-ID请求头或自动生成
+	RequestID string `json:"request_id"`
+	// 客户端真实IP
+	ClientIP string `json:"client_ip"`
+	// mTLS客户端证书身份，未开启客户端证书校验时为nil
+	Peer *PeerIdentity `json:"peer,omitempty"`
 	// 添加一个通用的map用于存储自定义数据
 	CustomData map[string]any `json:"custom_data"`
 }
@@ -61,6 +73,25 @@ func (rc *RequestContext) GetInt(key string) (int, bool) {
 	return 0, false
 }
 
+// IdentityKey 认证后的用户标识在CustomData中的key
+const IdentityKey = "identity"
+
+// SetIdentity 设置认证后的用户标识，通常在认证中间件中调用
+func (rc *RequestContext) SetIdentity(identity string) {
+	rc.Set(IdentityKey, identity)
+}
+
+// Identity 获取用户标识，未设置时使用mTLS客户端证书CN
+func (rc *RequestContext) Identity() string {
+	if identity, ok := rc.GetString(IdentityKey); ok && identity != "" {
+		return identity
+	}
+	if rc.Peer != nil {
+		return rc.Peer.CommonName
+	}
+	return ""
+}
+
 // FromContext 从标准 context 获取
 func FromContext(ctx context.Context) *RequestContext {
 	if v := ctx.Value(contextKey("request")); v != nil {
@@ -69,6 +100,17 @@ func FromContext(ctx context.Context) *RequestContext {
 	return nil
 }
 
+// RequestIDFromContext 从标准 context 获取请求ID，不在请求中时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if rc := FromContext(ctx); rc != nil {
+		return rc.RequestID
+	}
+	return ""
+}
+
 // NewContext 创建新的上下文
 func NewContext(ctx context.Context, rc *RequestContext) context.Context {
 	return context.WithValue(ctx, contextKey("request"), rc)
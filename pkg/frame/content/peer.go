@@ -0,0 +1,44 @@
+package content
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+)
+
+// PeerIdentity mTLS客户端证书身份
+type PeerIdentity struct {
+	CommonName   string   `json:"common_name"`   // 证书CN
+	Organization []string `json:"organization"`  // 证书组织
+	DNSNames     []string `json:"dns_names"`     // SAN DNS
+	URIs         []string `json:"uris"`          // SAN URI(如SPIFFE ID)
+	SerialNumber string   `json:"serial_number"` // 证书序列号
+	Issuer       string   `json:"issuer"`        // 签发者CN
+	Fingerprint  string   `json:"fingerprint"`   // 证书SHA256指纹
+}
+
+// NewPeerIdentity 从TLS连接状态中获取客户端证书身份
+// 未使用TLS或客户端未提供证书时返回nil
+func NewPeerIdentity(state *tls.ConnectionState) *PeerIdentity {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := state.PeerCertificates[0]
+	sum := sha256.Sum256(cert.Raw)
+
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	return &PeerIdentity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+		URIs:         uris,
+		SerialNumber: cert.SerialNumber.String(),
+		Issuer:       cert.Issuer.CommonName,
+		Fingerprint:  hex.EncodeToString(sum[:]),
+	}
+}
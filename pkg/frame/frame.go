@@ -20,6 +20,18 @@ type Component interface {
 	Stop(ctx context.Context) error
 }
 
+// HealthChecker 组件健康检查，实现该接口的组件会在管理端口展示健康状态
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// ComponentStatus 组件状态
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
 // Hook 定义钩子函数类型
 type Hook func(ctx context.Context) error
 
@@ -91,6 +103,38 @@ func (f *Frame) RegisterComponent(component Component) {
 	f.components = append(f.components, component)
 }
 
+// Components 获取已注册的组件
+func (f *Frame) Components() []Component {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]Component(nil), f.components...)
+}
+
+// Health 检查所有组件的健康状态，未实现HealthChecker的组件视为健康
+func (f *Frame) Health(ctx context.Context) []ComponentStatus {
+	components := f.Components()
+	statuses := make([]ComponentStatus, len(components))
+	for i, component := range components {
+		status := ComponentStatus{Name: ComponentName(component), Healthy: true}
+		if checker, ok := component.(HealthChecker); ok {
+			if err := checker.Health(ctx); err != nil {
+				status.Healthy = false
+				status.Error = err.Error()
+			}
+		}
+		statuses[i] = status
+	}
+	return statuses
+}
+
+// ComponentName 获取组件名称，组件未实现Name方法时使用类型名
+func ComponentName(component Component) string {
+	if named, ok := component.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", component)
+}
+
 // SetLogger 设置日志记录器
 func (f *Frame) SetLogger(logger *zap.Logger) {
 	f.logger = logger
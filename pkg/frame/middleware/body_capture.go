@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
+	"gorm.io/datatypes"
+)
+
+// 默认记录请求体的Content-Type
+var DefaultCaptureContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"application/xml",
+	"text/*",
+}
+
+// DefaultCaptureMaxBytes 默认最多记录64KB请求体
+const DefaultCaptureMaxBytes = 64 << 10
+
+// ContextOption 定义上下文中间件选项函数类型
+type ContextOption func(*contextConfig)
+
+// contextConfig 上下文中间件配置
+type contextConfig struct {
+	captureContentTypes []string     // 需要记录请求体的Content-Type
+	captureMaxBytes     int64        // 最多记录的字节数
+	requestIDHeader     string       // 请求ID请求头/响应头
+	mask                bool         // 是否对记录的查询参数和请求体脱敏
+	masker              *mask.Masker // 脱敏引擎，为空使用mask.Default()
+}
+
+// WithCaptureContentTypes 设置需要记录请求体的Content-Type，支持"text/*"通配
+// 不在列表中的请求(如multipart/form-data、application/octet-stream)不会记录请求体
+func WithCaptureContentTypes(contentTypes ...string) ContextOption {
+	return func(c *contextConfig) {
+		c.captureContentTypes = contentTypes
+	}
+}
+
+// WithRequestIDHeader 设置请求ID的请求头，默认X-Request-ID；请求未携带或格式无效时生成新的ID
+func WithRequestIDHeader(header string) ContextOption {
+	return func(c *contextConfig) {
+		c.requestIDHeader = header
+	}
+}
+
+// WithCaptureMask 设置是否对记录的查询参数和请求体脱敏，默认开启，masker为空使用mask.Default()
+func WithCaptureMask(enable bool, masker *mask.Masker) ContextOption {
+	return func(c *contextConfig) {
+		c.mask = enable
+		c.masker = masker
+	}
+}
+
+// WithCaptureMaxBytes 设置最多记录的请求体字节数，超出部分不记录并标记为截断，<=0则不记录请求体
+func WithCaptureMaxBytes(maxBytes int64) ContextOption {
+	return func(c *contextConfig) {
+		c.captureMaxBytes = maxBytes
+	}
+}
+
+// shouldCapture 判断Content-Type是否需要记录
+func (c *contextConfig) shouldCapture(contentType string) bool {
+	if c.captureMaxBytes <= 0 || contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range c.captureContentTypes {
+		allowed = strings.ToLower(allowed)
+		switch {
+		case allowed == mediaType:
+			return true
+		case strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")):
+			return true
+		case allowed == "application/json" && strings.HasSuffix(mediaType, "+json"):
+			// application/problem+json 等
+			return true
+		}
+	}
+	return false
+}
+
+// prefixedBody 预读部分加剩余请求体，关闭时关闭原始请求体
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// newPrefixedBody 创建先返回预读部分的请求体
+func newPrefixedBody(prefix []byte, body io.ReadCloser) io.ReadCloser {
+	return &prefixedBody{Reader: io.MultiReader(bytes.NewReader(prefix), body), Closer: body}
+}
+
+// toJSON 将请求体转换为可序列化的JSON
+// 完整且合法的JSON原样保存，其余(表单、文本、截断的JSON)保存为JSON字符串
+func toJSON(body []byte, truncated bool) datatypes.JSON {
+	if len(body) == 0 {
+		return nil
+	}
+	if !truncated && json.Valid(body) {
+		if string(body) == "{}" { // 如果请求参数为空，则设置为nil
+			return nil
+		}
+		return datatypes.JSON(body)
+	}
+	str, _ := json.Marshal(string(body))
+	return datatypes.JSON(str)
+}
+
+// getMasker 获取脱敏引擎，未开启脱敏时返回nil
+func (c *contextConfig) getMasker() *mask.Masker {
+	if !c.mask {
+		return nil
+	}
+	if c.masker != nil {
+		return c.masker
+	}
+	return mask.Default()
+}
+
+// captureQuery 记录查询参数
+func (c *contextConfig) captureQuery(query url.Values) datatypes.JSON {
+	if len(query) == 0 {
+		return nil
+	}
+	if masker := c.getMasker(); masker != nil {
+		query = masker.Query(query)
+	}
+	data, _ := json.Marshal(query)
+	return data
+}
+
+// captureBody 记录请求体
+func (c *contextConfig) captureBody(body []byte, truncated bool, contentType string) datatypes.JSON {
+	masker := c.getMasker()
+	if masker == nil {
+		return toJSON(body, truncated)
+	}
+	if !truncated && strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		body = []byte(masker.Form(string(body)))
+	}
+	data := toJSON(body, truncated)
+	if len(data) == 0 {
+		return data
+	}
+	return masker.JSON(data)
+}
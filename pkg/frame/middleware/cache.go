@@ -0,0 +1,498 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// cacheTagsKey 处理器添加的缓存标签在gin.Context中的key
+const cacheTagsKey = "frame.cache_tags"
+
+// cacheRefreshKey 标记后台刷新发起的请求，跳过缓存读取直接执行处理器
+type cacheRefreshKey struct{}
+
+// CacheOption 定义响应缓存选项函数类型
+type CacheOption func(*ResponseCache)
+
+// ResponseCache 基于Redis的GET响应缓存
+type ResponseCache struct {
+	redis        components.RedisProvider
+	prefix       string        // Redis key前缀
+	ttl          time.Duration // 默认缓存时间，<=0表示默认不缓存
+	routes       RouteRules[time.Duration]
+	routeTags    RouteRules[[]string] // 路由级缓存标签
+	stale        time.Duration        // 过期后仍可返回旧数据并后台刷新的时间
+	varyHeaders  []string             // 参与缓存key的请求头
+	varyIdentity bool                 // 缓存key是否区分用户
+	maxBodySize  int                  // 最多缓存的响应体字节数
+	refreshLock  time.Duration        // 后台刷新锁的过期时间
+	refreshTTL   time.Duration        // 后台刷新的超时时间
+	handler      http.Handler         // 后台刷新时重新执行请求的处理器，为空则使用当前http.Server的Handler
+	refreshing   sync.Map             // 本实例正在刷新的缓存key
+}
+
+// cacheEntry 缓存的响应
+type cacheEntry struct {
+	Response   *cachedResponse `json:"response"`
+	ETag       string          `json:"etag"`
+	FreshUntil int64           `json:"fresh_until"` // 新鲜截止时间(毫秒时间戳)
+}
+
+// WithCachePrefix 设置Redis key前缀
+func WithCachePrefix(prefix string) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.prefix = prefix
+	}
+}
+
+// WithCacheTTL 设置默认缓存时间，实际缓存时间使用util.RandomTTL增加随机秒数，避免同时失效
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.ttl = ttl
+	}
+}
+
+// WithCacheRouteTTL 按路由设置缓存时间，<=0表示该路由不缓存
+// 例: WithCacheRouteTTL(RouteRules[time.Duration]{"/orders/:id": time.Minute})
+func WithCacheRouteTTL(routes RouteRules[time.Duration]) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.routes = routes
+	}
+}
+
+// WithCacheRouteTags 按路由设置缓存标签，用于按标签失效
+func WithCacheRouteTags(routeTags RouteRules[[]string]) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.routeTags = routeTags
+	}
+}
+
+// WithCacheStaleWhileRevalidate 设置过期后仍可返回旧数据的时间
+// 期间返回旧数据，同时在后台重新执行一次请求刷新缓存，同一个key多个实例只有一个在刷新
+func WithCacheStaleWhileRevalidate(stale time.Duration) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.stale = stale
+	}
+}
+
+// WithCacheRefreshTimeout 设置后台刷新的超时时间，默认10秒
+func WithCacheRefreshTimeout(timeout time.Duration) CacheOption {
+	return func(rc *ResponseCache) {
+		if timeout > 0 {
+			rc.refreshTTL = timeout
+		}
+	}
+}
+
+// WithCacheRefreshHandler 设置后台刷新时重新执行请求的处理器，一般为gin.Engine
+// 默认使用处理当前请求的http.Server的Handler，无法获取时过期数据按未命中处理
+func WithCacheRefreshHandler(handler http.Handler) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.handler = handler
+	}
+}
+
+// WithCacheVaryHeaders 设置参与缓存key的请求头，如Accept-Language
+func WithCacheVaryHeaders(headers ...string) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.varyHeaders = headers
+	}
+}
+
+// WithCacheVaryIdentity 设置缓存key是否区分用户(RequestContext.Identity)，默认区分
+// 设为false时所有用户共享缓存，只能用于与用户无关的公开数据
+func WithCacheVaryIdentity(vary bool) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.varyIdentity = vary
+	}
+}
+
+// WithCacheMaxBodySize 设置最多缓存的响应体字节数，超出则不缓存
+func WithCacheMaxBodySize(size int) CacheOption {
+	return func(rc *ResponseCache) {
+		rc.maxBodySize = size
+	}
+}
+
+// NewResponseCache 创建响应缓存
+func NewResponseCache(redisProvider components.RedisProvider, opts ...CacheOption) *ResponseCache {
+	rc := &ResponseCache{
+		redis:        redisProvider,
+		prefix:       "cache:",
+		ttl:          time.Minute,
+		maxBodySize:  1 << 20,
+		refreshLock:  30 * time.Second,
+		refreshTTL:   10 * time.Second,
+		varyIdentity: true,
+	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	return rc
+}
+
+// CacheTags 为当前响应添加缓存标签，在处理器中调用
+// 例: middleware.CacheTags(c, "order:"+id)，订单更新后 cache.Invalidate(ctx, "order:"+id)
+func CacheTags(c *gin.Context, tags ...string) {
+	existing := c.GetStringSlice(cacheTagsKey)
+	c.Set(cacheTagsKey, append(existing, tags...))
+}
+
+// Middleware 获取缓存中间件
+// 仅缓存200且未设置Set-Cookie、Cache-Control: no-store/private的GET响应，
+// 响应带ETag，If-None-Match匹配时返回304；请求携带Cache-Control: no-cache时跳过缓存读取。
+// 缓存key默认区分用户，需要在认证中间件之后注册；携带Authorization或Cookie但未认证出用户的请求不使用缓存
+func (rc *ResponseCache) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ttl := rc.routes.match(c, rc.ttl)
+		if c.Request.Method != http.MethodGet || ttl <= 0 || !rc.cacheable(c) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		client := rc.redis.UniversalClient()
+		key := rc.prefix + "resp:" + rc.cacheKey(c)
+
+		_, revalidating := ctx.Value(cacheRefreshKey{}).(struct{})
+		if !revalidating && !strings.Contains(c.GetHeader("Cache-Control"), "no-cache") {
+			entry, err := rc.load(ctx, client, key)
+			if err == nil && entry != nil {
+				fresh := time.Now().UnixMilli() < entry.FreshUntil
+				handler := rc.refreshHandler(c)
+				if fresh || (handler != nil && !rc.startRefresh(c, handler, client, key)) {
+					rc.serve(c, entry, fresh)
+					c.Abort()
+					return
+				}
+				if handler != nil {
+					// 已在后台刷新，返回旧数据
+					rc.serve(c, entry, false)
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		c.Header("X-Cache", "MISS")
+		rc.refresh(c, client, key, ttl)
+	}
+}
+
+// Invalidate 按标签删除缓存
+func (rc *ResponseCache) Invalidate(ctx context.Context, tags ...string) error {
+	client := rc.redis.UniversalClient()
+	for _, tag := range tags {
+		tagKey := rc.prefix + "tag:" + tag
+		keys, err := client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return err
+		}
+		// 逐个删除，兼容集群模式下key不在同一slot
+		pipe := client.Pipeline()
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		pipe.Del(ctx, tagKey)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheable 缓存key区分用户时，携带凭证但未认证出用户的请求不使用缓存，避免不同用户共享缓存
+func (rc *ResponseCache) cacheable(c *gin.Context) bool {
+	if !rc.varyIdentity || (c.GetHeader("Authorization") == "" && c.GetHeader("Cookie") == "") {
+		return true
+	}
+	reqCtx := content.FromContext(c.Request.Context())
+	return reqCtx != nil && reqCtx.Identity() != ""
+}
+
+// refreshHandler 获取后台刷新使用的处理器
+func (rc *ResponseCache) refreshHandler(c *gin.Context) http.Handler {
+	if rc.handler != nil {
+		return rc.handler
+	}
+	if server, ok := c.Request.Context().Value(http.ServerContextKey).(*http.Server); ok && server.Handler != nil {
+		return server.Handler
+	}
+	return nil
+}
+
+// startRefresh 获取刷新锁后在后台重新执行请求，本实例和其他实例已在刷新时返回false
+func (rc *ResponseCache) startRefresh(c *gin.Context, handler http.Handler, client redis.UniversalClient, key string) bool {
+	if _, loaded := rc.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return false
+	}
+	if !rc.acquireRefresh(c.Request.Context(), client, key) {
+		rc.refreshing.Delete(key)
+		return false
+	}
+
+	// 复制请求，经过完整的中间件链(包括认证)重新执行，不影响当前请求
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), cacheRefreshKey{}, struct{}{}), rc.refreshTTL)
+	req := c.Request.Clone(ctx)
+	req.Header.Del("If-None-Match")
+	go func() {
+		defer rc.refreshing.Delete(key)
+		defer cancel()
+		handler.ServeHTTP(&refreshWriter{header: make(http.Header)}, req)
+	}()
+	return true
+}
+
+// refresh 执行处理器并缓存响应
+func (rc *ResponseCache) refresh(c *gin.Context, client redis.UniversalClient, key string, ttl time.Duration) {
+	writer := newBufferedWriter(c, rc.maxBodySize)
+	c.Next()
+
+	if writer.passthrough {
+		return
+	}
+	entry := rc.entry(c, writer, ttl)
+	if entry != nil {
+		c.Header("ETag", entry.ETag)
+		if matchETag(c.GetHeader("If-None-Match"), entry.ETag) {
+			writer.notModified()
+			rc.store(c, client, key, entry)
+			return
+		}
+	}
+	writer.flush()
+	if entry != nil {
+		rc.store(c, client, key, entry)
+	}
+}
+
+// entry 生成缓存数据，不可缓存时返回nil
+func (rc *ResponseCache) entry(c *gin.Context, writer *bufferedWriter, ttl time.Duration) *cacheEntry {
+	header := writer.Header()
+	if writer.Status() != http.StatusOK || header.Get("Set-Cookie") != "" {
+		return nil
+	}
+	if cc := header.Get("Cache-Control"); strings.Contains(cc, "no-store") || strings.Contains(cc, "private") {
+		return nil
+	}
+
+	body := writer.buf.Bytes()
+	sum := sha256.Sum256(body)
+	cached := &cachedResponse{Status: writer.Status(), Header: make(map[string][]string), Body: append([]byte(nil), body...)}
+	for k, v := range header {
+		if _, skip := skipReplayHeaders[k]; skip || k == "X-Cache" {
+			continue
+		}
+		cached.Header[k] = append([]string(nil), v...)
+	}
+	return &cacheEntry{
+		Response:   cached,
+		ETag:       `W/"` + hex.EncodeToString(sum[:16]) + `"`,
+		FreshUntil: time.Now().Add(util.RandomTTL(int(ttl.Seconds()))).UnixMilli(),
+	}
+}
+
+// load 读取缓存
+func (rc *ResponseCache) load(ctx context.Context, client redis.UniversalClient, key string) (*cacheEntry, error) {
+	data, err := client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Response == nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// store 保存缓存并登记标签
+func (rc *ResponseCache) store(c *gin.Context, client redis.UniversalClient, key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	ctx := context.WithoutCancel(c.Request.Context())
+	expiration := time.Until(time.UnixMilli(entry.FreshUntil)) + rc.stale
+
+	pipe := client.Pipeline()
+	pipe.Set(ctx, key, data, expiration)
+	tags := append(append([]string(nil), rc.routeTags.match(c, nil)...), c.GetStringSlice(cacheTagsKey)...)
+	for _, tag := range tags {
+		tagKey := rc.prefix + "tag:" + tag
+		pipe.SAdd(ctx, tagKey, key)
+		// 标签集合比缓存多保留一段时间，过期的key删除时无影响
+		pipe.Expire(ctx, tagKey, expiration+time.Hour)
+	}
+	pipe.Del(ctx, key+":refresh")
+	_, _ = pipe.Exec(ctx)
+}
+
+// serve 返回缓存的响应，不终止后续处理
+func (rc *ResponseCache) serve(c *gin.Context, entry *cacheEntry, fresh bool) {
+	state := "HIT"
+	if !fresh {
+		state = "STALE"
+	}
+	c.Header("X-Cache", state)
+	c.Header("ETag", entry.ETag)
+	if matchETag(c.GetHeader("If-None-Match"), entry.ETag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		c.Writer.Flush()
+		return
+	}
+	// 设置Content-Length，客户端读完响应即结束，不必等待后台刷新
+	c.Header("Content-Length", strconv.Itoa(len(entry.Response.Body)))
+	entry.Response.write(c, nil)
+	c.Writer.Flush()
+}
+
+// acquireRefresh 获取后台刷新锁，同一时间只有一个请求刷新
+func (rc *ResponseCache) acquireRefresh(ctx context.Context, client redis.UniversalClient, key string) bool {
+	ok, err := client.SetNX(ctx, key+":refresh", "1", rc.refreshLock).Result()
+	return err == nil && ok
+}
+
+// cacheKey 根据路径、排序后的查询参数、指定请求头和用户生成缓存key
+func (rc *ResponseCache) cacheKey(c *gin.Context) string {
+	var b strings.Builder
+	b.WriteString(c.Request.URL.Path)
+	b.WriteByte('?')
+	// url.Values.Encode按key排序
+	b.WriteString(c.Request.URL.Query().Encode())
+	for _, h := range rc.varyHeaders {
+		b.WriteByte('\n')
+		b.WriteString(strings.ToLower(h))
+		b.WriteByte('=')
+		b.WriteString(c.GetHeader(h))
+	}
+	if rc.varyIdentity {
+		b.WriteString("\nidentity=")
+		if reqCtx := content.FromContext(c.Request.Context()); reqCtx != nil {
+			b.WriteString(reqCtx.Identity())
+		}
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return c.FullPath() + ":" + hex.EncodeToString(sum[:])
+}
+
+// matchETag If-None-Match是否匹配
+func matchETag(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter 缓冲响应以便在写出前设置ETag，超过上限或Flush时改为直接写出
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf         bytes.Buffer
+	limit       int
+	passthrough bool
+}
+
+// newBufferedWriter 创建缓冲写入器并替换c.Writer
+func newBufferedWriter(c *gin.Context, limit int) *bufferedWriter {
+	w := &bufferedWriter{ResponseWriter: c.Writer, limit: limit}
+	c.Writer = w
+	return w
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if !w.passthrough && w.limit > 0 && w.buf.Len()+len(b) > w.limit {
+		w.flush()
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.passthrough && w.ResponseWriter.Written() || w.buf.Len() > 0
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.buf.Len()
+}
+
+// Flush 流式响应不缓存，直接写出
+func (w *bufferedWriter) Flush() {
+	w.flush()
+	w.ResponseWriter.Flush()
+}
+
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush 写出已缓冲的内容并改为直接写出
+func (w *bufferedWriter) flush() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// notModified 丢弃缓冲的响应体并返回304
+func (w *bufferedWriter) notModified() {
+	w.passthrough = true
+	w.buf.Reset()
+	w.Header().Del("Content-Type")
+	w.ResponseWriter.WriteHeader(http.StatusNotModified)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// refreshWriter 后台刷新时使用，丢弃处理器的输出
+type refreshWriter struct {
+	header http.Header
+}
+
+func (w *refreshWriter) Header() http.Header         { return w.header }
+func (w *refreshWriter) WriteHeader(int)             {}
+func (w *refreshWriter) Write(b []byte) (int, error) { return len(b), nil }
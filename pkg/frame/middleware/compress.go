@@ -0,0 +1,394 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// 支持的压缩编码
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+)
+
+// 默认不压缩的Content-Type(本身已压缩)
+var DefaultCompressExcludeTypes = []string{
+	"image/*",
+	"video/*",
+	"audio/*",
+	"font/woff",
+	"font/woff2",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/zstd",
+	"application/pdf",
+	"application/octet-stream",
+}
+
+// CompressOption 定义压缩中间件选项函数类型
+type CompressOption func(*compressConfig)
+
+// compressConfig 压缩中间件配置
+type compressConfig struct {
+	encodings    []string // 服务端支持的编码(按优先级)
+	minSize      int      // 小于该字节数的响应不压缩
+	level        int      // 压缩等级，0使用各编码默认等级
+	excludeTypes []string // 不压缩的Content-Type
+	observer     func(encoding string, originalBytes, compressedBytes int)
+	pools        map[string]*sync.Pool
+}
+
+// WithCompressEncodings 设置支持的编码及优先级，默认 br、zstd、gzip
+func WithCompressEncodings(encodings ...string) CompressOption {
+	return func(c *compressConfig) {
+		c.encodings = encodings
+	}
+}
+
+// WithCompressMinSize 设置最小压缩字节数，默认1024
+func WithCompressMinSize(size int) CompressOption {
+	return func(c *compressConfig) {
+		c.minSize = size
+	}
+}
+
+// WithCompressLevel 设置压缩等级，gzip为1~9，br为0~11，zstd为1~22(映射到最接近的编码器等级)
+func WithCompressLevel(level int) CompressOption {
+	return func(c *compressConfig) {
+		c.level = level
+	}
+}
+
+// WithCompressExcludeTypes 设置不压缩的Content-Type，支持"image/*"通配
+func WithCompressExcludeTypes(contentTypes ...string) CompressOption {
+	return func(c *compressConfig) {
+		c.excludeTypes = contentTypes
+	}
+}
+
+// WithCompressObserver 设置压缩统计回调，例: WithCompressObserver(monitor.ObserveCompression)
+func WithCompressObserver(observer func(encoding string, originalBytes, compressedBytes int)) CompressOption {
+	return func(c *compressConfig) {
+		c.observer = observer
+	}
+}
+
+// CompressMiddleware 响应压缩中间件
+// 根据Accept-Encoding协商br/zstd/gzip，响应小于最小字节数、已压缩的Content-Type、已设置Content-Encoding的响应不压缩；
+// 处理器调用Flush(如SSE)时立即开始压缩并刷新到客户端。需要注册在最前面，使后续中间件的输出都经过压缩
+func CompressMiddleware(opts ...CompressOption) gin.HandlerFunc {
+	conf := &compressConfig{
+		encodings:    []string{EncodingBrotli, EncodingZstd, EncodingGzip},
+		minSize:      1024,
+		excludeTypes: DefaultCompressExcludeTypes,
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	conf.pools = make(map[string]*sync.Pool, len(conf.encodings))
+	for _, encoding := range conf.encodings {
+		conf.pools[encoding] = newEncoderPool(encoding, conf.level)
+	}
+
+	return func(c *gin.Context) {
+		req := c.Request
+		if req.Method == http.MethodHead || req.Header.Get("Upgrade") != "" || req.Header.Get("Range") != "" {
+			c.Next()
+			return
+		}
+		encoding := negotiateEncoding(req.Header.Get("Accept-Encoding"), conf.encodings)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, conf: conf, encoding: encoding}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// encoder 压缩编码器
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// newEncoderPool 创建编码器池
+func newEncoderPool(encoding string, level int) *sync.Pool {
+	return &sync.Pool{New: func() any {
+		switch encoding {
+		case EncodingBrotli:
+			if level <= 0 {
+				level = brotli.DefaultCompression
+			}
+			return brotli.NewWriterLevel(io.Discard, level)
+		case EncodingZstd:
+			zstdLevel := zstd.SpeedDefault
+			if level > 0 {
+				zstdLevel = zstd.EncoderLevelFromZstd(level)
+			}
+			enc, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstdLevel), zstd.WithEncoderConcurrency(1))
+			return enc
+		default:
+			if level <= 0 {
+				level = gzip.DefaultCompression
+			}
+			enc, err := gzip.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				enc = gzip.NewWriter(io.Discard)
+			}
+			return enc
+		}
+	}}
+}
+
+// negotiateEncoding 根据Accept-Encoding选择编码，q值相同时按服务端优先级
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		qualities[name] = q
+	}
+
+	type candidate struct {
+		encoding string
+		q        float64
+		priority int
+	}
+	var candidates []candidate
+	for i, encoding := range supported {
+		q, ok := qualities[encoding]
+		if !ok {
+			if q, ok = qualities["*"]; !ok {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{encoding, q, i})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].q != candidates[j].q {
+			return candidates[i].q > candidates[j].q
+		}
+		return candidates[i].priority < candidates[j].priority
+	})
+	return candidates[0].encoding
+}
+
+// compressWriter 压缩响应写入器
+// 在响应体达到最小字节数或处理器Flush前先缓冲，以便根据Content-Type和大小决定是否压缩
+type compressWriter struct {
+	gin.ResponseWriter
+	conf      *compressConfig
+	encoding  string
+	buf       bytes.Buffer
+	decided   bool
+	compress  bool
+	encoder   encoder
+	counter   *countingWriter
+	original  int
+	headerNow bool // 处理器要求立即写出响应头
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() >= w.conf.minSize {
+			if err := w.decide(false); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+	if w.compress {
+		w.original += len(b)
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.headerNow = true
+}
+
+func (w *compressWriter) Written() bool {
+	if w.decided {
+		return w.ResponseWriter.Written()
+	}
+	return w.headerNow || w.buf.Len() > 0
+}
+
+// Size 处理器写入的响应体字节数(压缩前)，未写入时为-1
+func (w *compressWriter) Size() int {
+	switch {
+	case w.compress:
+		return w.original
+	case w.decided:
+		return w.ResponseWriter.Size()
+	case w.buf.Len() == 0 && !w.headerNow:
+		return -1
+	default:
+		return w.buf.Len()
+	}
+}
+
+// Flush 流式响应立即决定是否压缩并刷新到客户端
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.compress {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide 决定是否压缩并写出已缓冲的内容
+func (w *compressWriter) decide(streaming bool) error {
+	w.decided = true
+	w.compress = w.shouldCompress(streaming)
+
+	if !w.compress {
+		if w.buf.Len() > 0 {
+			_, err := w.ResponseWriter.Write(w.buf.Bytes())
+			w.buf.Reset()
+			return err
+		}
+		if w.headerNow || streaming {
+			w.ResponseWriter.WriteHeaderNow()
+		}
+		return nil
+	}
+
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", w.encoding)
+	// 压缩后内容不同，强ETag改为弱ETag
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+
+	w.counter = &countingWriter{w: w.ResponseWriter}
+	w.encoder = w.conf.pools[w.encoding].Get().(encoder)
+	w.encoder.Reset(w.counter)
+	if w.buf.Len() > 0 {
+		w.original += w.buf.Len()
+		_, err := w.encoder.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+	return nil
+}
+
+// shouldCompress 判断响应是否需要压缩
+func (w *compressWriter) shouldCompress(streaming bool) bool {
+	header := w.Header()
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" && w.buf.Len() > 0 {
+		contentType = http.DetectContentType(w.buf.Bytes())
+	}
+	if excluded(contentType, w.conf.excludeTypes) {
+		return false
+	}
+	// 可压缩类型的响应才需要按Accept-Encoding区分缓存
+	header.Add("Vary", "Accept-Encoding")
+	return streaming || w.buf.Len() >= w.conf.minSize
+}
+
+// finish 处理结束，写出缓冲内容并关闭编码器
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if !w.compress {
+		return
+	}
+	_ = w.encoder.Close()
+	w.encoder.Reset(io.Discard)
+	w.conf.pools[w.encoding].Put(w.encoder)
+	if w.conf.observer != nil {
+		w.conf.observer(w.encoding, w.original, w.counter.n)
+	}
+}
+
+// excluded Content-Type是否在排除列表中
+func excluded(contentType string, excludeTypes []string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range excludeTypes {
+		t = strings.ToLower(t)
+		if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// countingWriter 统计写出的字节数
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += n
+	return n, err
+}
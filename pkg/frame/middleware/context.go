@@ -2,17 +2,32 @@ package middleware
 
 import (
 	"bytes"
-	"encoding/json"
 	"io"
+	"net/http"
 
 	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/realip"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
 	"gorm.io/datatypes"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // ContextMiddleware 创建上下文中间件
-func ContextMiddleware() gin.HandlerFunc {
+// 只记录指定Content-Type的请求体，最多读取上限+1个字节，multipart/二进制等请求体不会被缓冲
+func ContextMiddleware(opts ...ContextOption) gin.HandlerFunc {
+	conf := &contextConfig{
+		captureContentTypes: DefaultCaptureContentTypes,
+		captureMaxBytes:     DefaultCaptureMaxBytes,
+		requestIDHeader:     content.RequestIDHeader,
+		mask:                true,
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
 	return func(c *gin.Context) {
 		// 判断方法类型
 		var (
@@ -20,29 +35,70 @@ func ContextMiddleware() gin.HandlerFunc {
 			requestBody  datatypes.JSON
 		)
 
-		// 打印GET请求参数
-		query := c.Request.URL.Query()
-		requestQuery, _ = json.Marshal(query)
-		if string(requestQuery) == "{}" { // 如果请求参数为空，则设置为nil
-			requestQuery = nil
-		}
+		// 记录GET请求参数(已脱敏)，请求参数为空时为nil
+		requestQuery = conf.captureQuery(c.Request.URL.Query())
 
-		// 打印POST请求参数
-		body, _ := c.GetRawData()
-		requestBody = datatypes.JSON(body)
-		if len(body) == 0 || string(requestBody) == "{}" { // 如果请求参数为空，则设置为nil
-			requestBody = nil
+		// 请求ID，沿用上游传入的ID便于串联调用链
+		requestID := c.GetHeader(conf.requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
 		}
-
-		// 重置请求体 PS:为了不阻碍后续处理中还需要用到原始请求体，将数据重新设置回去
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		c.Header(conf.requestIDHeader, requestID)
 
 		rc := &content.RequestContext{
+			RequestID:    requestID,
 			RequestQuery: &requestQuery,
 			RequestBody:  &requestBody,
+			ClientIP:     realip.FromGin(c),
+			Peer:         content.NewPeerIdentity(c.Request.TLS),
 			CustomData:   make(map[string]any), // 初始化CustomData
 		}
+
+		// 记录请求体，处理器执行前写入上下文
+		hasBody := c.Request.Body != nil && c.Request.Body != http.NoBody && c.Request.ContentLength != 0
+		if hasBody && conf.shouldCapture(c.Request.Header.Get("Content-Type")) {
+			if c.Request.ContentLength > 0 && c.Request.ContentLength <= conf.captureMaxBytes {
+				// 长度已知且不超过上限，直接读取，后续处理中即可使用
+				body, err := c.GetRawData()
+				if IsBodyTooLarge(err) { // 超出BodyLimitMiddleware的限制
+					response.Abort(c, enum.REQUEST_ENTITY_TOO_LARGE)
+					return
+				}
+				requestBody = conf.captureBody(body, false, c.ContentType())
+				// 重置请求体 PS:为了不阻碍后续处理中还需要用到原始请求体，将数据重新设置回去
+				c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+			} else {
+				// 长度未知或超过上限，只预读上限+1个字节用于记录，处理器读取时先返回预读部分再继续读取剩余请求体
+				prefix, err := io.ReadAll(io.LimitReader(c.Request.Body, conf.captureMaxBytes+1))
+				if IsBodyTooLarge(err) {
+					response.Abort(c, enum.REQUEST_ENTITY_TOO_LARGE)
+					return
+				}
+				truncated := int64(len(prefix)) > conf.captureMaxBytes
+				captured := prefix
+				if truncated {
+					captured = prefix[:conf.captureMaxBytes]
+				}
+				requestBody = conf.captureBody(captured, truncated, c.ContentType())
+				rc.RequestBodyTruncated = truncated
+				c.Request.Body = newPrefixedBody(prefix, c.Request.Body)
+			}
+		}
+
 		c.Request = c.Request.WithContext(content.NewContext(c.Request.Context(), rc))
 		c.Next()
 	}
 }
+
+// validRequestID 请求ID只允许可见ASCII字符且不超过128字节，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,274 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 释放锁，只删除自己持有的锁
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// IdempotencyOption 定义幂等中间件选项函数类型
+type IdempotencyOption func(*idempotencyConfig)
+
+// idempotencyConfig 幂等中间件配置
+type idempotencyConfig struct {
+	header      string        // 幂等键请求头
+	prefix      string        // Redis key前缀
+	ttl         time.Duration // 响应保存时间
+	lockTTL     time.Duration // 处理中锁的过期时间，应大于请求超时时间
+	required    bool          // 是否必须携带幂等键
+	methods     []string      // 生效的请求方法
+	maxBodySize int           // 最多保存的响应体字节数，超出则不保存
+	maxRequest  int64         // 计算指纹时最多读取的请求体字节数，超出返回4130
+}
+
+// WithIdempotencyHeader 设置幂等键请求头，默认Idempotency-Key
+func WithIdempotencyHeader(header string) IdempotencyOption {
+	return func(c *idempotencyConfig) {
+		c.header = header
+	}
+}
+
+// WithIdempotencyPrefix 设置Redis key前缀
+func WithIdempotencyPrefix(prefix string) IdempotencyOption {
+	return func(c *idempotencyConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithIdempotencyTTL 设置响应保存时间和处理中锁的过期时间
+func WithIdempotencyTTL(ttl, lockTTL time.Duration) IdempotencyOption {
+	return func(c *idempotencyConfig) {
+		c.ttl = ttl
+		c.lockTTL = lockTTL
+	}
+}
+
+// WithIdempotencyRequired 设置是否必须携带幂等键，未携带返回400
+func WithIdempotencyRequired(required bool) IdempotencyOption {
+	return func(c *idempotencyConfig) {
+		c.required = required
+	}
+}
+
+// WithIdempotencyMethods 设置生效的请求方法，默认POST
+func WithIdempotencyMethods(methods ...string) IdempotencyOption {
+	return func(c *idempotencyConfig) {
+		c.methods = methods
+	}
+}
+
+// WithIdempotencyMaxBodySize 设置最多保存的响应体字节数
+func WithIdempotencyMaxBodySize(size int) IdempotencyOption {
+	return func(c *idempotencyConfig) {
+		c.maxBodySize = size
+	}
+}
+
+// WithIdempotencyMaxRequestSize 设置计算请求指纹时最多读取的请求体字节数，默认1MB
+// 请求体需要读入内存计算指纹后再交给处理器，超出上限返回4130
+func WithIdempotencyMaxRequestSize(size int64) IdempotencyOption {
+	return func(c *idempotencyConfig) {
+		c.maxRequest = size
+	}
+}
+
+// idempotencyRecord 保存的请求指纹和响应
+type idempotencyRecord struct {
+	Fingerprint string          `json:"fingerprint"`
+	Response    *cachedResponse `json:"response"`
+}
+
+// IdempotencyMiddleware 幂等中间件
+// 携带Idempotency-Key的请求处理完成后保存请求指纹和响应，相同key重试时直接返回保存的响应(Idempotent-Replayed: true)；
+// 相同key但请求不同返回4090；相同请求正在处理中返回4090并设置Retry-After。
+// 5xx响应不保存，客户端可以使用相同key重试。幂等键按用户(RequestContext.Identity)和路由隔离，
+// 需要在BodyLimitMiddleware和认证中间件之后注册
+func IdempotencyMiddleware(redisProvider components.RedisProvider, opts ...IdempotencyOption) gin.HandlerFunc {
+	conf := &idempotencyConfig{
+		header:      "Idempotency-Key",
+		prefix:      "idempotency:",
+		ttl:         24 * time.Hour,
+		lockTTL:     time.Minute,
+		methods:     []string{http.MethodPost},
+		maxBodySize: 1 << 20,
+		maxRequest:  1 << 20,
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return func(c *gin.Context) {
+		if !containsMethod(conf.methods, c.Request.Method) {
+			c.Next()
+			return
+		}
+		idempotencyKey := strings.TrimSpace(c.GetHeader(conf.header))
+		if idempotencyKey == "" {
+			if conf.required {
+				response.Abort(c, enum.BAD_REQUEST, "missing "+conf.header+" header")
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > 255 {
+			response.Abort(c, enum.BAD_REQUEST, conf.header+" is too long")
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c, conf.maxRequest)
+		if err != nil {
+			if IsBodyTooLarge(err) {
+				response.Abort(c, enum.REQUEST_ENTITY_TOO_LARGE)
+				return
+			}
+			response.Abort(c, enum.BAD_REQUEST)
+			return
+		}
+
+		ctx := c.Request.Context()
+		client := redisProvider.UniversalClient()
+		key := conf.prefix + scopeKey(c, idempotencyKey)
+		lockKey := key + ":lock"
+
+		// 已处理过，重放响应
+		if replayed, err := replayIdempotent(c, client, key, fingerprint); err != nil || replayed {
+			if err != nil {
+				response.Abort(c, enum.SERVICE_UNAVAILABLE)
+			}
+			return
+		}
+
+		// 加锁，锁的值带上请求指纹用于区分重复请求和冲突请求
+		token := uuid.NewString() + ":" + fingerprint
+		locked, err := client.SetNX(ctx, lockKey, token, conf.lockTTL).Result()
+		if err != nil {
+			response.Abort(c, enum.SERVICE_UNAVAILABLE)
+			return
+		}
+		if !locked {
+			holder, _ := client.Get(ctx, lockKey).Result()
+			if holder != "" && !strings.HasSuffix(holder, ":"+fingerprint) {
+				response.Abort(c, enum.CONFLICT, conf.header+" is already used by a different request")
+				return
+			}
+			c.Header("Retry-After", "1")
+			response.Abort(c, enum.CONFLICT, "a request with the same "+conf.header+" is being processed")
+			return
+		}
+		defer releaseLockScript.Run(context.WithoutCancel(ctx), client, []string{lockKey}, token)
+
+		// 加锁前可能刚好有相同请求处理完成
+		if replayed, err := replayIdempotent(c, client, key, fingerprint); err != nil || replayed {
+			if err != nil {
+				response.Abort(c, enum.SERVICE_UNAVAILABLE)
+			}
+			return
+		}
+
+		recorder := newResponseRecorder(c, conf.maxBodySize)
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		cached, ok := recorder.snapshot()
+		if !ok {
+			return
+		}
+		data, err := json.Marshal(&idempotencyRecord{Fingerprint: fingerprint, Response: cached})
+		if err != nil {
+			return
+		}
+		// 请求ctx可能已超时，保存结果不受影响
+		_ = client.Set(context.WithoutCancel(ctx), key, data, conf.ttl).Err()
+	}
+}
+
+// replayIdempotent 存在已保存的响应时重放，请求指纹不一致时返回冲突
+func replayIdempotent(c *gin.Context, client redis.UniversalClient, key, fingerprint string) (bool, error) {
+	data, err := client.Get(c.Request.Context(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.Response == nil {
+		return false, nil
+	}
+	if record.Fingerprint != fingerprint {
+		response.Abort(c, enum.CONFLICT, "idempotency key is already used by a different request")
+		return true, nil
+	}
+	record.Response.replay(c, map[string]string{"Idempotent-Replayed": "true"})
+	return true, nil
+}
+
+// requestFingerprint 请求指纹: 方法、路径、查询参数和请求体的sha256
+// 最多读取maxSize字节，超出返回*http.MaxBytesError
+func requestFingerprint(c *gin.Context, maxSize int64) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "?" + c.Request.URL.RawQuery + "\n"))
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		if c.Request.ContentLength > maxSize {
+			return "", &http.MaxBytesError{Limit: maxSize}
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSize+1))
+		if err != nil {
+			return "", err
+		}
+		if int64(len(body)) > maxSize {
+			return "", &http.MaxBytesError{Limit: maxSize}
+		}
+		h.Write(body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// scopeKey 幂等键按用户和路由隔离，避免不同用户使用相同key互相影响
+func scopeKey(c *gin.Context, idempotencyKey string) string {
+	identity := ""
+	if rc := content.FromContext(c.Request.Context()); rc != nil {
+		identity = rc.Identity()
+	}
+	sum := sha256.Sum256([]byte(identity + "\n" + c.Request.Method + " " + c.FullPath() + "\n" + idempotencyKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// containsMethod 请求方法是否在列表中
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
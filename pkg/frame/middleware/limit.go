@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteRules 按路由覆盖默认值
+// key支持 "METHOD /path/:id" 或 "/path/:id"(所有方法)，路径为gin注册时的完整路径
+type RouteRules[T any] map[string]T
+
+// match 获取当前路由的配置
+func (r RouteRules[T]) match(c *gin.Context, defaultValue T) T {
+	if len(r) == 0 {
+		return defaultValue
+	}
+	path := c.FullPath()
+	if v, ok := r[c.Request.Method+" "+path]; ok {
+		return v
+	}
+	if v, ok := r[path]; ok {
+		return v
+	}
+	return defaultValue
+}
+
+// TimeoutMiddleware 请求超时中间件
+// 为c.Request.Context()设置截止时间，GORM/Redis/ClickHouse等使用该ctx的调用会在超时后返回，
+// 处理结束时若已超时且尚未响应，返回5040
+// PS:超时是协作式的，不会中断处理器: 不检查ctx的处理器会执行到结束后才返回5040，超时后已写出的响应不会被替换
+// (gin.Context在中间件返回后会被复用，不能像http.TimeoutHandler一样在另一个goroutine中继续执行处理器)
+// 需要强制限制写响应时间时配合http.Server的WriteTimeout(WithGinWriteTimeout)
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return RouteTimeoutMiddleware(timeout, nil)
+}
+
+// RouteTimeoutMiddleware 按路由设置超时时间的中间件，超时时间<=0表示不限制，超时为协作式(见TimeoutMiddleware)
+// 例: RouteTimeoutMiddleware(3*time.Second, RouteRules[time.Duration]{"GET /report/export": time.Minute})
+func RouteTimeoutMiddleware(defaultTimeout time.Duration, routes RouteRules[time.Duration]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := routes.match(c, defaultTimeout)
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			response.Abort(c, enum.GATEWAY_TIMEOUT)
+		}
+	}
+}
+
+// BodyLimitMiddleware 请求体大小限制中间件
+// 需要在ContextMiddleware之前注册，Content-Length超出时直接返回413，
+// 未声明长度(chunked)的请求在读取超出时返回错误
+func BodyLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return RouteBodyLimitMiddleware(maxBytes, nil)
+}
+
+// RouteBodyLimitMiddleware 按路由设置请求体大小限制的中间件，限制<=0表示不限制
+// 例: RouteBodyLimitMiddleware(1<<20, RouteRules[int64]{"POST /upload": 100 << 20})
+func RouteBodyLimitMiddleware(defaultMaxBytes int64, routes RouteRules[int64]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maxBytes := routes.match(c, defaultMaxBytes)
+		if maxBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			response.Abort(c, enum.REQUEST_ENTITY_TOO_LARGE)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+// IsBodyTooLarge 判断读取请求体的错误是否为超出大小限制
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
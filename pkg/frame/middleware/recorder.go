@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// responseRecorder 在写出响应的同时记录响应体，超过上限后停止记录
+// 响应头在第一次写出时记录，不包含外层中间件(例: 压缩)写出时修改的Content-Encoding、Vary和ETag
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	header   http.Header
+	limit    int
+	overflow bool
+}
+
+// newResponseRecorder 创建响应记录器并替换c.Writer
+func newResponseRecorder(c *gin.Context, limit int) *responseRecorder {
+	w := &responseRecorder{ResponseWriter: c.Writer, limit: limit}
+	c.Writer = w
+	return w
+}
+
+// Write 写出并记录响应体
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.captureHeader()
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString 写出并记录响应体
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.captureHeader()
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// WriteHeaderNow 记录响应头后写出
+func (w *responseRecorder) WriteHeaderNow() {
+	w.captureHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush 记录响应头后刷新
+func (w *responseRecorder) Flush() {
+	w.captureHeader()
+	w.ResponseWriter.Flush()
+}
+
+// captureHeader 记录处理器设置的响应头，只记录第一次写出前的值
+func (w *responseRecorder) captureHeader() {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+}
+
+// record 记录响应体
+func (w *responseRecorder) record(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.limit > 0 && w.body.Len()+len(b) > w.limit {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
+
+// Unwrap 供http.ResponseController获取底层ResponseWriter
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// cachedResponse 可重放的响应
+type cachedResponse struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+}
+
+// 重放响应时不复制的响应头
+var skipReplayHeaders = map[string]struct{}{
+	"Content-Length":    {},
+	"Date":              {},
+	"Set-Cookie":        {},
+	"Transfer-Encoding": {},
+	"Connection":        {},
+}
+
+// snapshot 获取已记录的响应，响应体超过上限时返回false
+func (w *responseRecorder) snapshot() (*cachedResponse, bool) {
+	if w.overflow {
+		return nil, false
+	}
+	w.captureHeader()
+	header := make(map[string][]string)
+	for k, v := range w.header {
+		if _, skip := skipReplayHeaders[k]; skip {
+			continue
+		}
+		header[k] = append([]string(nil), v...)
+	}
+	return &cachedResponse{
+		Status: w.Status(),
+		Header: header,
+		Body:   append([]byte(nil), w.body.Bytes()...),
+	}, true
+}
+
+// replay 重放响应并终止后续处理
+func (r *cachedResponse) replay(c *gin.Context, extraHeaders map[string]string) {
+	r.write(c, extraHeaders)
+	c.Abort()
+}
+
+// write 写出响应
+func (r *cachedResponse) write(c *gin.Context, extraHeaders map[string]string) {
+	for k, v := range r.Header {
+		c.Writer.Header()[k] = append([]string(nil), v...)
+	}
+	for k, v := range extraHeaders {
+		c.Header(k, v)
+	}
+	c.Status(r.Status)
+	if len(r.Body) > 0 {
+		_, _ = c.Writer.Write(r.Body)
+	} else {
+		c.Writer.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,228 @@
+package realip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的客户端IP请求头
+const (
+	HeaderXForwardedFor  = "X-Forwarded-For"
+	HeaderXRealIP        = "X-Real-IP"
+	HeaderCFConnectingIP = "CF-Connecting-IP"
+	HeaderForwarded      = "Forwarded" // RFC 7239
+)
+
+// ContextKey 解析后的客户端IP在gin.Context中的key
+const ContextKey = "frame.client_ip"
+
+// DefaultHeaders 默认检查的请求头，只使用X-Forwarded-For并从右往左跳过受信任代理
+// 代理只会在X-Forwarded-For末尾追加直连地址，客户端伪造的值位于左侧，不会被选中
+var DefaultHeaders = []string{
+	HeaderXForwardedFor,
+}
+
+// ResolverOption 定义解析器选项函数类型
+type ResolverOption func(*Resolver)
+
+// WithTrustUnixSocket 信任通过unix socket连接的请求(无法获取直连IP)，从请求头中读取客户端IP
+// 只在socket文件仅允许本机代理访问时开启
+func WithTrustUnixSocket(trust bool) ResolverOption {
+	return func(r *Resolver) {
+		r.trustUnixSocket = trust
+	}
+}
+
+// Resolver 客户端真实IP解析器
+// 只有当直连地址属于受信任代理时，才会读取请求头中的IP
+type Resolver struct {
+	trusted         []netip.Prefix
+	headers         []string
+	trustUnixSocket bool
+}
+
+// NewResolver 创建客户端IP解析器
+// trustedProxies 受信任的代理，支持CIDR和单个IP，为空则不信任任何代理
+// headers 按顺序检查的请求头，为空则使用DefaultHeaders
+// PS:CF-Connecting-IP、X-Real-IP、Forwarded需显式开启，且只能在所有受信任代理都会覆盖该请求头时使用，否则客户端可以自行设置
+func NewResolver(trustedProxies []string, headers []string, opts ...ResolverOption) (*Resolver, error) {
+	r := &Resolver{
+		trusted: make([]netip.Prefix, 0, len(trustedProxies)),
+		headers: headers,
+	}
+	if len(r.headers) == 0 {
+		r.headers = DefaultHeaders
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy cidr(%s): %v", proxy, err)
+			}
+			r.trusted = append(r.trusted, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy ip(%s): %v", proxy, err)
+		}
+		addr = addr.Unmap()
+		r.trusted = append(r.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return r, nil
+}
+
+// IsTrusted 判断IP是否属于受信任代理
+func (r *Resolver) IsTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers 按顺序检查的请求头
+func (r *Resolver) Headers() []string {
+	return r.headers
+}
+
+// ClientIP 解析客户端真实IP
+func (r *Resolver) ClientIP(req *http.Request) string {
+	remote, ok := parseIP(req.RemoteAddr)
+	if !ok {
+		// unix socket等无法解析直连地址的情况，只有显式信任时才读取请求头
+		if !r.trustUnixSocket {
+			return ""
+		}
+		if ip, found := r.fromHeaders(req); found {
+			return ip
+		}
+		return ""
+	}
+
+	if !r.IsTrusted(remote) {
+		return remote.String()
+	}
+	if ip, found := r.fromHeaders(req); found {
+		return ip
+	}
+	return remote.String()
+}
+
+// fromHeaders 按顺序从请求头中解析IP
+func (r *Resolver) fromHeaders(req *http.Request) (string, bool) {
+	for _, header := range r.headers {
+		values := req.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+
+		var chain []netip.Addr
+		switch http.CanonicalHeaderKey(header) {
+		case http.CanonicalHeaderKey(HeaderForwarded):
+			chain = parseForwarded(values)
+		default:
+			chain = parseList(values)
+		}
+		if len(chain) == 0 {
+			continue
+		}
+
+		// 从右往左找到第一个非受信任代理的IP
+		for i := len(chain) - 1; i >= 0; i-- {
+			if !r.IsTrusted(chain[i]) {
+				return chain[i].String(), true
+			}
+		}
+		// 全部为受信任代理时取最左侧
+		return chain[0].String(), true
+	}
+	return "", false
+}
+
+// parseList 解析逗号分隔的IP列表(X-Forwarded-For/X-Real-IP/CF-Connecting-IP)
+// 任意一项非法时整个请求头视为无效
+func parseList(values []string) []netip.Addr {
+	var chain []netip.Addr
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			addr, ok := parseIP(strings.TrimSpace(item))
+			if !ok {
+				return nil
+			}
+			chain = append(chain, addr)
+		}
+	}
+	return chain
+}
+
+// parseForwarded 解析RFC 7239 Forwarded请求头中的for参数
+// 例: Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"
+func parseForwarded(values []string) []netip.Addr {
+	var chain []netip.Addr
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(key), "for") {
+					continue
+				}
+				addr, ok := parseIP(strings.Trim(strings.TrimSpace(val), `"`))
+				if !ok {
+					// 混淆标识(如for=unknown、for=_hidden)无法作为客户端IP
+					return nil
+				}
+				chain = append(chain, addr)
+			}
+		}
+	}
+	return chain
+}
+
+// parseIP 解析IP，兼容带端口和IPv6方括号的格式
+func parseIP(s string) (netip.Addr, bool) {
+	if s == "" {
+		return netip.Addr{}, false
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// Middleware 解析客户端IP并保存到gin.Context
+func Middleware(r *Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ContextKey, r.ClientIP(c.Request))
+		c.Next()
+	}
+}
+
+// FromGin 获取解析后的客户端IP，未经过Middleware时使用gin的ClientIP
+func FromGin(c *gin.Context) string {
+	if ip := c.GetString(ContextKey); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
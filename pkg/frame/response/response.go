@@ -0,0 +1,97 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+	"github.com/boloc/go-frame-server/pkg/throw/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response 统一响应结构
+type Response struct {
+	Code    int    `json:"code"`    // 业务码，0为成功
+	Message string `json:"message"` // 提示信息
+	Data    any    `json:"data"`    // 响应数据
+}
+
+// HTTPStatus 根据业务码获取HTTP状态码
+// 业务码为HTTP状态码*10(+子码)，如 4001 -> 400、5040 -> 504，非标准状态码按4xx/5xx归类；
+// 只有4000-5999按业务码推导，失败响应不会使用1xx/2xx/3xx状态码(会丢弃响应体或要求Location)，
+// 小于4000的自定义业务码为400，其余为500
+func HTTPStatus(code int) int {
+	if code == enum.SUCCESS {
+		return http.StatusOK
+	}
+	switch {
+	case code > 0 && code < 4000:
+		return http.StatusBadRequest
+	case code < 4000 || code >= 6000:
+		return http.StatusInternalServerError
+	}
+	status := code / 10
+	if http.StatusText(status) != "" {
+		return status
+	}
+	if status < 500 {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Success 成功响应
+func Success(c *gin.Context, data any) {
+	c.JSON(http.StatusOK, &Response{
+		Code:    enum.SUCCESS,
+		Message: enum.GetMessage(enum.SUCCESS),
+		Data:    data,
+	})
+}
+
+// Fail 失败响应，msg为空时使用业务码对应的默认信息
+func Fail(c *gin.Context, code int, msg ...string) {
+	c.JSON(HTTPStatus(code), newFail(code, msg...))
+}
+
+// Abort 失败响应并终止后续处理
+func Abort(c *gin.Context, code int, msg ...string) {
+	c.AbortWithStatusJSON(HTTPStatus(code), newFail(code, msg...))
+}
+
+// Error 根据错误响应，throw异常使用其业务码，请求超时使用5040，其余为5000
+func Error(c *gin.Context, err error) {
+	code, msg := ErrorCode(c.Request.Context(), err)
+	c.AbortWithStatusJSON(HTTPStatus(code), &Response{
+		Code:    code,
+		Message: msg,
+		Data:    nil,
+	})
+}
+
+// ErrorCode 获取错误对应的业务码和提示信息
+func ErrorCode(ctx context.Context, err error) (int, string) {
+	// 请求已超时，下游(DB/Redis等)返回的错误统一视为超时
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return enum.GATEWAY_TIMEOUT, enum.GetMessage(enum.GATEWAY_TIMEOUT)
+	}
+	if exception, ok := handler.AsException(err); ok {
+		return exception.Code, exception.ErrorMsg
+	}
+	return enum.SERVER_ERROR, enum.GetMessage(enum.SERVER_ERROR)
+}
+
+// newFail 创建失败响应
+func newFail(code int, msg ...string) *Response {
+	message := enum.GetMessage(code)
+	if len(msg) > 0 && msg[0] != "" {
+		message = msg[0]
+	}
+	return &Response{
+		Code:    code,
+		Message: message,
+		Data:    nil,
+	}
+}
@@ -0,0 +1,255 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteModule 路由模块，各业务包各自实现并注册，避免所有路由堆在一个文件中
+type RouteModule interface {
+	// Name 模块名称，用于路由表展示和错误提示
+	Name() string
+	// Prefix 路由前缀，如 "/orders"
+	Prefix() string
+	// Version API版本，如 "v1"，为空则不分版本
+	Version() string
+	// Middlewares 模块级中间件
+	Middlewares() []gin.HandlerFunc
+	// Register 在模块路由组下注册路由
+	Register(group *gin.RouterGroup)
+}
+
+// ModuleOption 定义路由模块选项函数类型
+type ModuleOption func(*module)
+
+// module RouteModule的默认实现
+type module struct {
+	name        string
+	prefix      string
+	version     string
+	middlewares []gin.HandlerFunc
+	register    func(group *gin.RouterGroup)
+}
+
+// WithVersion 设置模块API版本
+func WithVersion(version string) ModuleOption {
+	return func(m *module) {
+		m.version = version
+	}
+}
+
+// WithMiddleware 添加模块级中间件
+func WithMiddleware(middleware ...gin.HandlerFunc) ModuleOption {
+	return func(m *module) {
+		m.middlewares = append(m.middlewares, middleware...)
+	}
+}
+
+// NewModule 创建路由模块
+func NewModule(name, prefix string, register func(group *gin.RouterGroup), opts ...ModuleOption) RouteModule {
+	m := &module{
+		name:        name,
+		prefix:      prefix,
+		middlewares: make([]gin.HandlerFunc, 0),
+		register:    register,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *module) Name() string                   { return m.name }
+func (m *module) Prefix() string                 { return m.prefix }
+func (m *module) Version() string                { return m.version }
+func (m *module) Middlewares() []gin.HandlerFunc { return m.middlewares }
+func (m *module) Register(group *gin.RouterGroup) {
+	if m.register != nil {
+		m.register(group)
+	}
+}
+
+var (
+	modules   = make([]RouteModule, 0)
+	modulesMu sync.RWMutex
+)
+
+// Register 注册路由模块到全局，通常在业务包的init中调用，GinComponent启动时统一挂载
+func Register(routeModules ...RouteModule) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	modules = append(modules, routeModules...)
+}
+
+// Modules 获取全局注册的路由模块
+func Modules() []RouteModule {
+	modulesMu.RLock()
+	defer modulesMu.RUnlock()
+	return append([]RouteModule(nil), modules...)
+}
+
+// BasePath 获取模块的完整路由前缀，如 /v1/orders
+func BasePath(m RouteModule) string {
+	base := "/"
+	if m.Version() != "" {
+		base = path.Join(base, m.Version())
+	}
+	if m.Prefix() != "" {
+		base = path.Join(base, m.Prefix())
+	}
+	return base
+}
+
+// RouteInfo 路由信息
+type RouteInfo struct {
+	Module  string // 所属模块，直接注册在engine上的为空
+	Version string // API版本
+	Method  string
+	Path    string
+	Handler string
+}
+
+// Mount 将路由模块挂载到engine，返回各模块注册的路由
+// 路由重复时返回错误，而不是由gin直接panic
+func Mount(engine *gin.Engine, routeModules ...RouteModule) (routes []RouteInfo, err error) {
+	for _, m := range routeModules {
+		before := routeSet(engine)
+
+		if err := Safe(m.Name(), func() {
+			group := engine.Group(BasePath(m), m.Middlewares()...)
+			m.Register(group)
+		}); err != nil {
+			return nil, err
+		}
+
+		for _, r := range engine.Routes() {
+			if _, exist := before[r.Method+" "+r.Path]; exist {
+				continue
+			}
+			routes = append(routes, RouteInfo{
+				Module:  m.Name(),
+				Version: m.Version(),
+				Method:  r.Method,
+				Path:    r.Path,
+				Handler: r.Handler,
+			})
+		}
+	}
+	return routes, nil
+}
+
+// Safe 执行路由注册，将gin的路由冲突panic转换为错误
+func Safe(name string, register func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to register routes of [%s]: %v", name, r)
+		}
+	}()
+	register()
+	return nil
+}
+
+// routeSet 获取engine当前已注册的路由
+func routeSet(engine *gin.Engine) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, r := range engine.Routes() {
+		set[r.Method+" "+r.Path] = struct{}{}
+	}
+	return set
+}
+
+// PrintRoutes 打印路由表
+// moduleRoutes 为Mount返回的模块路由，其余engine上的路由模块列显示为"-"
+func PrintRoutes(engine *gin.Engine, moduleRoutes []RouteInfo) {
+	byKey := make(map[string]RouteInfo, len(moduleRoutes))
+	for _, r := range moduleRoutes {
+		byKey[r.Method+" "+r.Path] = r
+	}
+
+	all := engine.Routes()
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Path == all[j].Path {
+			return all[i].Method < all[j].Method
+		}
+		return all[i].Path < all[j].Path
+	})
+
+	var b strings.Builder
+	b.WriteString("------------路由表--------------------\n")
+	fmt.Fprintf(&b, "%-8s %-40s %-16s %s\n", "METHOD", "PATH", "MODULE", "HANDLER")
+	for _, r := range all {
+		name := "-"
+		if info, ok := byKey[r.Method+" "+r.Path]; ok {
+			name = info.Module
+		}
+		fmt.Fprintf(&b, "%-8s %-40s %-16s %s\n", r.Method, r.Path, name, r.Handler)
+	}
+	b.WriteString("------------分割线--------------------")
+	fmt.Println(b.String())
+}
+
+// VersionHeaderHandler 基于请求头的API版本路由
+// 请求路径属于带版本模块的前缀且未携带版本时，按请求头(缺省为defaultVersion)改写为 /{version}/path，未注册的版本不改写
+// 例: GET /orders/1 + X-API-Version: v2 -> GET /v2/orders/1
+func VersionHeaderHandler(next http.Handler, header, defaultVersion string, routeModules []RouteModule) http.Handler {
+	prefixes := make(map[string]struct{})
+	versions := make(map[string]struct{})
+	for _, m := range routeModules {
+		if m.Version() == "" || strings.Trim(m.Prefix(), "/") == "" {
+			continue
+		}
+		prefixes[path.Join("/", m.Prefix())] = struct{}{}
+		versions[m.Version()] = struct{}{}
+	}
+	if len(prefixes) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlPath := r.URL.Path
+		if hasVersionPrefix(urlPath, versions) || !hasModulePrefix(urlPath, prefixes) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := strings.TrimSpace(r.Header.Get(header))
+		if version == "" {
+			version = defaultVersion
+		}
+		if _, ok := versions[version]; !ok {
+			// 只接受已注册的版本，避免请求头拼接出任意路径
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/" + version + urlPath
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = "/" + version + r.URL.RawPath
+		}
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// hasVersionPrefix 路径是否已携带版本
+func hasVersionPrefix(urlPath string, versions map[string]struct{}) bool {
+	first, _, _ := strings.Cut(strings.TrimPrefix(urlPath, "/"), "/")
+	_, ok := versions[first]
+	return ok
+}
+
+// hasModulePrefix 路径是否属于带版本模块的前缀
+func hasModulePrefix(urlPath string, prefixes map[string]struct{}) bool {
+	for prefix := range prefixes {
+		if urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBuffer 基于Redis Stream的事件缓冲，用于客户端断线重连(包括重连到其他实例)后补发事件
+type RedisBuffer struct {
+	client redis.UniversalClient
+	key    string
+	maxLen int64         // 最多保留的事件数(近似裁剪)
+	ttl    time.Duration // 无新事件时缓冲的过期时间
+}
+
+// NewRedisBuffer 创建事件缓冲
+// maxLen<=0时默认保留1000条，ttl<=0时不过期
+func NewRedisBuffer(client redis.UniversalClient, key string, maxLen int64, ttl time.Duration) *RedisBuffer {
+	if maxLen <= 0 {
+		maxLen = 1000
+	}
+	return &RedisBuffer{client: client, key: key, maxLen: maxLen, ttl: ttl}
+}
+
+// Append 写入事件，返回Redis Stream ID作为事件ID
+func (b *RedisBuffer) Append(ctx context.Context, event Event) (Event, error) {
+	if err := validate(event); err != nil {
+		return event, err
+	}
+	data, err := encodeData(event.Data)
+	if err != nil {
+		return event, err
+	}
+
+	pipe := b.client.Pipeline()
+	add := pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.key,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]any{"event": event.Event, "data": data},
+	})
+	if b.ttl > 0 {
+		pipe.Expire(ctx, b.key, b.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return event, err
+	}
+
+	event.ID = add.Val()
+	event.Data = data
+	return event, nil
+}
+
+// Since 获取lastID之后的事件
+// lastID不是Stream ID格式(如其他来源的事件ID)时不补发
+func (b *RedisBuffer) Since(ctx context.Context, lastID string) ([]Event, error) {
+	if !isStreamID(lastID) {
+		return nil, nil
+	}
+	messages, err := b.client.XRange(ctx, b.key, "("+lastID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(messages))
+	for _, msg := range messages {
+		events = append(events, toEvent(msg))
+	}
+	return events, nil
+}
+
+// Subscribe 阻塞读取lastID之后的新事件，ctx结束时关闭channel
+// lastID为空则从当前最新位置开始
+func (b *RedisBuffer) Subscribe(ctx context.Context, lastID string) <-chan Event {
+	if lastID == "" {
+		lastID = "$"
+	}
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{b.key, lastID},
+				Count:   100,
+				Block:   5 * time.Second,
+			}).Result()
+			if err != nil {
+				if errors.Is(err, redis.Nil) {
+					continue
+				}
+				// 连接异常时稍后重试
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+			for _, stream := range streams {
+				for _, msg := range stream.Messages {
+					lastID = msg.ID
+					select {
+					case ch <- toEvent(msg):
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// toEvent Redis Stream消息转换为事件
+func toEvent(msg redis.XMessage) Event {
+	event := Event{ID: msg.ID}
+	if v, ok := msg.Values["event"].(string); ok {
+		event.Event = v
+	}
+	if v, ok := msg.Values["data"].(string); ok {
+		event.Data = v
+	}
+	return event
+}
+
+// compareStreamID 比较Redis Stream ID(毫秒时间戳-序号)
+func compareStreamID(a, b string) int {
+	aMs, aSeq := parseStreamID(a)
+	bMs, bSeq := parseStreamID(b)
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isStreamID 是否为Redis Stream ID格式
+func isStreamID(id string) bool {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return false
+	}
+	_, msErr := strconv.ParseUint(msPart, 10, 64)
+	_, seqErr := strconv.ParseUint(seqPart, 10, 64)
+	return msErr == nil && seqErr == nil
+}
+
+// parseStreamID 解析Redis Stream ID，非法ID视为0
+func parseStreamID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
@@ -0,0 +1,305 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrShutdown 服务停止导致流结束，客户端会携带Last-Event-ID自动重连
+var ErrShutdown = errors.New("sse: server shutting down")
+
+// ErrInvalidField 事件ID或事件类型包含换行符，写入后会被客户端解析为额外的字段
+var ErrInvalidField = errors.New("sse: event id and event type must not contain line breaks")
+
+// hubKey gin.Context中保存Hub的键名
+const hubKey = "sse_hub"
+
+// Event SSE事件
+type Event struct {
+	ID    string        // 事件ID，客户端重连时通过Last-Event-ID带回
+	Event string        // 事件类型，为空则为message
+	Data  any           // 事件数据，string/[]byte原样输出，其余类型序列化为JSON
+	Retry time.Duration // 建议客户端重连间隔，0则不设置
+}
+
+// Option 定义SSE选项函数类型
+type Option func(*streamConfig)
+
+// streamConfig SSE流配置
+type streamConfig struct {
+	keepAlive time.Duration // 心跳注释间隔
+	retry     time.Duration // 建议客户端重连间隔
+	buffer    *RedisBuffer  // 断线续传缓冲
+}
+
+// WithKeepAlive 设置心跳注释间隔，防止代理因空闲断开连接，<=0则不发送
+func WithKeepAlive(interval time.Duration) Option {
+	return func(c *streamConfig) {
+		c.keepAlive = interval
+	}
+}
+
+// WithRetry 设置建议客户端重连间隔
+func WithRetry(retry time.Duration) Option {
+	return func(c *streamConfig) {
+		c.retry = retry
+	}
+}
+
+// WithResume 设置断线续传缓冲，请求携带Last-Event-ID时先补发缓冲中之后的事件
+// 事件需通过RedisBuffer.Append写入，使用Redis Stream ID作为事件ID
+func WithResume(buffer *RedisBuffer) Option {
+	return func(c *streamConfig) {
+		c.buffer = buffer
+	}
+}
+
+// Stream 将channel中的事件以SSE推送给客户端，直到channel关闭、客户端断开或服务停止
+// 服务停止开始后建立的流直接返回503和ErrShutdown
+// 服务停止的通知来自Middleware登记的Hub，GinComponent默认已登记
+// 会清除http.Server的WriteTimeout，但请求超时中间件设置的ctx截止时间仍然生效，
+// SSE路由需要在RouteTimeoutMiddleware中将超时设为0
+func Stream(c *gin.Context, events <-chan Event, opts ...Option) error {
+	config := &streamConfig{keepAlive: 15 * time.Second}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	release, closing, ok := hubFromGin(c).acquire()
+	if !ok {
+		// 服务停止中不再建立新流，客户端稍后重连到其他实例
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return ErrShutdown
+	}
+	defer release()
+
+	w := c.Writer
+	rc := http.NewResponseController(w)
+	// 长连接不受http.Server.WriteTimeout限制
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭nginx缓冲
+	w.WriteHeader(http.StatusOK)
+
+	ctx := c.Request.Context()
+	if config.retry > 0 {
+		if _, err := fmt.Fprintf(w, "retry: %d\n\n", config.retry.Milliseconds()); err != nil {
+			return err
+		}
+	}
+
+	// 补发断线期间的事件
+	lastID := c.GetHeader("Last-Event-ID")
+	if config.buffer != nil && lastID != "" {
+		missed, err := config.buffer.Since(ctx, lastID)
+		if err != nil {
+			return err
+		}
+		for _, event := range missed {
+			if err := Encode(w, event); err != nil {
+				return err
+			}
+			lastID = event.ID
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+
+	var keepAlive <-chan time.Time
+	if config.keepAlive > 0 {
+		ticker := time.NewTicker(config.keepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-closing:
+			return ErrShutdown
+		case <-keepAlive:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return err
+			}
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			// 已补发过的事件不重复推送
+			if config.buffer != nil && event.ID != "" && lastID != "" && compareStreamID(event.ID, lastID) <= 0 {
+				continue
+			}
+			if err := Encode(w, event); err != nil {
+				return err
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// Encode 按SSE格式写入事件，ID和事件类型包含换行符时返回ErrInvalidField
+func Encode(w io.Writer, event Event) error {
+	if err := validate(event); err != nil {
+		return err
+	}
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: ")
+		b.WriteString(event.ID)
+		b.WriteByte('\n')
+	}
+	if event.Event != "" {
+		b.WriteString("event: ")
+		b.WriteString(event.Event)
+		b.WriteByte('\n')
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", event.Retry.Milliseconds())
+	}
+
+	data, err := encodeData(event.Data)
+	if err != nil {
+		return err
+	}
+	// 多行数据每行一个data字段
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: ")
+		b.WriteString(strings.TrimSuffix(line, "\r"))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// validate 校验单行字段，data中的换行按多个data字段输出，不需要校验
+func validate(event Event) error {
+	if strings.ContainsAny(event.ID, "\r\n") || strings.ContainsAny(event.Event, "\r\n") {
+		return ErrInvalidField
+	}
+	return nil
+}
+
+// encodeData 事件数据转换为字符串
+func encodeData(data any) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// Hub 记录进行中的SSE流，服务停止时通知其结束
+// 每个GinComponent使用独立的Hub，停止一个组件不影响其他组件的SSE流
+type Hub struct {
+	mu      sync.Mutex
+	closing chan struct{}
+	closed  bool // Shutdown后不再登记新的流，直到Reset
+	active  int
+}
+
+// NewHub 创建Hub
+func NewHub() *Hub {
+	return &Hub{closing: make(chan struct{})}
+}
+
+// Middleware 将Hub保存到gin.Context，Stream通过它登记SSE流
+func Middleware(h *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(hubKey, h)
+		c.Next()
+	}
+}
+
+// hubFromGin 获取Middleware保存的Hub，未登记时返回nil，流不会收到停止通知
+func hubFromGin(c *gin.Context) *Hub {
+	h, _ := c.Value(hubKey).(*Hub)
+	return h
+}
+
+// acquire 登记一个SSE流，Hub为nil时返回永不关闭的通知channel；已开始停止时返回false
+func (h *Hub) acquire() (release func(), closing <-chan struct{}, ok bool) {
+	if h == nil {
+		return func() {}, nil, true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, nil, false
+	}
+	h.active++
+	return func() {
+		h.mu.Lock()
+		h.active--
+		h.mu.Unlock()
+	}, h.closing, true
+}
+
+// Active 进行中的SSE流数量
+func (h *Hub) Active() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.active
+}
+
+// Reset 重新接受SSE流，由GinComponent.Start调用(组件重启)
+func (h *Hub) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		h.closing = make(chan struct{})
+		h.closed = false
+	}
+}
+
+// Shutdown 通知进行中的SSE流结束并等待其退出，之后建立的流直接被拒绝，由GinComponent.Stop在关闭监听前调用
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		close(h.closing)
+		h.closed = true
+	}
+	h.mu.Unlock()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for h.Active() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
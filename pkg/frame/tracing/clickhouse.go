@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// clickhouseConn 为查询创建span的ClickHouse连接
+type clickhouseConn struct {
+	driver.Conn
+	component string
+}
+
+// WrapClickHouse 包装ClickHouse连接，例: components.WithClickHouseConnWrapper(tracing.WrapClickHouse)
+// Query的span在读取完成(Rows.Close)时结束，PrepareBatch的span在Send/Abort时结束；不在请求链路中的查询不记录
+func WrapClickHouse(component string, conn driver.Conn) driver.Conn {
+	return &clickhouseConn{Conn: conn, component: component}
+}
+
+// start 创建span
+func (c *clickhouseConn) start(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return ctx, nil
+	}
+	return Tracer().Start(ctx, "clickhouse."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemClickhouse,
+			semconv.DBOperationName(clickhouseOperation(query, operation)),
+			semconv.DBQueryText(query),
+			attribute.String("db.instance", c.component),
+		),
+	)
+}
+
+// Select 查询到结构体切片
+func (c *clickhouseConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := c.start(ctx, "select", query)
+	err := c.Conn.Select(ctx, dest, query, args...)
+	endSpan(span, err)
+	return err
+}
+
+// Query 查询
+func (c *clickhouseConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	ctx, span := c.start(ctx, "query", query)
+	rows, err := c.Conn.Query(ctx, query, args...)
+	if err != nil || span == nil {
+		endSpan(span, err)
+		return rows, err
+	}
+	return &clickhouseRows{Rows: rows, span: span}, nil
+}
+
+// QueryRow 查询单行
+func (c *clickhouseConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	ctx, span := c.start(ctx, "query_row", query)
+	row := c.Conn.QueryRow(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// PrepareBatch 批量写入
+func (c *clickhouseConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	ctx, span := c.start(ctx, "batch", query)
+	batch, err := c.Conn.PrepareBatch(ctx, query, opts...)
+	if err != nil || span == nil {
+		endSpan(span, err)
+		return batch, err
+	}
+	return &clickhouseBatch{Batch: batch, span: span}, nil
+}
+
+// Exec 执行
+func (c *clickhouseConn) Exec(ctx context.Context, query string, args ...any) error {
+	ctx, span := c.start(ctx, "exec", query)
+	err := c.Conn.Exec(ctx, query, args...)
+	endSpan(span, err)
+	return err
+}
+
+// AsyncInsert 异步写入
+func (c *clickhouseConn) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	ctx, span := c.start(ctx, "async_insert", query)
+	err := c.Conn.AsyncInsert(ctx, query, wait, args...)
+	endSpan(span, err)
+	return err
+}
+
+// clickhouseRows 关闭时结束span
+type clickhouseRows struct {
+	driver.Rows
+	span trace.Span
+}
+
+// Close 关闭并结束span
+func (r *clickhouseRows) Close() error {
+	err := r.Rows.Close()
+	if err == nil {
+		err = r.Rows.Err()
+	}
+	endSpan(r.span, err)
+	return err
+}
+
+// clickhouseBatch 发送或放弃时结束span
+type clickhouseBatch struct {
+	driver.Batch
+	span trace.Span
+}
+
+// Send 发送并结束span
+func (b *clickhouseBatch) Send() error {
+	err := b.Batch.Send()
+	b.span.SetAttributes(attribute.Int("db.clickhouse.rows", b.Batch.Rows()))
+	endSpan(b.span, err)
+	return err
+}
+
+// Abort 放弃并结束span
+func (b *clickhouseBatch) Abort() error {
+	err := b.Batch.Abort()
+	b.span.SetAttributes(attribute.Bool("db.clickhouse.aborted", true))
+	endSpan(b.span, err)
+	return err
+}
+
+// clickhouseOperation SQL的第一个单词，解析不到时使用方法名
+func clickhouseOperation(query, operation string) string {
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return strings.ToLower(fields[0])
+	}
+	return operation
+}
+
+// endSpan 记录错误并结束span，span为nil时忽略
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"errors"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// GormOption 定义GORM插件选项函数类型
+type GormOption func(*gormPlugin)
+
+// WithGormQueryText 设置是否记录SQL，默认记录(参数使用占位符，不记录参数值)
+func WithGormQueryText(enable bool) GormOption {
+	return func(p *gormPlugin) {
+		p.queryText = enable
+	}
+}
+
+// gormPlugin 为每条语句创建span
+type gormPlugin struct {
+	queryText bool
+}
+
+// instance中保存span的key
+const gormSpanKey = "tracing:span"
+
+// GormPlugin 创建GORM插件，例: MySQLConfig.Plugins、mysqlComponent.Use(tracing.GormPlugin())
+// span属性包含组件名称(db.instance)和主从角色(db.role)，需通过db.WithContext(ctx)传入上游span
+func GormPlugin(opts ...GormOption) gorm.Plugin {
+	p := &gormPlugin{queryText: true}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Name 插件名称
+func (p *gormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize 注册回调
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", p.before("create")),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", p.before("query")),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", p.before("update")),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", p.before("row")),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+// before 创建span
+func (p *gormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			// 不在请求链路中的语句(如启动、定时任务)不单独成链
+			return
+		}
+		component, role := components.GormInstance(db)
+		attributes := []attribute.KeyValue{
+			dbSystem(db.Dialector.Name()),
+			semconv.DBOperationName(operation),
+			attribute.String("db.instance", component),
+			attribute.String("db.role", role),
+		}
+		if db.Statement.Table != "" {
+			attributes = append(attributes, semconv.DBCollectionName(db.Statement.Table))
+		}
+		_, span := Tracer().Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attributes...),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// after 记录SQL、影响行数和错误并结束span
+func (p *gormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if p.queryText && db.Statement.SQL.Len() > 0 {
+		span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+	}
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	if db.RowsAffected >= 0 { // Row/Rows不统计影响行数
+		span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+	}
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+// dbSystem 数据库类型
+func dbSystem(dialector string) attribute.KeyValue {
+	switch dialector {
+	case "mysql":
+		return semconv.DBSystemMySQL
+	case "clickhouse":
+		return semconv.DBSystemClickhouse
+	default:
+		return semconv.DBSystemKey.String(dialector)
+	}
+}
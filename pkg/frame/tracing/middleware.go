@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/realip"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MiddlewareOption 定义中间件选项函数类型
+type MiddlewareOption func(*middlewareConfig)
+
+// middlewareConfig 中间件配置
+type middlewareConfig struct {
+	skipPaths   map[string]bool
+	traceHeader string
+}
+
+// WithMiddlewareSkipPaths 设置不记录的路径，例: 健康检查/readyz、/livez
+func WithMiddlewareSkipPaths(paths ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		for _, path := range paths {
+			c.skipPaths[path] = true
+		}
+	}
+}
+
+// WithMiddlewareTraceHeader 设置返回trace_id的响应头，默认X-Trace-ID，为空不返回
+func WithMiddlewareTraceHeader(header string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.traceHeader = header
+	}
+}
+
+// Middleware 创建链路追踪中间件，从请求头traceparent/tracestate继续上游的链路，需在ContextMiddleware之前注册
+// span名称为 "方法 路由"，5xx标记为错误
+func Middleware(opts ...MiddlewareOption) gin.HandlerFunc {
+	conf := &middlewareConfig{
+		skipPaths:   make(map[string]bool),
+		traceHeader: "X-Trace-ID",
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return func(c *gin.Context) {
+		if conf.skipPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		spanName := c.Request.Method
+		if route != "" {
+			spanName += " " + route
+		}
+		attributes := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(realip.FromGin(c)),
+			semconv.UserAgentOriginal(c.Request.UserAgent()),
+		}
+		if route != "" {
+			attributes = append(attributes, semconv.HTTPRoute(route))
+		}
+		ctx, span := Tracer().Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attributes...),
+		)
+		defer span.End()
+
+		if conf.traceHeader != "" && span.SpanContext().IsValid() {
+			c.Header(conf.traceHeader, span.SpanContext().TraceID().String())
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if requestID := content.RequestIDFromContext(c.Request.Context()); requestID != "" {
+			span.SetAttributes(attribute.String("request.id", requestID))
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+	}
+}
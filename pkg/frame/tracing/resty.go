@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// restyParentKey 请求context中保存发起请求时的原始context，重试时从原始context重新创建span
+type restyParentKey struct{}
+
+// InstrumentResty 为resty客户端的请求创建span并在请求头中注入traceparent，例: tracing.InstrumentResty(util.GetClient())
+// 每次重试单独一个span；不在请求链路中的请求不记录，需通过request.SetContext(ctx)传入上游span
+func InstrumentResty(client *resty.Client) *resty.Client {
+	client.OnBeforeRequest(func(c *resty.Client, r *resty.Request) error {
+		ctx := r.Context()
+		if parent, ok := ctx.Value(restyParentKey{}).(context.Context); ok {
+			// 重试，结束上一次请求的span
+			trace.SpanFromContext(ctx).End()
+			ctx = parent
+		}
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			return nil
+		}
+		spanCtx, _ := Tracer().Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLFull(restyURL(c, r)),
+			),
+		)
+		otel.GetTextMapPropagator().Inject(spanCtx, propagation.HeaderCarrier(r.Header))
+		r.SetContext(context.WithValue(spanCtx, restyParentKey{}, ctx))
+		return nil
+	})
+	client.OnAfterResponse(func(c *resty.Client, resp *resty.Response) error {
+		span, ok := restySpan(resp.Request)
+		if !ok {
+			return nil
+		}
+		status := resp.StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		span.End()
+		return nil
+	})
+	client.OnError(func(r *resty.Request, err error) {
+		span, ok := restySpan(r)
+		if !ok {
+			return
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+	})
+	return client
+}
+
+// restySpan 获取InstrumentResty创建的span
+func restySpan(r *resty.Request) (trace.Span, bool) {
+	if r == nil {
+		return nil, false
+	}
+	ctx := r.Context()
+	if _, ok := ctx.Value(restyParentKey{}).(context.Context); !ok {
+		return nil, false
+	}
+	return trace.SpanFromContext(ctx), true
+}
+
+// restyURL 请求地址，相对地址拼接客户端的BaseURL；不记录查询参数和用户信息，避免泄露令牌
+func restyURL(c *resty.Client, r *resty.Request) string {
+	raw := r.URL
+	if c.BaseURL != "" && strings.HasPrefix(raw, "/") {
+		raw = strings.TrimSuffix(c.BaseURL, "/") + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+	return u.String()
+}
@@ -0,0 +1,308 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+// 导出方式
+const (
+	ExporterOTLP   = "otlp"   // OTLP/HTTP，发送到Collector、Jaeger、Tempo等
+	ExporterStdout = "stdout" // 输出到标准输出(本地调试)
+	ExporterFile   = "file"   // 每行一个JSON写入文件(离线环境)
+	ExporterNone   = "none"   // 只生成trace_id用于日志串联，不导出
+)
+
+// instrumentationName 埋点名称
+const instrumentationName = "github.com/boloc/go-frame-server/pkg/frame/tracing"
+
+// TracingOption 定义链路追踪选项函数类型
+type TracingOption func(*TracingComponent)
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	ServiceName    string            // 服务名称
+	ServiceVersion string            // 服务版本
+	Environment    string            // 部署环境
+	Exporter       string            // 导出方式 otlp/stdout/file/none
+	Endpoint       string            // OTLP地址，例: http://127.0.0.1:4318，路径默认/v1/traces
+	Headers        map[string]string // OTLP请求头，例: 认证
+	Timeout        time.Duration     // OTLP导出超时
+	FilePath       string            // file导出的文件路径
+	SampleRatio    float64           // 根span采样率 0~1，有上游span时跟随上游的采样决定
+	Attributes     map[string]string // 额外的资源属性
+	BatchTimeout   time.Duration     // 批量导出间隔
+	MaxQueueSize   int               // 待导出队列长度，满时丢弃
+}
+
+// TracingComponent 链路追踪组件，启动后设置为全局TracerProvider和W3C传播器
+type TracingComponent struct {
+	config   *TracingConfig
+	mu       sync.Mutex
+	provider *sdktrace.TracerProvider
+	file     io.Closer
+}
+
+// WithTracingServiceName 设置服务名称
+func WithTracingServiceName(name string) TracingOption {
+	return func(t *TracingComponent) {
+		t.config.ServiceName = name
+	}
+}
+
+// WithTracingServiceVersion 设置服务版本
+func WithTracingServiceVersion(version string) TracingOption {
+	return func(t *TracingComponent) {
+		t.config.ServiceVersion = version
+	}
+}
+
+// WithTracingEnvironment 设置部署环境
+func WithTracingEnvironment(env string) TracingOption {
+	return func(t *TracingComponent) {
+		t.config.Environment = env
+	}
+}
+
+// WithTracingExporter 设置导出方式 otlp/stdout/file/none，为空时不修改
+func WithTracingExporter(exporter string) TracingOption {
+	return func(t *TracingComponent) {
+		if exporter != "" {
+			t.config.Exporter = exporter
+		}
+	}
+}
+
+// WithTracingOTLP 设置OTLP地址和请求头
+func WithTracingOTLP(endpoint string, headers map[string]string) TracingOption {
+	return func(t *TracingComponent) {
+		t.config.Endpoint = endpoint
+		t.config.Headers = headers
+	}
+}
+
+// WithTracingTimeout 设置OTLP导出超时
+func WithTracingTimeout(timeout time.Duration) TracingOption {
+	return func(t *TracingComponent) {
+		if timeout > 0 {
+			t.config.Timeout = timeout
+		}
+	}
+}
+
+// WithTracingFile 设置file导出的文件路径
+func WithTracingFile(path string) TracingOption {
+	return func(t *TracingComponent) {
+		if path != "" {
+			t.config.FilePath = path
+		}
+	}
+}
+
+// WithTracingSampleRatio 设置根span采样率 0~1
+func WithTracingSampleRatio(ratio float64) TracingOption {
+	return func(t *TracingComponent) {
+		t.config.SampleRatio = ratio
+	}
+}
+
+// WithTracingAttributes 设置额外的资源属性
+func WithTracingAttributes(attributes map[string]string) TracingOption {
+	return func(t *TracingComponent) {
+		t.config.Attributes = attributes
+	}
+}
+
+// WithTracingBatch 设置批量导出间隔和待导出队列长度
+func WithTracingBatch(timeout time.Duration, maxQueueSize int) TracingOption {
+	return func(t *TracingComponent) {
+		if timeout > 0 {
+			t.config.BatchTimeout = timeout
+		}
+		if maxQueueSize > 0 {
+			t.config.MaxQueueSize = maxQueueSize
+		}
+	}
+}
+
+// NewTracingComponent 创建链路追踪组件
+func NewTracingComponent(opts ...TracingOption) *TracingComponent {
+	t := &TracingComponent{
+		config: &TracingConfig{
+			ServiceName:  "go-frame-server",
+			Exporter:     ExporterOTLP,
+			Timeout:      10 * time.Second,
+			FilePath:     "./logs/traces.json",
+			SampleRatio:  1,
+			BatchTimeout: 5 * time.Second,
+			MaxQueueSize: sdktrace.DefaultMaxQueueSize,
+		},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Name 组件名称
+func (t *TracingComponent) Name() string {
+	return "tracing"
+}
+
+// Start 创建TracerProvider并设置为全局，日志中加入trace_id和span_id
+func (t *TracingComponent) Start(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.provider != nil {
+		return nil
+	}
+
+	exporter, err := t.exporter(ctx)
+	if err != nil {
+		return fmt.Errorf("create %s exporter: %w", t.config.Exporter, err)
+	}
+	res, err := t.resource(ctx)
+	if err != nil {
+		return fmt.Errorf("create resource: %w", err)
+	}
+	providerOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.config.SampleRatio))),
+	}
+	if exporter != nil {
+		providerOptions = append(providerOptions, sdktrace.WithBatcher(exporter,
+			sdktrace.WithBatchTimeout(t.config.BatchTimeout),
+			sdktrace.WithMaxQueueSize(t.config.MaxQueueSize),
+			sdktrace.WithExportTimeout(t.config.Timeout),
+		))
+	}
+	t.provider = sdktrace.NewTracerProvider(providerOptions...)
+
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Named("tracing").Warn("tracing error", zap.Error(err))
+	}))
+	logger.RegisterContextFields("tracing", LogFields)
+
+	fmt.Printf("Tracing started: service=%s, exporter=%s, sample_ratio=%v\n",
+		t.config.ServiceName, t.config.Exporter, t.config.SampleRatio)
+	return nil
+}
+
+// Stop 导出剩余的span并关闭，恢复为不记录的TracerProvider
+func (t *TracingComponent) Stop(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.provider == nil {
+		return nil
+	}
+
+	logger.UnregisterContextFields("tracing")
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	err := t.provider.Shutdown(ctx)
+	if t.file != nil {
+		err = errors.Join(err, t.file.Close())
+	}
+	t.provider, t.file = nil, nil
+	return err
+}
+
+// exporter 按配置创建导出器，none时返回nil
+func (t *TracingComponent) exporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	switch t.config.Exporter {
+	case ExporterOTLP:
+		if t.config.Endpoint == "" {
+			return nil, errors.New("otlp endpoint is empty")
+		}
+		return otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpointURL(t.config.Endpoint),
+			otlptracehttp.WithHeaders(t.config.Headers),
+			otlptracehttp.WithTimeout(t.config.Timeout),
+		)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterFile:
+		if err := os.MkdirAll(filepath.Dir(t.config.FilePath), 0o755); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(t.config.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		t.file = file
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", t.config.Exporter)
+	}
+}
+
+// resource 服务信息，OTEL_RESOURCE_ATTRIBUTES环境变量中的属性同样生效
+func (t *TracingComponent) resource(ctx context.Context) (*resource.Resource, error) {
+	attributes := []attribute.KeyValue{semconv.ServiceName(t.config.ServiceName)}
+	if t.config.ServiceVersion != "" {
+		attributes = append(attributes, semconv.ServiceVersion(t.config.ServiceVersion))
+	}
+	if t.config.Environment != "" {
+		attributes = append(attributes, semconv.DeploymentEnvironment(t.config.Environment))
+	}
+	for key, value := range t.config.Attributes {
+		attributes = append(attributes, attribute.String(key, value))
+	}
+	return resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithProcessPID(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(attributes...),
+	)
+}
+
+// Tracer 获取框架埋点使用的Tracer，组件未启动时不记录
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// LogFields 当前span的trace_id和span_id日志字段，没有span时为空
+func LogFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
+}
+
+// TraceID 当前span的trace_id，没有span时为空字符串
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ContextFieldsFunc 从context中提取日志字段，例: 链路追踪的trace_id、span_id
+type ContextFieldsFunc func(ctx context.Context) []zap.Field
+
+// contextFields 已注册的字段提取函数，名称 => 函数
+var contextFields sync.Map
+
+// RegisterContextFields 注册context字段提取函数，同名覆盖，例: 链路追踪组件启动时注册trace_id
+func RegisterContextFields(name string, fn ContextFieldsFunc) {
+	contextFields.Store(name, fn)
+}
+
+// UnregisterContextFields 取消注册context字段提取函数
+func UnregisterContextFields(name string) {
+	contextFields.Delete(name)
+}
+
+// ContextFields 提取context中的日志字段
+func ContextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	var fields []zap.Field
+	contextFields.Range(func(_, value any) bool {
+		fields = append(fields, value.(ContextFieldsFunc)(ctx)...)
+		return true
+	})
+	return fields
+}
+
+// Ctx 获取带有context字段的日志记录器，例: logger.Ctx(c.Request.Context()).Info("xxx")
+func Ctx(ctx context.Context) *zap.Logger {
+	l := log.Load().WithOptions(zap.AddCallerSkip(-1)) // 直接使用返回的记录器，不经过便捷方法
+	if fields := ContextFields(ctx); len(fields) > 0 {
+		l = l.With(fields...)
+	}
+	return l
+}
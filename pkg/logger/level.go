@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// moduleLevels 模块日志级别 name -> zap.AtomicLevel，未设置的模块跟随全局级别
+var moduleLevels sync.Map
+
+// ParseLevel 解析日志级别，只支持 debug、info、warn、error，空字符串为info，其他值返回错误
+func ParseLevel(level string) (zapcore.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "":
+		return zapcore.InfoLevel, nil
+	case LevelToString(DebugLevel):
+		return zapcore.DebugLevel, nil
+	case LevelToString(InfoLevel):
+		return zapcore.InfoLevel, nil
+	case LevelToString(WarnLevel), "warning":
+		return zapcore.WarnLevel, nil
+	case LevelToString(ErrorLevel):
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("未知的日志级别: %q，可选值: debug、info、warn、error", level)
+	}
+}
+
+// SetLevel 修改全局日志级别
+func SetLevel(level string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	atomicLevel.SetLevel(l)
+	return nil
+}
+
+// SetModuleLevel 设置模块日志级别，之后该模块独立于全局级别
+func SetModuleLevel(name, level string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	ModuleLevel(name).SetLevel(l)
+	return nil
+}
+
+// ResetModuleLevel 删除模块日志级别，恢复跟随全局级别
+func ResetModuleLevel(name string) {
+	moduleLevels.Delete(name)
+}
+
+// ModuleLevel 获取模块日志级别，不存在时以当前全局级别创建
+func ModuleLevel(name string) zap.AtomicLevel {
+	if v, ok := moduleLevels.Load(name); ok {
+		return v.(zap.AtomicLevel)
+	}
+	v, _ := moduleLevels.LoadOrStore(name, zap.NewAtomicLevelAt(atomicLevel.Level()))
+	return v.(zap.AtomicLevel)
+}
+
+// Levels 获取全局和各模块的日志级别
+func Levels() (string, map[string]string) {
+	modules := make(map[string]string)
+	moduleLevels.Range(func(key, value any) bool {
+		modules[key.(string)] = value.(zap.AtomicLevel).String()
+		return true
+	})
+	return atomicLevel.String(), modules
+}
+
+// ApplyLevels 设置全局和模块日志级别(用于配置热加载)，不在modules中的模块恢复跟随全局级别；
+// 任一级别无效时不做任何修改
+func ApplyLevels(level string, modules map[string]string) error {
+	global, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	parsed := make(map[string]zapcore.Level, len(modules))
+	names := make([]string, 0, len(modules))
+	for name, moduleLevel := range modules {
+		l, err := ParseLevel(moduleLevel)
+		if err != nil {
+			return fmt.Errorf("模块%s: %w", name, err)
+		}
+		parsed[name] = l
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	atomicLevel.SetLevel(global)
+	moduleLevels.Range(func(key, _ any) bool {
+		if _, ok := parsed[key.(string)]; !ok {
+			moduleLevels.Delete(key)
+		}
+		return true
+	})
+	for _, name := range names {
+		ModuleLevel(name).SetLevel(parsed[name])
+	}
+	return nil
+}
+
+// Named 获取模块日志记录器，例: logger.Named("mysql")
+// 模块通过SetModuleLevel设置独立级别，未设置时跟随全局级别；
+// 记录器始终写入当前的日志输出: 日志组件启动前和停止后输出到标准错误
+func Named(name string) *zap.Logger {
+	return log.Load().WithOptions(
+		zap.AddCallerSkip(-1), // 直接使用返回的记录器，不经过便捷方法
+		zap.WrapCore(func(zapcore.Core) zapcore.Core {
+			return &namedCore{levelCore: levelCore{module: name}}
+		}),
+	).Named(name)
+}
+
+// namedCore 模块记录器的core，每次写入时取当前全局记录器的输出，
+// 避免日志组件停止后仍写入已关闭的文件
+type namedCore struct {
+	levelCore
+	fields []zapcore.Field                // With添加的字段
+	cache  atomic.Pointer[namedCoreCache] // 按全局记录器缓存的输出
+}
+
+// namedCoreCache 全局记录器及对应的输出
+type namedCoreCache struct {
+	root *zap.Logger
+	core zapcore.Core
+}
+
+// current 获取当前全局记录器的输出，全局记录器变化时重建
+func (c *namedCore) current() zapcore.Core {
+	root := log.Load()
+	if cached := c.cache.Load(); cached != nil && cached.root == root {
+		return cached.core
+	}
+	core := root.Core()
+	if lc, ok := core.(*levelCore); ok {
+		core = lc.Core
+	}
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	c.cache.Store(&namedCoreCache{root: root, core: core})
+	return core
+}
+
+func (c *namedCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(append(merged, c.fields...), fields...)
+	return &namedCore{levelCore: levelCore{module: c.module}, fields: merged}
+}
+
+func (c *namedCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return ce
+	}
+	return c.current().Check(entry, ce)
+}
+
+func (c *namedCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(entry, fields)
+}
+
+func (c *namedCore) Sync() error {
+	return c.current().Sync()
+}
+
+// levelCore 按全局或模块级别过滤日志
+type levelCore struct {
+	zapcore.Core
+	module string // 为空使用全局级别
+}
+
+// enabler 获取当前生效的级别
+func (c *levelCore) enabler() zapcore.LevelEnabler {
+	if c.module != "" {
+		if v, ok := moduleLevels.Load(c.module); ok {
+			return v.(zap.AtomicLevel)
+		}
+	}
+	return atomicLevel
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.enabler().Enabled(level)
+}
+
+func (c *levelCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.enabler())
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), module: c.module}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
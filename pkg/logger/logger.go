@@ -1,13 +1,18 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -16,12 +21,40 @@ import (
 // LoggerOption 定义日志选项函数类型
 type LoggerOption func(*LoggerComponent)
 
-var log *zap.Logger
+// log 全局日志记录器，日志组件启动前为输出到标准错误的后备记录器，停止后恢复
+var log atomic.Pointer[zap.Logger]
+
+// fallbackLogger 后备日志记录器，控制台格式输出到标准错误，同样按全局级别过滤
+var fallbackLogger = newFallbackLogger()
+
+func init() {
+	log.Store(fallbackLogger)
+}
+
+// atomicLevel 全局日志级别，支持运行时修改
+var atomicLevel = zap.NewAtomicLevel()
 
 // LoggerComponent 日志组件
 type LoggerComponent struct {
 	config  *LoggerConfig
 	started atomic.Bool // 使用原子操作确保日志组件的状态一致性
+	// 额外的日志输出目标
+	sinks       []sinkRegistration
+	sinkWriters []*sinkWriter
+	// 缓冲写入的文件和控制台输出，停止时写出
+	buffered []*zapcore.BufferedWriteSyncer
+	// 文件输出，停止时关闭
+	fileWriter *closableFile
+	// 去重和限流，停止时输出剩余汇总
+	suppressor *suppressor
+	// 恢复启动前的zap全局记录器
+	undoGlobals func()
+}
+
+// sinkRegistration 注册的Sink及其选项
+type sinkRegistration struct {
+	sink Sink
+	opts []SinkOption
 }
 
 const (
@@ -50,7 +83,7 @@ func LevelToString(l zapcore.Level) string {
 
 // LoggerConfig 日志配置
 type LoggerConfig struct {
-	Level      string // debug, info, warn, error
+	Level      string // debug, info, warn, error，其他值启动失败
 	IsStdout   bool   // 是否输出到控制台
 	IsFile     bool   // 是否输出到文件
 	Filename   string // 日志文件名
@@ -58,6 +91,18 @@ type LoggerConfig struct {
 	MaxBackups int    // 最大备份数
 	MaxAge     int    // 天
 	Compress   bool   // 是否压缩
+	// 模块日志级别(logger.Named)，例: {"mysql": "debug"}，未设置的模块跟随Level
+	ModuleLevels map[string]string
+	// 是否对日志消息和字段脱敏
+	Mask bool
+	// 脱敏引擎，为空使用mask.Default()
+	Masker *mask.Masker
+	// 文件和控制台输出的缓冲区大小(字节)，0为同步写入
+	BufferSize int
+	// 缓冲区刷新间隔，默认1秒；warn以下的日志最多延迟该时间写出，error以上立即写出
+	FlushInterval time.Duration
+	// 采样、去重和按调用位置限流
+	Sampling SamplingConfig
 }
 
 // WithLoggerLevel 设置日志级别
@@ -67,6 +112,36 @@ func WithLoggerLevel(level string) LoggerOption {
 	}
 }
 
+// WithLoggerModuleLevels 设置模块日志级别，例: {"mysql": "debug", "redis": "warn"}
+func WithLoggerModuleLevels(levels map[string]string) LoggerOption {
+	return func(l *LoggerComponent) {
+		l.config.ModuleLevels = levels
+	}
+}
+
+// WithLoggerSink 添加日志输出目标(ClickHouse、HTTP采集端、syslog等)，日志以JSON格式异步批量写入
+func WithLoggerSink(sink Sink, opts ...SinkOption) LoggerOption {
+	return func(l *LoggerComponent) {
+		l.sinks = append(l.sinks, sinkRegistration{sink: sink, opts: opts})
+	}
+}
+
+// WithLoggerMask 设置是否对日志脱敏(密码、token等字段，邮箱、银行卡号、身份证号、手机号)，masker为空使用mask.Default()
+func WithLoggerMask(enable bool, masker *mask.Masker) LoggerOption {
+	return func(l *LoggerComponent) {
+		l.config.Mask = enable
+		l.config.Masker = masker
+	}
+}
+
+// WithLoggerBuffer 设置文件和控制台输出异步缓冲写入，size为缓冲区大小(字节，0为同步写入)，flushInterval为刷新间隔
+func WithLoggerBuffer(size int, flushInterval time.Duration) LoggerOption {
+	return func(l *LoggerComponent) {
+		l.config.BufferSize = size
+		l.config.FlushInterval = flushInterval
+	}
+}
+
 // WithLoggerFilename 设置日志文件名
 func WithLoggerFilename(filename string) LoggerOption {
 	// 如果文件名没有设置，则使用默认值
@@ -148,26 +223,22 @@ func NewLoggerComponent(opts ...LoggerOption) *LoggerComponent {
 	return l
 }
 
-// Start 启动日志组件
-func (l *LoggerComponent) Start() error {
+// Name 组件名称
+func (l *LoggerComponent) Name() string {
+	return "logger"
+}
+
+// Start 启动日志组件，重复调用直接返回
+// 需要记录其他组件启动日志时，在注册组件前调用Start并第一个注册日志组件，框架停止时最后停止
+func (l *LoggerComponent) Start(ctx context.Context) error {
 	// 确保不会重复启动
 	if l.started.Load() {
 		return nil
 	}
 
-	// 设置日志级别
-	var level zapcore.Level
-	switch l.config.Level {
-	case LevelToString(DebugLevel):
-		level = zapcore.DebugLevel
-	case LevelToString(InfoLevel):
-		level = zapcore.InfoLevel
-	case LevelToString(WarnLevel):
-		level = zapcore.WarnLevel
-	case LevelToString(ErrorLevel):
-		level = zapcore.ErrorLevel
-	default:
-		level = zapcore.InfoLevel
+	// 设置日志级别，未知的级别直接启动失败
+	if err := ApplyLevels(l.config.Level, l.config.ModuleLevels); err != nil {
+		return err
 	}
 
 	// 配置通用编码器设置
@@ -205,17 +276,20 @@ func (l *LoggerComponent) Start() error {
 			LocalTime:  true,                // 使用本地时间
 		}
 
+		file := &closableFile{file: fileWriter}
+
 		// 文件输出的编码器配置 - 使用JSON格式
 		fileEncoderConfig := encoderConfig
 		fileEncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
 
 		// 文件使用JSON格式，便于后期分析
 		fileCore := zapcore.NewCore(
-			zapcore.NewJSONEncoder(fileEncoderConfig),
-			zapcore.AddSync(fileWriter),
-			level,
+			l.encoder(zapcore.NewJSONEncoder(fileEncoderConfig)),
+			l.writeSyncer(zapcore.AddSync(file)),
+			zapcore.DebugLevel, // 由levelCore按全局或模块级别过滤
 		)
 		cores = append(cores, fileCore)
+		l.fileWriter = file
 	}
 
 	// 控制台输出
@@ -226,32 +300,59 @@ func (l *LoggerComponent) Start() error {
 
 		// 控制台使用更易读的格式
 		consoleCore := zapcore.NewCore(
-			zapcore.NewConsoleEncoder(consoleEncoderConfig),
-			zapcore.AddSync(os.Stdout),
-			level,
+			l.encoder(zapcore.NewConsoleEncoder(consoleEncoderConfig)),
+			l.writeSyncer(zapcore.Lock(os.Stdout)),
+			zapcore.DebugLevel,
 		)
 		cores = append(cores, consoleCore)
 	}
 
+	// 额外的输出目标，使用与文件相同的JSON格式
+	for _, registration := range l.sinks {
+		sinkEncoderConfig := encoderConfig
+		sinkEncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		writer := newSinkWriter(registration.sink, registration.opts...)
+		l.sinkWriters = append(l.sinkWriters, writer)
+		cores = append(cores, newSinkCore(l.encoder(zapcore.NewJSONEncoder(sinkEncoderConfig)), writer))
+	}
+
 	// 检查是否有可用的core
 	if len(cores) == 0 {
-		return fmt.Errorf("没有配置任何日志输出，请设置IsFile或Stdout为true")
+		return fmt.Errorf("没有配置任何日志输出，请设置IsFile、Stdout为true或添加Sink")
 	}
 
-	// 合并所有输出
-	core := zapcore.NewTee(cores...)
+	// 合并所有输出，依次经过去重、限流和采样
+	tee, err := newSamplingCore(zapcore.NewTee(cores...), l.config.Sampling.Tick, l.config.Sampling.Levels)
+	if err != nil {
+		return err
+	}
+	suppressor, err := newSuppressor(l.config.Sampling)
+	if err != nil {
+		return err
+	}
+	if suppressor != nil {
+		tee = suppressor.wrap(tee)
+		suppressor.start()
+		l.suppressor = suppressor
+	}
+	core := &levelCore{Core: tee}
 
 	// 创建记录器，开启调用信息
-	log = zap.New(
+	logger := zap.New(
 		core,
 		zap.AddCaller(),
 		zap.AddCallerSkip(1),
 		zap.AddStacktrace(zapcore.ErrorLevel), // 为错误级别以上添加堆栈跟踪
 		zap.WithFatalHook(zapcore.WriteThenFatal), // 确保 Fatal 级别的日志在程序退出前被写入
 	)
+	if len(l.buffered) > 0 {
+		// 缓冲写入时error以上的日志立即写出
+		logger = logger.WithOptions(zap.Hooks(flushOnError(l.buffered)))
+	}
 
 	// 替换全局记录器
-	zap.ReplaceGlobals(log)
+	log.Store(logger)
+	l.undoGlobals = zap.ReplaceGlobals(logger)
 
 	// 设置启动标志
 	l.started.Store(true)
@@ -259,8 +360,129 @@ func (l *LoggerComponent) Start() error {
 	return nil
 }
 
+// encoder 开启脱敏时包装编码器
+func (l *LoggerComponent) encoder(encoder zapcore.Encoder) zapcore.Encoder {
+	if !l.config.Mask {
+		return encoder
+	}
+	return newMaskEncoder(encoder, l.config.Masker)
+}
+
+// writeSyncer 开启缓冲时包装为异步缓冲写入
+func (l *LoggerComponent) writeSyncer(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+	if l.config.BufferSize <= 0 {
+		return ws
+	}
+	buffered := &zapcore.BufferedWriteSyncer{
+		WS:            ws,
+		Size:          l.config.BufferSize,
+		FlushInterval: l.config.FlushInterval,
+	}
+	l.buffered = append(l.buffered, buffered)
+	return buffered
+}
+
+// flushOnError error以上的日志写出缓冲区
+func flushOnError(buffered []*zapcore.BufferedWriteSyncer) func(zapcore.Entry) error {
+	return func(entry zapcore.Entry) error {
+		if entry.Level < zapcore.ErrorLevel {
+			return nil
+		}
+		for _, ws := range buffered {
+			_ = ws.Sync()
+		}
+		return nil
+	}
+}
+
+// Stop 写出缓冲的日志，关闭所有Sink和日志文件，之后的日志输出到标准错误
+// 先切换到后备记录器再关闭输出，关闭后仍在进行的写入返回错误，不会重新打开日志文件
+func (l *LoggerComponent) Stop(ctx context.Context) error {
+	if !l.started.Load() {
+		return nil
+	}
+	logger := log.Load()
+	log.Store(fallbackLogger)
+	if l.undoGlobals != nil {
+		l.undoGlobals()
+	}
+
+	var lastErr error
+	if l.suppressor != nil {
+		l.suppressor.close()
+	}
+	// 先关闭Sink，写出剩余日志的等待时间受ctx限制，之后的Sync不再等待Sink
+	for _, writer := range l.sinkWriters {
+		if err := writer.close(ctx); err != nil {
+			lastErr = err
+		}
+	}
+	// 控制台为终端时Sync会返回错误，忽略
+	_ = logger.Sync()
+	for _, buffered := range l.buffered {
+		if err := buffered.Stop(); err != nil {
+			lastErr = err
+		}
+	}
+	if l.fileWriter != nil {
+		if err := l.fileWriter.Close(); err != nil {
+			lastErr = err
+		}
+	}
+
+	l.buffered, l.sinkWriters, l.fileWriter, l.suppressor, l.undoGlobals = nil, nil, nil, nil, nil
+	l.started.Store(false)
+	return lastErr
+}
+
+// errFileClosed 日志组件停止后写入日志文件
+var errFileClosed = errors.New("logger: log file is closed")
+
+// closableFile 日志文件，关闭后的写入返回错误，避免lumberjack重新打开文件
+type closableFile struct {
+	mu     sync.Mutex
+	file   *lumberjack.Logger
+	closed bool
+}
+
+func (f *closableFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, errFileClosed
+	}
+	return f.file.Write(p)
+}
+
+// Close 关闭日志文件，重复调用直接返回
+func (f *closableFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.file.Close()
+}
+
+// GetLogger 获取当前的日志记录器，启动前为输出到标准错误的后备记录器
 func (l *LoggerComponent) GetLogger() *zap.Logger {
-	return log
+	return log.Load()
+}
+
+// L 获取当前的全局日志记录器，启动前为输出到标准错误的后备记录器
+func L() *zap.Logger {
+	return log.Load()
+}
+
+// Dir 日志文件所在目录
+func (l *LoggerComponent) Dir() string {
+	return filepath.Dir(l.config.Filename)
+}
+
+// Level 获取全局日志级别，可在运行时修改(模块级别使用SetModuleLevel)；实现了http.Handler(GET查询，PUT {"level":"debug"}修改)
+func Level() zap.AtomicLevel {
+	return atomicLevel
 }
 
 // 时间编码器
@@ -268,23 +490,36 @@ func timeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format("2006-01-02 15:04:05"))
 }
 
-// 以下是一些便捷方法
+// newFallbackLogger 创建后备日志记录器
+func newFallbackLogger() *zap.Logger {
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.EncodeTime = timeEncoder
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConfig), zapcore.Lock(os.Stderr), zapcore.DebugLevel)
+	return zap.New(
+		&levelCore{Core: core},
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	)
+}
+
+// 以下是一些便捷方法，日志组件启动前输出到标准错误
 func Debug(msg string, fields ...zap.Field) {
-	log.Debug(msg, fields...)
+	log.Load().Debug(msg, fields...)
 }
 
 func Info(msg string, fields ...zap.Field) {
-	log.Info(msg, fields...)
+	log.Load().Info(msg, fields...)
 }
 
 func Warn(msg string, fields ...zap.Field) {
-	log.Warn(msg, fields...)
+	log.Load().Warn(msg, fields...)
 }
 
 func Error(msg string, fields ...zap.Field) {
-	log.Error(msg, fields...)
+	log.Load().Error(msg, fields...)
 }
 
 func Fatal(msg string, fields ...zap.Field) {
-	log.Fatal(msg, fields...)
+	log.Load().Fatal(msg, fields...)
 }
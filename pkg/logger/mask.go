@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// maskEncoder 编码前对日志消息和字段脱敏
+// 敏感字段名整体替换，字符串值按规则部分隐藏，结构体支持 `log:"mask"` 标签
+type maskEncoder struct {
+	zapcore.Encoder
+	masker *mask.Masker
+}
+
+// newMaskEncoder 创建脱敏编码器，masker为空时使用mask.Default()
+func newMaskEncoder(encoder zapcore.Encoder, masker *mask.Masker) zapcore.Encoder {
+	return &maskEncoder{Encoder: encoder, masker: masker}
+}
+
+// getMasker 获取脱敏引擎
+func (e *maskEncoder) getMasker() *mask.Masker {
+	if e.masker != nil {
+		return e.masker
+	}
+	return mask.Default()
+}
+
+func (e *maskEncoder) Clone() zapcore.Encoder {
+	return &maskEncoder{Encoder: e.Encoder.Clone(), masker: e.masker}
+}
+
+// With添加的字段通过以下方法写入
+func (e *maskEncoder) AddString(key, value string) {
+	e.Encoder.AddString(key, e.maskString(key, value))
+}
+
+func (e *maskEncoder) AddByteString(key string, value []byte) {
+	e.Encoder.AddString(key, e.maskString(key, string(value)))
+}
+
+func (e *maskEncoder) AddReflected(key string, value any) error {
+	return e.Encoder.AddReflected(key, e.getMasker().Field(key, value))
+}
+
+func (e *maskEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	masker := e.getMasker()
+	entry.Message = masker.String(entry.Message)
+	masked := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		masked[i] = e.maskField(field)
+	}
+	return e.Encoder.EncodeEntry(entry, masked)
+}
+
+// maskString 脱敏字符串字段
+func (e *maskEncoder) maskString(key, value string) string {
+	masker := e.getMasker()
+	if masker.IsSensitive(key) {
+		return mask.Placeholder
+	}
+	return masker.String(value)
+}
+
+// maskField 脱敏字段，数值等不含敏感信息的类型保持不变
+func (e *maskEncoder) maskField(field zapcore.Field) zapcore.Field {
+	masker := e.getMasker()
+	switch field.Type {
+	case zapcore.StringType:
+		return zap.String(field.Key, e.maskString(field.Key, field.String))
+	case zapcore.ByteStringType:
+		return zap.String(field.Key, e.maskString(field.Key, string(field.Interface.([]byte))))
+	case zapcore.StringerType:
+		return zap.String(field.Key, e.maskString(field.Key, stringerValue(field.Interface)))
+	case zapcore.ReflectType:
+		return zap.Reflect(field.Key, masker.Field(field.Key, field.Interface))
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType:
+		if masker.IsSensitive(field.Key) {
+			return zap.String(field.Key, mask.Placeholder)
+		}
+		return field
+	case zapcore.ErrorType:
+		// 只替换错误信息，errors.Is/As仍可使用
+		if err, ok := field.Interface.(error); ok && err != nil {
+			field.Interface = maskedError{error: err, msg: masker.String(err.Error())}
+		}
+		return field
+	default:
+		if masker.IsSensitive(field.Key) && field.Type != zapcore.SkipType && field.Type != zapcore.NamespaceType {
+			return zap.String(field.Key, mask.Placeholder)
+		}
+		return field
+	}
+}
+
+// stringerValue 获取Stringer的值，nil指针等情况返回<nil>
+func stringerValue(v any) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = "<nil>"
+		}
+	}()
+	return v.(fmt.Stringer).String()
+}
+
+// maskedError 脱敏后的错误
+type maskedError struct {
+	error
+	msg string
+}
+
+func (e maskedError) Error() string {
+	return e.msg
+}
+
+func (e maskedError) Unwrap() error {
+	return e.error
+}
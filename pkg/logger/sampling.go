@@ -0,0 +1,438 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingRule 采样规则: 每个采样周期内相同消息前First条全部输出，之后每Thereafter条输出1条(0为全部丢弃)
+type SamplingRule struct {
+	First      int
+	Thereafter int
+}
+
+// SamplingConfig 日志采样和限流配置
+type SamplingConfig struct {
+	Tick   time.Duration           // 采样周期，默认1秒
+	Levels map[string]SamplingRule // 按级别采样，例: {"info": {100, 10}}，未配置的级别不采样
+
+	DedupWindow time.Duration // 相同消息去重窗口，窗口内只输出第一条，结束后输出"repeated N times"汇总；0为不去重
+	DedupLevel  string        // 去重的最低级别，默认error
+
+	CallerLimit    int           // 每个调用位置每个周期最多输出条数，超出丢弃并汇总；0为不限流
+	CallerInterval time.Duration // 限流周期，默认1秒
+	CallerLevel    string        // 限流的最低级别，默认warn
+}
+
+// WithLoggerSampling 设置按级别采样，例: WithLoggerSampling(time.Second, map[string]logger.SamplingRule{"debug": {100, 100}})
+func WithLoggerSampling(tick time.Duration, levels map[string]SamplingRule) LoggerOption {
+	return func(l *LoggerComponent) {
+		l.config.Sampling.Tick = tick
+		l.config.Sampling.Levels = levels
+	}
+}
+
+// WithLoggerDedup 设置相同消息去重，window内相同级别、模块和消息的日志只输出第一条，level为去重的最低级别
+func WithLoggerDedup(window time.Duration, level string) LoggerOption {
+	return func(l *LoggerComponent) {
+		l.config.Sampling.DedupWindow = window
+		l.config.Sampling.DedupLevel = level
+	}
+}
+
+// WithLoggerCallerLimit 设置按调用位置限流，每个调用位置每interval最多输出limit条，level为限流的最低级别
+func WithLoggerCallerLimit(limit int, interval time.Duration, level string) LoggerOption {
+	return func(l *LoggerComponent) {
+		l.config.Sampling.CallerLimit = limit
+		l.config.Sampling.CallerInterval = interval
+		l.config.Sampling.CallerLevel = level
+	}
+}
+
+// suppressor 去重和限流状态，定期输出被丢弃日志的汇总
+type suppressor struct {
+	dedup       *dedupState
+	callerLimit *callerLimitState
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// newSuppressor 按配置包装core，未开启去重和限流时返回nil
+func newSuppressor(config SamplingConfig) (*suppressor, error) {
+	s := &suppressor{}
+	if config.DedupWindow > 0 {
+		level, err := parseLevelDefault(config.DedupLevel, zapcore.ErrorLevel)
+		if err != nil {
+			return nil, err
+		}
+		s.dedup = &dedupState{window: config.DedupWindow, level: level, entries: make(map[dedupKey]*dedupEntry)}
+	}
+	if config.CallerLimit > 0 {
+		level, err := parseLevelDefault(config.CallerLevel, zapcore.WarnLevel)
+		if err != nil {
+			return nil, err
+		}
+		interval := config.CallerInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		s.callerLimit = &callerLimitState{limit: config.CallerLimit, interval: interval, level: level, callers: make(map[uintptr]*callerEntry)}
+	}
+	if s.dedup == nil && s.callerLimit == nil {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// wrap 包装core: 先去重，再按调用位置限流
+func (s *suppressor) wrap(core zapcore.Core) zapcore.Core {
+	if s.callerLimit != nil {
+		core = &callerLimitCore{Core: core, state: s.callerLimit}
+	}
+	if s.dedup != nil {
+		core = &dedupCore{Core: core, state: s.dedup}
+	}
+	return core
+}
+
+// start 启动汇总协程
+func (s *suppressor) start() {
+	interval := time.Hour
+	if s.dedup != nil {
+		interval = s.dedup.window
+	}
+	if s.callerLimit != nil && s.callerLimit.interval < interval {
+		interval = s.callerLimit.interval
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush(false)
+			case <-s.stop:
+				s.flush(true)
+				return
+			}
+		}
+	}()
+}
+
+// close 停止汇总协程并输出剩余汇总
+func (s *suppressor) close() {
+	close(s.stop)
+	<-s.done
+}
+
+// flush 输出已结束窗口的汇总，all为true时输出全部
+func (s *suppressor) flush(all bool) {
+	now := time.Now()
+	var summaries []summary
+	if s.dedup != nil {
+		summaries = append(summaries, s.dedup.expire(now, all)...)
+	}
+	if s.callerLimit != nil {
+		summaries = append(summaries, s.callerLimit.expire(now, all)...)
+	}
+	for _, sum := range summaries {
+		sum.write()
+	}
+}
+
+// summary 被丢弃日志的汇总
+type summary struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// write 经过后续的采样和输出
+func (s summary) write() {
+	if ce := s.core.Check(s.entry, nil); ce != nil {
+		ce.Write(s.fields...)
+	}
+}
+
+// dedupKey 去重的键
+type dedupKey struct {
+	level   zapcore.Level
+	logger  string
+	message string
+}
+
+// dedupEntry 去重窗口
+type dedupEntry struct {
+	core     zapcore.Core // 第一条日志的后续core，用于输出汇总
+	start    time.Time
+	repeated int
+}
+
+// dedupState 去重状态，With派生的core共享
+type dedupState struct {
+	window  time.Duration
+	level   zapcore.Level
+	mu      sync.Mutex
+	entries map[dedupKey]*dedupEntry
+}
+
+// allow 判断是否输出，窗口已结束时同时返回上一窗口的汇总
+func (s *dedupState) allow(core zapcore.Core, entry zapcore.Entry) (bool, *summary) {
+	key := dedupKey{level: entry.Level, logger: entry.LoggerName, message: entry.Message}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.entries[key]
+	if ok && entry.Time.Sub(current.start) < s.window {
+		current.repeated++
+		return false, nil
+	}
+	var sum *summary
+	if ok && current.repeated > 0 {
+		sum = s.summary(key, current)
+	}
+	s.entries[key] = &dedupEntry{core: core, start: entry.Time}
+	return true, sum
+}
+
+// expire 移除已结束的窗口
+func (s *dedupState) expire(now time.Time, all bool) []summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var summaries []summary
+	for key, current := range s.entries {
+		if !all && now.Sub(current.start) < s.window {
+			continue
+		}
+		if current.repeated > 0 {
+			summaries = append(summaries, *s.summary(key, current))
+		}
+		delete(s.entries, key)
+	}
+	return summaries
+}
+
+func (s *dedupState) summary(key dedupKey, current *dedupEntry) *summary {
+	return &summary{
+		core: current.core,
+		entry: zapcore.Entry{
+			Level:      key.level,
+			Time:       time.Now(),
+			LoggerName: key.logger,
+			Message:    fmt.Sprintf("%s (repeated %d times)", key.message, current.repeated),
+		},
+		fields: []zapcore.Field{zap.Int("repeated", current.repeated), zap.Duration("window", s.window)},
+	}
+}
+
+// dedupCore 相同级别、模块和消息的日志在窗口内只输出第一条，panic、fatal不去重
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.state.level.Enabled(entry.Level) || entry.Level > zapcore.ErrorLevel {
+		return c.Core.Check(entry, ce)
+	}
+	ok, sum := c.state.allow(c.Core, entry)
+	if sum != nil {
+		sum.write()
+	}
+	if !ok {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// callerEntry 调用位置的限流窗口
+type callerEntry struct {
+	core    zapcore.Core
+	caller  string
+	level   zapcore.Level
+	logger  string
+	start   time.Time
+	count   int
+	dropped int
+}
+
+// callerLimitState 限流状态，With派生的core共享
+type callerLimitState struct {
+	limit    int
+	interval time.Duration
+	level    zapcore.Level
+	mu       sync.Mutex
+	callers  map[uintptr]*callerEntry
+}
+
+// allow 判断是否输出，窗口已结束时同时返回上一窗口的汇总
+func (s *callerLimitState) allow(core zapcore.Core, entry zapcore.Entry, pc uintptr, caller string) (bool, *summary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.callers[pc]
+	var sum *summary
+	if ok && entry.Time.Sub(current.start) >= s.interval {
+		if current.dropped > 0 {
+			sum = s.summary(current)
+		}
+		ok = false
+	}
+	if !ok {
+		current = &callerEntry{core: core, caller: caller, start: entry.Time}
+		s.callers[pc] = current
+	}
+	current.count++
+	if current.count > s.limit {
+		current.dropped++
+		// 汇总使用被丢弃日志中的最高级别
+		if current.dropped == 1 || entry.Level > current.level {
+			current.level, current.logger = entry.Level, entry.LoggerName
+		}
+		return false, sum
+	}
+	return true, sum
+}
+
+// expire 移除已结束的窗口
+func (s *callerLimitState) expire(now time.Time, all bool) []summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var summaries []summary
+	for pc, current := range s.callers {
+		if !all && now.Sub(current.start) < s.interval {
+			continue
+		}
+		if current.dropped > 0 {
+			summaries = append(summaries, *s.summary(current))
+		}
+		delete(s.callers, pc)
+	}
+	return summaries
+}
+
+func (s *callerLimitState) summary(current *callerEntry) *summary {
+	return &summary{
+		core: current.core,
+		entry: zapcore.Entry{
+			Level:      current.level,
+			Time:       time.Now(),
+			LoggerName: current.logger,
+			Message:    fmt.Sprintf("rate limited: dropped %d logs from %s", current.dropped, current.caller),
+		},
+		fields: []zapcore.Field{zap.Int("dropped", current.dropped), zap.String("from", current.caller), zap.Duration("interval", s.interval)},
+	}
+}
+
+// callerLimitCore 按调用位置限流，panic、fatal不限流
+type callerLimitCore struct {
+	zapcore.Core
+	state *callerLimitState
+}
+
+func (c *callerLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &callerLimitCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *callerLimitCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.state.level.Enabled(entry.Level) || entry.Level > zapcore.ErrorLevel {
+		return c.Core.Check(entry, ce)
+	}
+	// Check时zap还未填充调用位置，需要自行查找
+	pc, caller := findCaller()
+	if pc == 0 {
+		return c.Core.Check(entry, ce)
+	}
+	ok, sum := c.state.allow(c.Core, entry, pc, caller)
+	if sum != nil {
+		sum.write()
+	}
+	if !ok {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// loggerPackage 本包路径，查找调用位置时跳过
+var loggerPackage = reflect.TypeOf(levelCore{}).PkgPath() + "."
+
+// findCaller 查找zap和本包之外的第一个调用位置
+func findCaller() (uintptr, string) {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "go.uber.org/zap") &&
+			!strings.HasPrefix(frame.Function, loggerPackage) &&
+			!strings.HasPrefix(frame.Function, "runtime.") {
+			return frame.PC, zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true).TrimmedPath()
+		}
+		if !more {
+			return 0, ""
+		}
+	}
+}
+
+// samplingCore 按级别使用不同的zap采样器
+type samplingCore struct {
+	zapcore.Core
+	samplers map[zapcore.Level]zapcore.Core
+}
+
+// newSamplingCore 按配置创建采样core，未配置级别时返回原core
+func newSamplingCore(core zapcore.Core, tick time.Duration, rules map[string]SamplingRule) (zapcore.Core, error) {
+	if len(rules) == 0 {
+		return core, nil
+	}
+	if tick <= 0 {
+		tick = time.Second
+	}
+	samplers := make(map[zapcore.Level]zapcore.Core, len(rules))
+	for name, rule := range rules {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("sampling: %w", err)
+		}
+		if rule.First <= 0 {
+			return nil, fmt.Errorf("sampling: first of level %s must be positive", name)
+		}
+		samplers[level] = zapcore.NewSamplerWithOptions(core, tick, rule.First, rule.Thereafter)
+	}
+	return &samplingCore{Core: core, samplers: samplers}, nil
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	samplers := make(map[zapcore.Level]zapcore.Core, len(c.samplers))
+	for level, sampler := range c.samplers {
+		samplers[level] = sampler.With(fields)
+	}
+	return &samplingCore{Core: c.Core.With(fields), samplers: samplers}
+}
+
+func (c *samplingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if sampler, ok := c.samplers[entry.Level]; ok {
+		return sampler.Check(entry, ce)
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// parseLevelDefault 解析级别，为空时使用默认级别
+func parseLevelDefault(level string, defaultLevel zapcore.Level) (zapcore.Level, error) {
+	if level == "" {
+		return defaultLevel, nil
+	}
+	return ParseLevel(level)
+}
@@ -0,0 +1,334 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SinkEntry 输出到Sink的日志
+type SinkEntry struct {
+	Time    time.Time
+	Level   zapcore.Level
+	Logger  string // 日志模块名
+	Caller  string
+	Message string
+	JSON    []byte // 完整的JSON编码(含字段)，不含换行
+}
+
+// Sink 日志输出目标，由后台协程批量调用WriteBatch，实现无需并发安全
+type Sink interface {
+	// Name 名称，用于统计
+	Name() string
+	// WriteBatch 写入一批日志，返回错误时该批日志计入失败数
+	WriteBatch(ctx context.Context, entries []SinkEntry) error
+	// Close 关闭，剩余日志写入后调用，不会与WriteBatch并发
+	Close() error
+}
+
+// SinkOption 定义Sink选项函数类型
+type SinkOption func(*sinkConfig)
+
+// sinkConfig Sink异步写入配置
+type sinkConfig struct {
+	level         zapcore.Level // 最低输出级别
+	bufferSize    int           // 缓冲队列长度，队列满时丢弃
+	batchSize     int           // 单批最多条数
+	flushInterval time.Duration // 刷新间隔
+	writeTimeout  time.Duration // 单批写入超时时间
+}
+
+// WithSinkLevel 设置Sink最低输出级别(在全局/模块级别之上再过滤)，例: 只把warn以上发送到采集端
+func WithSinkLevel(level zapcore.Level) SinkOption {
+	return func(c *sinkConfig) {
+		c.level = level
+	}
+}
+
+// WithSinkBufferSize 设置缓冲队列长度，默认4096，队列满时丢弃并计入丢弃数
+func WithSinkBufferSize(size int) SinkOption {
+	return func(c *sinkConfig) {
+		c.bufferSize = size
+	}
+}
+
+// WithSinkBatch 设置单批最多条数和刷新间隔，默认500条/1秒
+func WithSinkBatch(batchSize int, flushInterval time.Duration) SinkOption {
+	return func(c *sinkConfig) {
+		c.batchSize = batchSize
+		c.flushInterval = flushInterval
+	}
+}
+
+// WithSinkWriteTimeout 设置单批写入超时时间，默认5秒
+func WithSinkWriteTimeout(timeout time.Duration) SinkOption {
+	return func(c *sinkConfig) {
+		c.writeTimeout = timeout
+	}
+}
+
+// SinkStats Sink统计
+type SinkStats struct {
+	Name    string `json:"name"`
+	Queued  int    `json:"queued"`  // 队列中的条数
+	Written uint64 `json:"written"` // 写入成功条数
+	Failed  uint64 `json:"failed"`  // 写入失败条数
+	Dropped uint64 `json:"dropped"` // 队列满丢弃条数
+}
+
+var (
+	activeSinks   []*sinkWriter
+	activeSinksMu sync.Mutex
+)
+
+// Sinks 获取已启动Sink的统计
+func Sinks() []SinkStats {
+	activeSinksMu.Lock()
+	defer activeSinksMu.Unlock()
+	stats := make([]SinkStats, len(activeSinks))
+	for i, w := range activeSinks {
+		stats[i] = w.stats()
+	}
+	return stats
+}
+
+// sinkWriter 异步批量写入Sink
+type sinkWriter struct {
+	sink    Sink
+	config  sinkConfig
+	queue   chan SinkEntry
+	flushCh chan chan struct{}
+	done    chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+
+	written atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// newSinkWriter 创建并启动异步写入
+func newSinkWriter(sink Sink, opts ...SinkOption) *sinkWriter {
+	config := sinkConfig{
+		level:         zapcore.DebugLevel,
+		bufferSize:    4096,
+		batchSize:     500,
+		flushInterval: time.Second,
+		writeTimeout:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.batchSize <= 0 {
+		config.batchSize = 500
+	}
+	if config.flushInterval <= 0 {
+		config.flushInterval = time.Second
+	}
+
+	w := &sinkWriter{
+		sink:    sink,
+		config:  config,
+		queue:   make(chan SinkEntry, config.bufferSize),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	go w.run()
+
+	activeSinksMu.Lock()
+	activeSinks = append(activeSinks, w)
+	activeSinksMu.Unlock()
+	return w
+}
+
+// enqueue 加入队列，队列满或已关闭时丢弃
+func (w *sinkWriter) enqueue(entry SinkEntry) {
+	select {
+	case <-w.done:
+		w.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case w.queue <- entry:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// run 后台批量写入
+func (w *sinkWriter) run() {
+	defer close(w.closed)
+	ticker := time.NewTicker(w.config.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SinkEntry, 0, w.config.batchSize)
+	write := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), w.config.writeTimeout)
+		err := w.sink.WriteBatch(ctx, batch)
+		cancel()
+		if err != nil {
+			w.failed.Add(uint64(len(batch)))
+		} else {
+			w.written.Add(uint64(len(batch)))
+		}
+		batch = make([]SinkEntry, 0, w.config.batchSize)
+	}
+	// drain 取出队列中已有的日志
+	drain := func() {
+		for {
+			select {
+			case entry := <-w.queue:
+				batch = append(batch, entry)
+				if len(batch) >= w.config.batchSize {
+					write()
+				}
+			default:
+				write()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case entry := <-w.queue:
+			batch = append(batch, entry)
+			if len(batch) >= w.config.batchSize {
+				write()
+			}
+		case <-ticker.C:
+			write()
+		case ack := <-w.flushCh:
+			drain()
+			close(ack)
+		case <-w.done:
+			drain()
+			return
+		}
+	}
+}
+
+// flush 写出队列中的日志，已开始关闭时由close负责写出，不再等待
+func (w *sinkWriter) flush() {
+	ack := make(chan struct{})
+	select {
+	case w.flushCh <- ack:
+		<-ack
+	case <-w.done:
+	}
+}
+
+// close 写出剩余日志并关闭Sink，ctx结束时不等待，后台写入完成后再关闭Sink
+func (w *sinkWriter) close(ctx context.Context) error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		select {
+		case <-w.closed:
+			if closeErr := w.sink.Close(); closeErr != nil {
+				err = fmt.Errorf("sink %s: %w", w.sink.Name(), closeErr)
+			}
+		case <-ctx.Done():
+			err = fmt.Errorf("sink %s: %w", w.sink.Name(), ctx.Err())
+			// 后台仍在写入，写完后再关闭Sink，避免与WriteBatch并发
+			go func() {
+				<-w.closed
+				_ = w.sink.Close()
+			}()
+		}
+
+		activeSinksMu.Lock()
+		for i, active := range activeSinks {
+			if active == w {
+				activeSinks = append(activeSinks[:i], activeSinks[i+1:]...)
+				break
+			}
+		}
+		activeSinksMu.Unlock()
+	})
+	return err
+}
+
+// stats 统计
+func (w *sinkWriter) stats() SinkStats {
+	return SinkStats{
+		Name:    w.sink.Name(),
+		Queued:  len(w.queue),
+		Written: w.written.Load(),
+		Failed:  w.failed.Load(),
+		Dropped: w.dropped.Load(),
+	}
+}
+
+// sinkCore 将日志编码为JSON后加入Sink队列
+type sinkCore struct {
+	encoder zapcore.Encoder
+	writer  *sinkWriter
+}
+
+// newSinkCore 创建Sink输出
+func newSinkCore(encoder zapcore.Encoder, writer *sinkWriter) *sinkCore {
+	return &sinkCore{encoder: encoder, writer: writer}
+}
+
+func (c *sinkCore) Enabled(level zapcore.Level) bool {
+	return level >= c.writer.config.level
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &sinkCore{encoder: encoder, writer: c.writer}
+}
+
+func (c *sinkCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// SinkEntry.Message同样需要脱敏
+	if encoder, ok := c.encoder.(*maskEncoder); ok {
+		entry.Message = encoder.getMasker().String(entry.Message)
+	}
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if n := len(data); n > 0 && data[n-1] == '\n' {
+		data = data[:n-1]
+	}
+	sinkEntry := SinkEntry{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+		JSON:    append([]byte(nil), data...),
+	}
+	if entry.Caller.Defined {
+		sinkEntry.Caller = entry.Caller.TrimmedPath()
+	}
+	buf.Free()
+	c.writer.enqueue(sinkEntry)
+	return nil
+}
+
+// Sync 写出队列中的日志
+func (c *sinkCore) Sync() error {
+	c.writer.flush()
+	return nil
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPSinkOption 定义HTTP Sink选项函数类型
+type HTTPSinkOption func(*HTTPSink)
+
+// HTTPSink 将一批日志以NDJSON(每行一条JSON)POST到采集端
+type HTTPSink struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+}
+
+// WithHTTPSinkHeader 设置请求头，例: WithHTTPSinkHeader("Authorization", "Bearer xxx")
+func WithHTTPSinkHeader(key, value string) HTTPSinkOption {
+	return func(s *HTTPSink) {
+		s.headers[key] = value
+	}
+}
+
+// WithHTTPSinkClient 设置HTTP客户端，默认超时10秒
+func WithHTTPSinkClient(client *http.Client) HTTPSinkOption {
+	return func(s *HTTPSink) {
+		s.client = client
+	}
+}
+
+// NewHTTPSink 创建HTTP Sink
+func NewHTTPSink(endpoint string, opts ...HTTPSinkOption) *HTTPSink {
+	s := &HTTPSink{
+		url:     endpoint,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		headers: map[string]string{"Content-Type": "application/x-ndjson"},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name Sink名称，只包含scheme和host，不输出地址中的账号和查询参数
+func (s *HTTPSink) Name() string {
+	u, err := url.Parse(s.url)
+	if err != nil || u.Host == "" {
+		return "http"
+	}
+	return "http:" + u.Scheme + "://" + u.Host
+}
+
+// WriteBatch 发送一批日志，非2xx响应返回错误
+func (s *HTTPSink) WriteBatch(ctx context.Context, entries []SinkEntry) error {
+	var body bytes.Buffer
+	for _, entry := range entries {
+		body.Write(entry.JSON)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("http sink: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close 关闭空闲连接
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 日志格式
+const (
+	SyslogFormatRFC5424 = "rfc5424" // syslog协议(RFC5424)，消息体为JSON
+	SyslogFormatJournal = "journal" // journald原生协议(/run/systemd/journal/socket)
+)
+
+// 常用地址
+const (
+	DefaultSyslogSocket  = "/dev/log"
+	DefaultJournalSocket = "/run/systemd/journal/socket"
+)
+
+// syslog facility local0
+const syslogFacilityLocal0 = 16
+
+// SyslogSinkOption 定义syslog Sink选项函数类型
+type SyslogSinkOption func(*SyslogSink)
+
+// SyslogSink 将日志写入syslog/journald套接字
+type SyslogSink struct {
+	network  string // unixgram、unix、udp、tcp
+	address  string
+	tag      string
+	format   string
+	facility int
+	hostname string
+	conn     net.Conn
+}
+
+// WithSyslogFormat 设置日志格式，默认rfc5424
+func WithSyslogFormat(format string) SyslogSinkOption {
+	return func(s *SyslogSink) {
+		s.format = format
+	}
+}
+
+// WithSyslogFacility 设置facility，默认local0(16)
+func WithSyslogFacility(facility int) SyslogSinkOption {
+	return func(s *SyslogSink) {
+		s.facility = facility
+	}
+}
+
+// NewSyslogSink 创建syslog Sink，例:
+// NewSyslogSink("unixgram", logger.DefaultSyslogSocket, "frame-server")
+// NewSyslogSink("unixgram", logger.DefaultJournalSocket, "frame-server", logger.WithSyslogFormat(logger.SyslogFormatJournal))
+func NewSyslogSink(network, address, tag string, opts ...SyslogSinkOption) *SyslogSink {
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	s := &SyslogSink{
+		network:  network,
+		address:  address,
+		tag:      tag,
+		format:   SyslogFormatRFC5424,
+		facility: syslogFacilityLocal0,
+		hostname: hostname,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name Sink名称
+func (s *SyslogSink) Name() string {
+	return s.format + ":" + s.network + "://" + s.address
+}
+
+// WriteBatch 逐条写入，连接断开时重连一次
+func (s *SyslogSink) WriteBatch(ctx context.Context, entries []SinkEntry) error {
+	for _, entry := range entries {
+		msg := s.formatEntry(entry)
+		if err := s.write(ctx, msg); err != nil {
+			// 重连后重试
+			s.closeConn()
+			if err := s.write(ctx, msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write 写入一条消息
+func (s *SyslogSink) write(ctx context.Context, msg []byte) error {
+	if s.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return fmt.Errorf("syslog sink: %w", err)
+		}
+		s.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(deadline)
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// formatEntry 编码一条消息
+func (s *SyslogSink) formatEntry(entry SinkEntry) []byte {
+	var buf bytes.Buffer
+	severity := syslogSeverity(entry.Level)
+	if s.format == SyslogFormatJournal {
+		// journald原生协议: 每行一个KEY=VALUE，JSON中没有换行可以直接写入
+		buf.WriteString("PRIORITY=" + strconv.Itoa(severity) + "\n")
+		buf.WriteString("SYSLOG_IDENTIFIER=" + s.tag + "\n")
+		buf.WriteString("SYSLOG_FACILITY=" + strconv.Itoa(s.facility) + "\n")
+		if entry.Logger != "" {
+			buf.WriteString("LOGGER=" + entry.Logger + "\n")
+		}
+		if entry.Caller != "" {
+			buf.WriteString("CODE_FILE=" + entry.Caller + "\n")
+		}
+		buf.WriteString("MESSAGE=")
+		buf.Write(entry.JSON)
+		buf.WriteByte('\n')
+		return buf.Bytes()
+	}
+
+	// RFC5424: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	buf.WriteString("<" + strconv.Itoa(s.facility*8+severity) + ">1 ")
+	buf.WriteString(entry.Time.Format(time.RFC3339Nano) + " ")
+	buf.WriteString(s.hostname + " " + s.tag + " " + strconv.Itoa(os.Getpid()) + " - - ")
+	buf.Write(entry.JSON)
+	// 流式连接使用换行分隔消息
+	if s.network == "tcp" || s.network == "unix" {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// closeConn 关闭连接
+func (s *SyslogSink) closeConn() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// Close 关闭连接
+func (s *SyslogSink) Close() error {
+	s.closeConn()
+	return nil
+}
+
+// syslogSeverity zap级别转换为syslog severity
+func syslogSeverity(level zapcore.Level) int {
+	switch {
+	case level >= zapcore.FatalLevel:
+		return 2 // crit
+	case level >= zapcore.ErrorLevel:
+		return 3 // err
+	case level == zapcore.WarnLevel:
+		return 4 // warning
+	case level == zapcore.InfoLevel:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
@@ -0,0 +1,247 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/router"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsOption 定义指标组件选项函数类型
+type MetricsOption func(*MetricsComponent)
+
+// MetricsConfig 指标组件配置
+type MetricsConfig struct {
+	Namespace         string            // 指标名称前缀，例: order，resource_goroutine_count输出为order_resource_goroutine_count
+	ConstLabels       map[string]string // 所有指标附加的标签，例: service、env
+	Interval          time.Duration     // 内存使用量、Goroutine数量的采集间隔
+	RuntimeCollectors bool              // 是否注册Go运行时(go_*)和进程(process_*)指标
+	Push              *PushConfig       // 指标推送，为空不推送
+}
+
+// MetricsComponent 指标组件，使用独立的Registry，不影响Prometheus全局注册器
+// 服务指标和组件指标(连接池、查询耗时)加上命名空间前缀，所有指标附加固定标签
+type MetricsComponent struct {
+	config     *MetricsConfig
+	registry   *prometheus.Registry
+	registerer prometheus.Registerer
+	mu         sync.Mutex
+	registered bool
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	pushClient *http.Client // 推送使用的客户端，复用连接
+}
+
+// WithMetricsNamespace 设置指标名称前缀，非法字符替换为下划线
+func WithMetricsNamespace(namespace string) MetricsOption {
+	return func(m *MetricsComponent) {
+		m.config.Namespace = metricsNamespace(namespace)
+	}
+}
+
+// WithMetricsConstLabels 添加所有指标附加的标签，值为空的忽略
+func WithMetricsConstLabels(labels map[string]string) MetricsOption {
+	return func(m *MetricsComponent) {
+		for name, value := range labels {
+			if value != "" {
+				m.config.ConstLabels[name] = value
+			}
+		}
+	}
+}
+
+// WithMetricsService 设置服务名称和部署环境标签(service、env)
+func WithMetricsService(service, env string) MetricsOption {
+	return WithMetricsConstLabels(map[string]string{"service": service, "env": env})
+}
+
+// WithMetricsInterval 设置内存使用量、Goroutine数量的采集间隔
+func WithMetricsInterval(interval time.Duration) MetricsOption {
+	return func(m *MetricsComponent) {
+		if interval > 0 {
+			m.config.Interval = interval
+		}
+	}
+}
+
+// WithMetricsRuntimeCollectors 设置是否注册Go运行时和进程指标，默认注册
+func WithMetricsRuntimeCollectors(enable bool) MetricsOption {
+	return func(m *MetricsComponent) {
+		m.config.RuntimeCollectors = enable
+	}
+}
+
+// NewMetricsComponent 创建指标组件
+func NewMetricsComponent(opts ...MetricsOption) *MetricsComponent {
+	m := &MetricsComponent{
+		config: &MetricsConfig{
+			ConstLabels:       make(map[string]string),
+			Interval:          15 * time.Second,
+			RuntimeCollectors: true,
+		},
+		registry: prometheus.NewRegistry(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	labeled := prometheus.WrapRegistererWith(m.config.ConstLabels, m.registry)
+	m.registerer = labeled
+	if m.config.Namespace != "" {
+		m.registerer = prometheus.WrapRegistererWithPrefix(m.config.Namespace+"_", labeled)
+	}
+	return m
+}
+
+// Name 组件名称
+func (m *MetricsComponent) Name() string {
+	return "metrics"
+}
+
+// Registry 获取指标组件的Registry，用于输出或推送
+func (m *MetricsComponent) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Registerer 获取带命名空间前缀和固定标签的注册器，用于注册业务指标
+func (m *MetricsComponent) Registerer() prometheus.Registerer {
+	return m.registerer
+}
+
+// Handler 输出指标的处理器
+func (m *MetricsComponent) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Module 获取挂载指标接口的路由模块，通过WithGinRouteModules挂载到GinComponent
+// 例: Module("/metrics", monitor.PrometheusAuth())
+func (m *MetricsComponent) Module(path string, middlewares ...gin.HandlerFunc) router.RouteModule {
+	handler := gin.WrapH(m.Handler())
+	return router.NewModule("metrics", path, func(group *gin.RouterGroup) {
+		group.GET("", handler)
+	}, router.WithMiddleware(middlewares...))
+}
+
+// Start 注册指标，组件指标改为注册到此Registry，并定期采集资源使用情况，设置了推送间隔时定时推送
+func (m *MetricsComponent) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		return nil
+	}
+
+	if !m.registered {
+		if err := m.register(); err != nil {
+			return err
+		}
+		m.registered = true
+	}
+	if err := components.SetMetricsRegisterer(m.registerer); err != nil {
+		return fmt.Errorf("register component metrics: %w", err)
+	}
+
+	collectCtx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.collect(collectCtx)
+	}()
+	if m.config.Push != nil && m.config.Push.Interval > 0 {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.schedule(collectCtx)
+		}()
+	}
+
+	fmt.Printf("Metrics started: namespace=%s, interval=%s\n", m.config.Namespace, m.config.Interval)
+	if m.config.Push != nil {
+		fmt.Printf("Metrics push: mode=%s, url=%s, interval=%s\n", m.config.Push.Mode, m.config.Push.URL, m.config.Push.Interval)
+	}
+	return nil
+}
+
+// Stop 停止采集和定时推送，组件指标恢复注册到Prometheus全局注册器
+// 停止时不推送，其他组件停止后连接池指标已移除，最终的推送使用BeforeStop(Push)
+func (m *MetricsComponent) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel == nil {
+		return nil
+	}
+
+	m.cancel()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	m.cancel = nil
+	return components.SetMetricsRegisterer(prometheus.DefaultRegisterer)
+}
+
+// register 注册服务指标和运行时指标，运行时指标不加前缀
+func (m *MetricsComponent) register() error {
+	for _, collector := range serviceCollectors {
+		if err := m.registerer.Register(collector); err != nil {
+			return fmt.Errorf("register service metrics: %w", err)
+		}
+	}
+	if m.config.RuntimeCollectors {
+		labeled := prometheus.WrapRegistererWith(m.config.ConstLabels, m.registry)
+		if err := labeled.Register(collectors.NewGoCollector()); err != nil {
+			return fmt.Errorf("register go collector: %w", err)
+		}
+		if err := labeled.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})); err != nil {
+			return fmt.Errorf("register process collector: %w", err)
+		}
+	}
+	return nil
+}
+
+// collect 立即采集一次，之后按间隔采集
+func (m *MetricsComponent) collect(ctx context.Context) {
+	updateMetrics()
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			updateMetrics()
+		}
+	}
+}
+
+// metricsNamespace 替换指标名称中的非法字符，例: go-frame-server => go_frame_server
+func metricsNamespace(namespace string) string {
+	var b strings.Builder
+	for i, r := range namespace {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
@@ -1,7 +1,6 @@
 package monitor
 
 import (
-	"fmt"
 	"runtime"
 	"strconv"
 	"time"
@@ -10,12 +9,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// 服务指标，由MetricsComponent注册到独立的Registry，未启动时只记录不输出
 var (
 	// 内存使用量
-	memoryUsage = promauto.NewGauge(
+	memoryUsage = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "resource_memory_usage_bytes",
 			Help: "资源内存使用量（字节）",
@@ -23,7 +22,7 @@ var (
 	)
 
 	// Goroutine数量
-	goroutineCount = promauto.NewGauge(
+	goroutineCount = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "resource_goroutine_count",
 			Help: "资源Goroutine数量",
@@ -31,7 +30,7 @@ var (
 	)
 
 	// 服务响应时间
-	resourceLatency = promauto.NewHistogramVec(
+	resourceLatency = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name: "source_response_time_seconds",
 			Help: "服务响应时间（秒）",
@@ -42,37 +41,50 @@ var (
 	)
 
 	// 错误监控
-	sourceError = promauto.NewCounterVec(
+	sourceError = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "source_error_count",
 			Help: "服务错误监控",
 		},
 		[]string{"endpoint", "error_code"},
 	)
-)
 
-func init() {
-	// 注册所有指标
-	// 注意：使用promauto已自动注册，不需要再显式注册
-	fmt.Println("Prometheus metrics initializing...")
-	// 开始定期收集资源使用情况
-	go collectResourceMetrics()
-}
+	// 响应压缩前后字节数
+	compressionBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_response_compression_bytes_total",
+			Help: "响应压缩前(original)后(compressed)字节数",
+		},
+		[]string{"encoding", "stage"},
+	)
 
-// collectResourceMetrics 定期收集资源使用情况
-func collectResourceMetrics() {
-	// 立即更新一次数据
-	updateMetrics()
+	// 响应压缩率(压缩后/压缩前)
+	compressionRatio = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_compression_ratio",
+			Help:    "响应压缩率（压缩后/压缩前）",
+			Buckets: []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.8, 1},
+		},
+		[]string{"encoding"},
+	)
 
-	// 开始定期更新
-	for {
-		fmt.Println("查看是否执行")
-		time.Sleep(5 * time.Second)
-		updateMetrics()
+	// 慢查询数量
+	slowQueryCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_slow_query_total",
+			Help: "慢查询数量",
+		},
+		[]string{"component", "table", "operation"},
+	)
+
+	// 所有服务指标
+	serviceCollectors = []prometheus.Collector{
+		memoryUsage, goroutineCount, resourceLatency, sourceError,
+		compressionBytes, compressionRatio, slowQueryCount,
 	}
-}
+)
 
-// 将更新逻辑抽取出来
+// updateMetrics 更新内存使用量和Goroutine数量
 func updateMetrics() {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
@@ -94,6 +106,21 @@ func ObserveError(endpoint string, errorCode int) {
 	sourceError.WithLabelValues(endpoint, strconv.Itoa(errorCode)).Inc()
 }
 
+// ObserveCompression 记录响应压缩前后字节数，用于middleware.WithCompressObserver
+func ObserveCompression(encoding string, originalBytes, compressedBytes int) {
+	if originalBytes <= 0 {
+		return
+	}
+	compressionBytes.WithLabelValues(encoding, "original").Add(float64(originalBytes))
+	compressionBytes.WithLabelValues(encoding, "compressed").Add(float64(compressedBytes))
+	compressionRatio.WithLabelValues(encoding).Observe(float64(compressedBytes) / float64(originalBytes))
+}
+
+// ObserveSlowQuery 记录慢查询，用于MySQLConfig.SlowObserver/ClickHouseGORMConfig.SlowObserver
+func ObserveSlowQuery(component, table, operation string, elapsed time.Duration) {
+	slowQueryCount.WithLabelValues(component, table, operation).Inc()
+}
+
 // 添加基本认证
 func PrometheusAuth() gin.HandlerFunc {
 	return gin.BasicAuth(gin.Accounts{
@@ -0,0 +1,214 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/logger"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+)
+
+// 推送方式
+const (
+	PushModePushgateway = "pushgateway"  // 推送到Pushgateway，同一分组的指标整体替换
+	PushModeRemoteWrite = "remote_write" // Prometheus remote write协议，例: Prometheus、VictoriaMetrics、Mimir
+)
+
+// PushConfig 指标推送配置，用于运行时间短、来不及被抓取的任务
+type PushConfig struct {
+	Mode      string            // 推送方式 pushgateway/remote_write
+	URL       string            // Pushgateway地址，例: http://127.0.0.1:9091；remote write地址，例: http://127.0.0.1:9090/api/v1/write
+	Job       string            // 任务名称，Pushgateway分组的job，remote write附加job标签
+	Grouping  map[string]string // 分组标签，例: instance，不能与指标已有的标签(如service、env)重名
+	Interval  time.Duration     // 定时推送间隔，为0只在调用Push时推送(例: 停止前)
+	Timeout   time.Duration     // 单次推送超时
+	Retries   int               // 失败重试次数
+	RetryWait time.Duration     // 重试等待时间，第n次重试等待n倍
+	Username  string            // 基本认证用户名
+	Password  string            // 基本认证密码
+	Headers   map[string]string // 额外请求头，例: 多租户X-Scope-OrgID
+}
+
+// pushStatusError 推送返回非2xx状态码
+type pushStatusError struct {
+	code int
+	body string
+}
+
+func (e *pushStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.code, e.body)
+}
+
+// WithMetricsPush 设置指标推送，Mode为空时默认pushgateway，Job为空时使用服务名称
+func WithMetricsPush(conf PushConfig) MetricsOption {
+	return func(m *MetricsComponent) {
+		if conf.Mode == "" {
+			conf.Mode = PushModePushgateway
+		}
+		if conf.Timeout <= 0 {
+			conf.Timeout = 10 * time.Second
+		}
+		if conf.RetryWait <= 0 {
+			conf.RetryWait = time.Second
+		}
+		m.config.Push = &conf
+		m.pushClient = &http.Client{Timeout: conf.Timeout}
+	}
+}
+
+// Push 推送一次指标，失败时按配置重试，未设置推送时不处理
+// 注册为停止前的钩子，在组件停止前推送最终的指标，例: f.BeforeStop(metricsComponent.Push)
+func (m *MetricsComponent) Push(ctx context.Context) error {
+	conf := m.config.Push
+	if conf == nil {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt <= conf.Retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(time.Duration(attempt) * conf.RetryWait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errors.Join(err, ctx.Err())
+			case <-timer.C:
+			}
+		}
+		if err = m.pushOnce(ctx); err == nil || !retryablePushError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("push metrics to %s: %w", conf.Mode, err)
+	}
+	return nil
+}
+
+// pushOnce 按推送方式推送一次
+func (m *MetricsComponent) pushOnce(ctx context.Context) error {
+	conf := m.config.Push
+	client := m.pushClient
+	switch conf.Mode {
+	case PushModePushgateway:
+		pusher := push.New(conf.URL, m.pushJob()).Gatherer(m.registry).Client(client)
+		for name, value := range conf.Grouping {
+			pusher = pusher.Grouping(name, value)
+		}
+		if conf.Username != "" {
+			pusher = pusher.BasicAuth(conf.Username, conf.Password)
+		}
+		if len(conf.Headers) > 0 {
+			header := make(http.Header)
+			for name, value := range conf.Headers {
+				header.Set(name, value)
+			}
+			pusher = pusher.Header(header)
+		}
+		return pusher.PushContext(ctx)
+	case PushModeRemoteWrite:
+		return m.remoteWrite(ctx, client)
+	default:
+		return fmt.Errorf("unknown push mode %q", conf.Mode)
+	}
+}
+
+// remoteWrite 将当前指标按remote write协议(protobuf+snappy)发送
+func (m *MetricsComponent) remoteWrite(ctx context.Context, client *http.Client) error {
+	conf := m.config.Push
+	families, err := m.registry.Gather()
+	if err != nil {
+		return fmt.Errorf("gather metrics: %w", err)
+	}
+	labels := map[string]string{"job": m.pushJob()}
+	for name, value := range conf.Grouping {
+		labels[name] = value
+	}
+	data := encodeWriteRequest(families, labels, time.Now().UnixMilli())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.URL, bytes.NewReader(s2.EncodeSnappy(nil, data)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for name, value := range conf.Headers {
+		req.Header.Set(name, value)
+	}
+	if conf.Username != "" {
+		req.SetBasicAuth(conf.Username, conf.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &pushStatusError{code: resp.StatusCode, body: string(bytes.TrimSpace(body))}
+	}
+	return nil
+}
+
+// pushJob 任务名称，未设置时使用service标签，都没有时为go-frame-server
+func (m *MetricsComponent) pushJob() string {
+	if m.config.Push.Job != "" {
+		return m.config.Push.Job
+	}
+	if service := m.config.ConstLabels["service"]; service != "" {
+		return service
+	}
+	return "go-frame-server"
+}
+
+// schedule 按间隔定时推送，失败只记录日志
+func (m *MetricsComponent) schedule(ctx context.Context) {
+	ticker := time.NewTicker(m.config.Push.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Push(ctx); err != nil && ctx.Err() == nil {
+				logger.Named("metrics").Warn("push metrics failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// retryablePushError 4xx(429除外)为请求本身的问题，不重试
+func retryablePushError(err error) bool {
+	code := pushStatusCode(err)
+	if code == 0 {
+		return true
+	}
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// pushStatusPattern Pushgateway客户端返回的状态码错误，例: unexpected status code 400 while pushing to ...
+var pushStatusPattern = regexp.MustCompile(`unexpected status code (\d+)`)
+
+// pushStatusCode 获取推送返回的状态码，网络错误等返回0
+func pushStatusCode(err error) int {
+	var statusErr *pushStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code
+	}
+	if match := pushStatusPattern.FindStringSubmatch(err.Error()); match != nil {
+		code, _ := strconv.Atoi(match[1])
+		return code
+	}
+	return 0
+}
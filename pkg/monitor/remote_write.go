@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remote write的protobuf字段编号(prometheus/prompb)
+// WriteRequest{timeseries=1} TimeSeries{labels=1, samples=2} Label{name=1, value=2} Sample{value=1, timestamp=2}
+const (
+	writeRequestTimeseries protowire.Number = 1
+	timeSeriesLabels       protowire.Number = 1
+	timeSeriesSamples      protowire.Number = 2
+	labelName              protowire.Number = 1
+	labelValue             protowire.Number = 2
+	sampleValue            protowire.Number = 1
+	sampleTimestamp        protowire.Number = 2
+)
+
+// remoteLabel 时间序列的标签
+type remoteLabel struct {
+	name  string
+	value string
+}
+
+// encodeWriteRequest 将指标转换为remote write的WriteRequest，直方图和摘要按文本格式拆分为多个序列
+// extra为附加的标签，例: job、分组标签，与指标标签重名时以指标标签为准
+func encodeWriteRequest(families []*dto.MetricFamily, extra map[string]string, timestamp int64) []byte {
+	var b []byte
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			ts := timestamp
+			if metric.TimestampMs != nil {
+				ts = metric.GetTimestampMs()
+			}
+			base := metricLabels(metric, extra)
+			write := func(suffix string, value float64, labels ...remoteLabel) {
+				b = protowire.AppendTag(b, writeRequestTimeseries, protowire.BytesType)
+				b = protowire.AppendBytes(b, encodeTimeSeries(name+suffix, base, labels, value, ts))
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				write("", metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				write("", metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				write("", metric.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					write("", quantile.GetValue(), remoteLabel{"quantile", formatFloat(quantile.GetQuantile())})
+				}
+				write("_sum", summary.GetSampleSum())
+				write("_count", float64(summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				hasInf := false
+				for _, bucket := range histogram.GetBucket() {
+					if math.IsInf(bucket.GetUpperBound(), 1) {
+						hasInf = true
+					}
+					write("_bucket", float64(bucket.GetCumulativeCount()), remoteLabel{"le", formatFloat(bucket.GetUpperBound())})
+				}
+				if !hasInf {
+					write("_bucket", float64(histogram.GetSampleCount()), remoteLabel{"le", "+Inf"})
+				}
+				write("_sum", histogram.GetSampleSum())
+				write("_count", float64(histogram.GetSampleCount()))
+			}
+		}
+	}
+	return b
+}
+
+// metricLabels 指标标签加上附加标签
+func metricLabels(metric *dto.Metric, extra map[string]string) []remoteLabel {
+	labels := make([]remoteLabel, 0, len(metric.GetLabel())+len(extra))
+	exists := make(map[string]bool, len(metric.GetLabel()))
+	for _, label := range metric.GetLabel() {
+		labels = append(labels, remoteLabel{label.GetName(), label.GetValue()})
+		exists[label.GetName()] = true
+	}
+	for name, value := range extra {
+		if !exists[name] {
+			labels = append(labels, remoteLabel{name, value})
+		}
+	}
+	return labels
+}
+
+// encodeTimeSeries 编码一个时间序列，标签按名称排序
+func encodeTimeSeries(name string, base, labels []remoteLabel, value float64, timestamp int64) []byte {
+	all := make([]remoteLabel, 0, len(base)+len(labels)+1)
+	all = append(all, remoteLabel{"__name__", name})
+	all = append(all, base...)
+	all = append(all, labels...)
+	sort.Slice(all, func(i, j int) bool { return all[i].name < all[j].name })
+
+	var b []byte
+	for _, label := range all {
+		var lb []byte
+		lb = protowire.AppendTag(lb, labelName, protowire.BytesType)
+		lb = protowire.AppendString(lb, label.name)
+		lb = protowire.AppendTag(lb, labelValue, protowire.BytesType)
+		lb = protowire.AppendString(lb, label.value)
+		b = protowire.AppendTag(b, timeSeriesLabels, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+
+	var sb []byte
+	sb = protowire.AppendTag(sb, sampleValue, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(value))
+	sb = protowire.AppendTag(sb, sampleTimestamp, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(timestamp))
+	b = protowire.AppendTag(b, timeSeriesSamples, protowire.BytesType)
+	return protowire.AppendBytes(b, sb)
+}
+
+// formatFloat 按文本格式输出le、quantile的值
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -14,7 +14,9 @@ const (
 	FORBIDDEN                       = 4030 // 禁止
 	NOT_FOUND                       = 4040 // 未找到
 	METHOD_NOT_ALLOWED              = 4050 // 方法不允许
+	CONFLICT                        = 4090 // 冲突
 	GONE                            = 4100 // 已删除
+	REQUEST_ENTITY_TOO_LARGE        = 4130 // 请求体过大
 	UNSUPPORTED_MEDIA_TYPE          = 4150 // 不支持的媒体类型
 	UNPROCESSABLE_ENTITY            = 4220 // 不可处理的实体
 	TOO_MANY_REQUESTS               = 4290 // 太多请求
@@ -52,7 +54,9 @@ var apiCodes = []ApiCode{
 	{Code: FORBIDDEN, Message: "Forbidden"},
 	{Code: NOT_FOUND, Message: "Not Found"},
 	{Code: METHOD_NOT_ALLOWED, Message: "Method Not Allowed"},
+	{Code: CONFLICT, Message: "Conflict"},
 	{Code: GONE, Message: "Gone"},
+	{Code: REQUEST_ENTITY_TOO_LARGE, Message: "Request Entity Too Large"},
 	{Code: UNSUPPORTED_MEDIA_TYPE, Message: "Unsupported Media Type"},
 	{Code: UNPROCESSABLE_ENTITY, Message: "Unprocessable Entity"},
 	{Code: TOO_MANY_REQUESTS, Message: "Too Many Requests"},
@@ -81,3 +85,8 @@ func GetMessage(code int) string {
 	}
 	return "Error!"
 }
+
+// ApiCodes 获取所有已注册的业务码
+func ApiCodes() []ApiCode {
+	return append([]ApiCode(nil), apiCodes...)
+}
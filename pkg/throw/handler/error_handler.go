@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 )
@@ -17,6 +18,20 @@ func (e *ExceptionError) Error() string {
 	return e.ErrorMsg
 }
 
+// Exception 获取异常信息(ApiError、SqlError等嵌入类型均可直接调用)
+func (e *ExceptionError) Exception() *ExceptionError {
+	return e
+}
+
+// AsException 从错误链中获取异常信息
+func AsException(err error) (*ExceptionError, bool) {
+	var target interface{ Exception() *ExceptionError }
+	if errors.As(err, &target) {
+		return target.Exception(), true
+	}
+	return nil, false
+}
+
 // 记录错误调用者信息
 func ErrorCaller() (string, string) {
 	// 获取错误路径和函数名
@@ -10,6 +10,9 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/boloc/go-frame-server/pkg/frame/realip"
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -46,18 +49,19 @@ func BuildClickhouseDSN(dbMap map[string]any) string {
 	)
 }
 
-// 获取客户端IP
+// 获取客户端IP(经过受信任代理解析后的真实IP)
 func GetClientIP(c *gin.Context) string {
-	return c.ClientIP()
+	return realip.FromGin(c)
 }
 
-// 打印请求体
+// 打印请求体(已脱敏)
 func PrintReqParams(c *gin.Context) {
+	masker := mask.Default()
 	// 判断方法类型
 	method := c.Request.Method
 	if method == "GET" {
 		// 打印GET请求参数
-		query := c.Request.URL.Query()
+		query := masker.Query(c.Request.URL.Query())
 		fmt.Printf("传入的GET请求参数: %v\n", query)
 		//转成json,带格式的
 		queryJson, _ := json.MarshalIndent(query, "", "  ")
@@ -67,7 +71,11 @@ func PrintReqParams(c *gin.Context) {
 		body, _ := c.GetRawData()
 		// 转成json,带格式的
 		// bodyJson, _ := json.MarshalIndent(body, "", "  ")
-		fmt.Printf("传入的body请求体: %s\n", string(body))
+		if c.ContentType() == "application/x-www-form-urlencoded" {
+			fmt.Printf("传入的body请求体: %s\n", masker.Form(string(body)))
+		} else {
+			fmt.Printf("传入的body请求体: %s\n", masker.JSON(body))
+		}
 		// 为了不阻碍后续处理中还需要用到原始请求体，将数据重新设置回去
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 	}
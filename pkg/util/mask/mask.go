@@ -0,0 +1,440 @@
+package mask
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Placeholder 敏感字段的替换值
+const Placeholder = "******"
+
+// TagName 结构体标签，`log:"mask"` 脱敏，`log:"-"` 不输出
+const TagName = "log"
+
+// DefaultFields 默认的敏感字段名(忽略大小写、下划线和中划线)
+var DefaultFields = []string{
+	"password", "passwd", "pwd", "old_password", "new_password", "confirm_password",
+	"secret", "client_secret", "access_key_secret", "secret_key", "private_key",
+	"token", "access_token", "refresh_token", "id_token", "api_key", "apikey",
+	"authorization", "proxy_authorization", "cookie", "set_cookie", "session",
+	"credit_card", "card_number", "cvv", "pin",
+}
+
+// Pattern 值的脱敏规则
+type Pattern struct {
+	Name    string
+	Regexp  *regexp.Regexp
+	Replace func(match string) string // 为空时替换为Placeholder
+}
+
+// DefaultPatterns 默认的值脱敏规则: 邮箱、银行卡号、身份证号、手机号
+var DefaultPatterns = []Pattern{
+	{
+		Name:   "email",
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		Replace: func(match string) string {
+			at := strings.LastIndex(match, "@")
+			return keep(match[:at], 1, 0) + match[at:]
+		},
+	},
+	{
+		Name:   "id_card",
+		Regexp: regexp.MustCompile(`\b[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`),
+		Replace: func(match string) string {
+			return keep(match, 6, 4)
+		},
+	},
+	{
+		Name:   "bank_card",
+		Regexp: regexp.MustCompile(`\b\d{4}(?:[ -]?\d{4}){2,3}(?:[ -]?\d{1,3})?\b`),
+		Replace: func(match string) string {
+			digits := strings.NewReplacer(" ", "", "-", "").Replace(match)
+			if len(digits) < 13 || len(digits) > 19 || !luhn(digits) {
+				return match
+			}
+			return keep(digits, 4, 4)
+		},
+	},
+	{
+		Name:   "phone",
+		Regexp: regexp.MustCompile(`\b(?:\+?86[ -]?)?1[3-9]\d{9}\b`),
+		Replace: func(match string) string {
+			return keep(match, len(match)-8, 4)
+		},
+	},
+}
+
+// Option 定义脱敏选项函数类型
+type Option func(*Masker)
+
+// WithFields 设置敏感字段名，替换默认值
+func WithFields(fields ...string) Option {
+	return func(m *Masker) {
+		m.fields = make(map[string]struct{}, len(fields))
+		m.addFields(fields)
+	}
+}
+
+// WithExtraFields 在默认敏感字段名基础上追加
+func WithExtraFields(fields ...string) Option {
+	return func(m *Masker) {
+		m.addFields(fields)
+	}
+}
+
+// WithPatterns 设置值脱敏规则，替换默认值
+func WithPatterns(patterns ...Pattern) Option {
+	return func(m *Masker) {
+		m.patterns = patterns
+	}
+}
+
+// WithExtraPatterns 在默认值脱敏规则基础上追加，例:
+// mask.WithExtraPatterns(mask.Pattern{Name: "order_no", Regexp: regexp.MustCompile(`ORD\d{12}`)})
+func WithExtraPatterns(patterns ...Pattern) Option {
+	return func(m *Masker) {
+		m.patterns = append(append([]Pattern(nil), m.patterns...), patterns...)
+	}
+}
+
+// Masker 脱敏引擎
+// 字段名命中敏感字段时整体替换为Placeholder，字符串值按规则(邮箱、银行卡号、身份证号、手机号)部分隐藏
+type Masker struct {
+	fields   map[string]struct{}
+	patterns []Pattern
+}
+
+// New 创建脱敏引擎，默认使用DefaultFields和DefaultPatterns
+func New(opts ...Option) *Masker {
+	m := &Masker{
+		fields:   make(map[string]struct{}, len(DefaultFields)),
+		patterns: DefaultPatterns,
+	}
+	m.addFields(DefaultFields)
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+var defaultMasker atomic.Pointer[Masker]
+
+func init() {
+	defaultMasker.Store(New())
+}
+
+// Default 获取全局脱敏引擎
+func Default() *Masker {
+	return defaultMasker.Load()
+}
+
+// SetDefault 设置全局脱敏引擎
+func SetDefault(m *Masker) {
+	defaultMasker.Store(m)
+}
+
+// addFields 添加敏感字段名
+func (m *Masker) addFields(fields []string) {
+	for _, field := range fields {
+		m.fields[normalize(field)] = struct{}{}
+	}
+}
+
+// IsSensitive 字段名是否为敏感字段
+func (m *Masker) IsSensitive(field string) bool {
+	_, ok := m.fields[normalize(field)]
+	return ok
+}
+
+// String 按规则隐藏字符串中的敏感值
+func (m *Masker) String(s string) string {
+	for _, p := range m.patterns {
+		if p.Replace == nil {
+			s = p.Regexp.ReplaceAllLiteralString(s, Placeholder)
+			continue
+		}
+		s = p.Regexp.ReplaceAllStringFunc(s, p.Replace)
+	}
+	return s
+}
+
+// Field 脱敏一个键值对，敏感字段返回Placeholder
+func (m *Masker) Field(key string, value any) any {
+	if m.IsSensitive(key) {
+		return Placeholder
+	}
+	return m.Any(value)
+}
+
+// JSON 脱敏JSON，不是合法JSON时按字符串处理
+func (m *Masker) JSON(data []byte) []byte {
+	var v any
+	// 使用json.Number避免大整数丢失精度
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return []byte(m.String(string(data)))
+	}
+	masked, err := json.Marshal(m.Any(v))
+	if err != nil {
+		return data
+	}
+	return masked
+}
+
+// Query 脱敏查询参数/表单
+func (m *Masker) Query(values url.Values) url.Values {
+	masked := make(url.Values, len(values))
+	for key, vs := range values {
+		masked[key] = m.strings(key, vs)
+	}
+	return masked
+}
+
+// Header 脱敏请求头
+func (m *Masker) Header(header http.Header) http.Header {
+	masked := make(http.Header, len(header))
+	for key, vs := range header {
+		masked[key] = m.strings(key, vs)
+	}
+	return masked
+}
+
+// URL 脱敏URL中的查询参数，例: /login?token=xxx -> /login?token=******
+func (m *Masker) URL(rawURL string) string {
+	path, rawQuery, ok := strings.Cut(rawURL, "?")
+	if !ok || rawQuery == "" {
+		return m.String(rawURL)
+	}
+	return m.String(path) + "?" + m.Form(rawQuery)
+}
+
+// Form 脱敏查询字符串/application/x-www-form-urlencoded请求体
+func (m *Masker) Form(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return m.String(rawQuery)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// 不使用Encode，避免Placeholder被转义
+	parts := make([]string, 0, len(values))
+	for _, key := range keys {
+		for _, v := range m.strings(key, values[key]) {
+			parts = append(parts, url.QueryEscape(key)+"="+v)
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// strings 脱敏字符串列表
+func (m *Masker) strings(key string, values []string) []string {
+	masked := make([]string, len(values))
+	for i, v := range values {
+		if m.IsSensitive(key) {
+			masked[i] = Placeholder
+		} else {
+			masked[i] = m.String(v)
+		}
+	}
+	return masked
+}
+
+// Any 脱敏任意值，返回可JSON序列化的副本
+// map按key判断敏感字段，结构体按json名称判断并支持 `log:"mask"`(脱敏)、`log:"-"`(不输出)标签
+func (m *Masker) Any(value any) any {
+	if value == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		return m.String(v)
+	case []byte:
+		return m.String(string(v))
+	case json.RawMessage:
+		return json.RawMessage(m.JSON(v))
+	case map[string]any:
+		masked := make(map[string]any, len(v))
+		for key, item := range v {
+			masked[key] = m.Field(key, item)
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(v))
+		for i, item := range v {
+			masked[i] = m.Any(item)
+		}
+		return masked
+	case json.Number:
+		return v
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	}
+	return m.reflect(reflect.ValueOf(value), 0)
+}
+
+// 最大递归深度，防止循环引用
+const maxDepth = 32
+
+// reflect 通过反射脱敏
+func (m *Masker) reflect(rv reflect.Value, depth int) any {
+	if !rv.IsValid() || depth > maxDepth {
+		return nil
+	}
+	// 自定义序列化的类型(time.Time、decimal等)保持原样
+	if rv.CanInterface() {
+		switch rv.Interface().(type) {
+		case json.Marshaler, encoding.TextMarshaler:
+			return rv.Interface()
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return m.reflect(rv.Elem(), depth+1)
+	case reflect.String:
+		return m.String(rv.String())
+	case reflect.Struct:
+		return m.reflectStruct(rv, depth)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		masked := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := toString(iter.Key())
+			if m.IsSensitive(key) {
+				masked[key] = Placeholder
+				continue
+			}
+			masked[key] = m.reflect(iter.Value(), depth+1)
+		}
+		return masked
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return m.String(string(rv.Bytes()))
+		}
+		masked := make([]any, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			masked[i] = m.reflect(rv.Index(i), depth+1)
+		}
+		return masked
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	default:
+		if rv.CanInterface() {
+			return rv.Interface()
+		}
+		return nil
+	}
+}
+
+// reflectStruct 按json名称输出结构体字段
+func (m *Masker) reflectStruct(rv reflect.Value, depth int) any {
+	rt := rv.Type()
+	masked := make(map[string]any, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		if tagName, _, _ := strings.Cut(jsonTag, ","); tagName != "" {
+			name = tagName
+		}
+
+		switch field.Tag.Get(TagName) {
+		case "-":
+			continue
+		case "mask":
+			masked[name] = Placeholder
+			continue
+		}
+		if m.IsSensitive(name) || m.IsSensitive(field.Name) {
+			masked[name] = Placeholder
+			continue
+		}
+
+		value := m.reflect(rv.Field(i), depth+1)
+		// 匿名结构体字段展开
+		if field.Anonymous && jsonTag == "" {
+			if embedded, ok := value.(map[string]any); ok {
+				for k, v := range embedded {
+					if _, exists := masked[k]; !exists {
+						masked[k] = v
+					}
+				}
+				continue
+			}
+		}
+		masked[name] = value
+	}
+	return masked
+}
+
+// normalize 字段名统一为小写并去掉下划线和中划线
+func normalize(field string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(field))
+}
+
+// toString map的key转换为字符串
+func toString(rv reflect.Value) string {
+	if rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	if b, err := json.Marshal(rv.Interface()); err == nil {
+		return strings.Trim(string(b), `"`)
+	}
+	return ""
+}
+
+// keep 保留前head位和后tail位，中间替换为*
+func keep(s string, head, tail int) string {
+	if head < 0 {
+		head = 0
+	}
+	if len(s) <= head+tail {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:head] + strings.Repeat("*", len(s)-head-tail) + s[len(s)-tail:]
+}
+
+// luhn 银行卡号校验，减少误判
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame"
+	"github.com/boloc/go-frame-server/pkg/frame/admin"
+)
+
+// stubComponent 测试组件，err不为空时健康检查失败
+type stubComponent struct {
+	name string
+	err  error
+}
+
+func (s *stubComponent) Start(ctx context.Context) error  { return nil }
+func (s *stubComponent) Stop(ctx context.Context) error   { return nil }
+func (s *stubComponent) Name() string                     { return s.name }
+func (s *stubComponent) Health(ctx context.Context) error { return s.err }
+
+// 测试管理组件: 基本认证、组件列表和健康状态
+// go test -v -run TestAdminHandler ./tests/admin_test.go
+func TestAdminHandler(t *testing.T) {
+	f := frame.New()
+	redis := &stubComponent{name: "redis"}
+	f.RegisterComponent(&stubComponent{name: "mysql"})
+	f.RegisterComponent(redis)
+	handler := admin.NewAdminComponent(f, admin.WithAdminAuth("ops", "secret"), admin.WithAdminDumpSignal(false)).Handler()
+
+	request := func(path, username, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// 认证
+	for _, tc := range []struct {
+		username, password string
+		want               int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"ops", "wrong", http.StatusUnauthorized},
+		{"ops", "secret", http.StatusOK},
+	} {
+		if w := request("/components", tc.username, tc.password); w.Code != tc.want {
+			t.Errorf("auth %s/%s: status = %d, want %d", tc.username, tc.password, w.Code, tc.want)
+		}
+	}
+
+	// 组件列表
+	var statuses []frame.ComponentStatus
+	if err := json.Unmarshal(request("/components", "ops", "secret").Body.Bytes(), &statuses); err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].Name != "mysql" || statuses[1].Name != "redis" || !statuses[1].Healthy {
+		t.Errorf("components = %+v", statuses)
+	}
+
+	// 健康状态
+	if w := request("/health", "ops", "secret"); w.Code != http.StatusOK {
+		t.Errorf("health status = %d, want 200", w.Code)
+	}
+	redis.err = errors.New("connection refused")
+	w := request("/health", "ops", "secret")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unhealthy status = %d, want 503", w.Code)
+	}
+	statuses = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+		t.Fatal(err)
+	}
+	if statuses[1].Healthy || statuses[1].Error != "connection refused" {
+		t.Errorf("unhealthy components = %+v", statuses)
+	}
+}
+
+// 测试管理组件未设置密码时只允许监听本机地址
+// go test -v -run TestAdminRequiresAuth ./tests/admin_test.go
+func TestAdminRequiresAuth(t *testing.T) {
+	f := frame.New()
+	public := admin.NewAdminComponent(f, admin.WithAdminAddress(":0"), admin.WithAdminDumpSignal(false))
+	if err := public.Start(context.Background()); err == nil {
+		public.Stop(context.Background())
+		t.Fatal("admin without password should not listen on all interfaces")
+	}
+
+	local := admin.NewAdminComponent(f, admin.WithAdminAddress("127.0.0.1:0"), admin.WithAdminDumpSignal(false))
+	if err := local.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	authed := admin.NewAdminComponent(f, admin.WithAdminAddress(":0"), admin.WithAdminAuth("ops", "secret"), admin.WithAdminDumpSignal(false))
+	if err := authed.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := authed.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
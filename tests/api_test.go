@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/api"
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateUserRequest struct {
+	ID        int64  `uri:"id" binding:"required,min=1"`
+	Name      string `json:"name" binding:"required,max=16"`
+	Notify    bool   `form:"notify"`
+	RequestID string `header:"X-Request-ID" binding:"required"`
+}
+
+type User struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func newAPIEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	group := engine.Group("/v1/users")
+	api.Handle(group, http.MethodPut, "/:id", func(ctx context.Context, req UpdateUserRequest) (*User, error) {
+		if content.FromContext(ctx) == nil {
+			return nil, fmt.Errorf("request context missing")
+		}
+		if req.ID == 404 {
+			return nil, throw.ApiCustomException(enum.NOT_FOUND, "user not found")
+		}
+		return &User{ID: req.ID, Name: req.Name}, nil
+	}, api.WithSummary("更新用户"), api.WithErrorCodes(enum.NOT_FOUND))
+	return engine
+}
+
+func doJSON(engine *gin.Engine, method, target, body string, headers map[string]string) (int, *response.Response) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	resp := &response.Response{}
+	_ = json.Unmarshal(w.Body.Bytes(), resp)
+	return w.Code, resp
+}
+
+// 测试类型化接口的绑定、校验和统一响应
+// go test -v -run TestTypedHandler  ./tests/api_test.go
+func TestTypedHandler(t *testing.T) {
+	engine := newAPIEngine()
+	headers := map[string]string{"X-Request-Id": "req-1"}
+
+	status, resp := doJSON(engine, http.MethodPut, "/v1/users/7?notify=true", `{"name":"tom"}`, headers)
+	if status != http.StatusOK || resp.Code != enum.SUCCESS {
+		t.Fatalf("expected success, got %d %+v", status, resp)
+	}
+	if data := resp.Data.(map[string]any); data["id"].(float64) != 7 || data["name"] != "tom" {
+		t.Fatalf("unexpected data %+v", resp.Data)
+	}
+
+	// 缺少必填请求头
+	if status, resp = doJSON(engine, http.MethodPut, "/v1/users/7", `{"name":"tom"}`, nil); status != http.StatusBadRequest || resp.Code != enum.BAD_REQUEST {
+		t.Fatalf("expected validation error, got %d %+v", status, resp)
+	}
+
+	// 业务异常
+	if status, resp = doJSON(engine, http.MethodPut, "/v1/users/404", `{"name":"tom"}`, headers); status != http.StatusNotFound || resp.Code != enum.NOT_FOUND {
+		t.Fatalf("expected not found, got %d %+v", status, resp)
+	}
+}
+
+type RenameItemRequest struct {
+	Slug string `uri:"slug" binding:"required"`
+	Name string `json:"name"`
+}
+
+// 测试请求头和路径参数只绑定声明了header/uri标签的字段，不能覆盖请求体绑定的字段
+// go test -v -run TestTypedHandlerTaggedOnly ./tests/api_test.go
+func TestTypedHandlerTaggedOnly(t *testing.T) {
+	engine := newAPIEngine()
+	api.Handle(engine.Group("/v1/items"), http.MethodPut, "/:slug/:Name", func(ctx context.Context, req RenameItemRequest) (map[string]string, error) {
+		return map[string]string{"slug": req.Slug, "name": req.Name}, nil
+	})
+
+	headers := map[string]string{"X-Request-Id": "req-1", "Name": "evil"}
+	status, resp := doJSON(engine, http.MethodPut, "/v1/users/7", `{"name":"tom"}`, headers)
+	if status != http.StatusOK {
+		t.Fatalf("expected success, got %d %+v", status, resp)
+	}
+	if data := resp.Data.(map[string]any); data["name"] != "tom" {
+		t.Errorf("header overrode body field: %+v", data)
+	}
+
+	status, resp = doJSON(engine, http.MethodPut, "/v1/items/book/evil", `{"name":"tom"}`, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected success, got %d %+v", status, resp)
+	}
+	if data := resp.Data.(map[string]any); data["slug"] != "book" || data["name"] != "tom" {
+		t.Errorf("path param overrode body field: %+v", data)
+	}
+}
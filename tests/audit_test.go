@@ -0,0 +1,334 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boloc/go-frame-server/pkg/frame/audit"
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// memoryAuditStore 内存审计存储
+type memoryAuditStore struct {
+	mu      sync.Mutex
+	entries []*audit.Entry
+}
+
+func (s *memoryAuditStore) Name() string { return "memory" }
+
+func (s *memoryAuditStore) Last(ctx context.Context, chain string) (uint64, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return 0, "", nil
+	}
+	last := s.entries[len(s.entries)-1]
+	return last.Seq, last.Hash, nil
+}
+
+func (s *memoryAuditStore) Write(ctx context.Context, entries []*audit.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+func (s *memoryAuditStore) Range(ctx context.Context, chain string, fromSeq uint64, limit int) ([]*audit.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []*audit.Entry
+	for _, entry := range s.entries {
+		if entry.Chain == chain && entry.Seq >= fromSeq && len(entries) < limit {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	return entries, nil
+}
+
+// list 获取已写入的记录
+func (s *memoryAuditStore) list() []*audit.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*audit.Entry(nil), s.entries...)
+}
+
+func (s *memoryAuditStore) Query(ctx context.Context, query *audit.Query) ([]*audit.Entry, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries, int64(len(s.entries)), nil
+}
+
+// 测试审计记录、哈希链校验和脱敏
+// go test -v -run TestAudit ./tests/audit_test.go
+func TestAudit(t *testing.T) {
+	store := &memoryAuditStore{}
+	auditor := audit.New(store, audit.WithChain("test"), audit.WithBatch(10, 10*time.Millisecond))
+	if err := auditor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rc := &content.RequestContext{RequestID: "req-1", ClientIP: "10.0.0.1"}
+	rc.SetIdentity("alice")
+	ctx := content.NewContext(context.Background(), rc)
+
+	type user struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Age      int    `json:"age"`
+	}
+	before := user{Name: "bob", Password: "old", Age: 20}
+	after := user{Name: "bob", Password: "new", Age: 21}
+	if err := auditor.Log(ctx, audit.ActionUpdate, "user", "1", before, after); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Record(audit.ContextWithActor(context.Background(), "cron:cleanup"), audit.Entry{Action: "cleanup", Resource: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(store.entries))
+	}
+	first := store.entries[0]
+	if first.Actor != "alice" || first.RequestID != "req-1" || first.ClientIP != "10.0.0.1" || first.Seq != 1 {
+		t.Errorf("unexpected entry: %+v", first)
+	}
+	var diff map[string]any
+	if err := json.Unmarshal(first.Diff, &diff); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := diff["name"]; ok || diff["password"] != "******" || diff["age"] == nil {
+		t.Errorf("unexpected diff: %s", first.Diff)
+	}
+	if second := store.entries[1]; second.Actor != "cron:cleanup" || second.PrevHash != first.Hash {
+		t.Errorf("unexpected chain: %+v", second)
+	}
+
+	result, err := auditor.Verify(context.Background(), "")
+	if err != nil || !result.Valid || result.Checked != 2 {
+		t.Fatalf("verify = %+v, %v", result, err)
+	}
+	// 篡改后校验失败
+	store.entries[0].Actor = "mallory"
+	if result, _ := auditor.Verify(context.Background(), ""); result.Valid || result.BrokenAt != 1 {
+		t.Errorf("tampered chain should be invalid: %+v", result)
+	}
+	store.entries[0].Actor = "alice"
+
+	// 重启后继续链接
+	if err := auditor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_ = auditor.Record(context.Background(), audit.Entry{Action: "login", Resource: "user"})
+	_ = auditor.Stop(context.Background())
+	if result, _ := auditor.Verify(context.Background(), ""); !result.Valid || result.Checked != 3 {
+		t.Errorf("verify after restart = %+v", result)
+	}
+}
+
+// blockingAuditStore 写入时等待release关闭的审计存储
+type blockingAuditStore struct {
+	memoryAuditStore
+	writing chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingAuditStore) Write(ctx context.Context, entries []*audit.Entry) error {
+	s.once.Do(func() { close(s.writing) })
+	<-s.release
+	return s.memoryAuditStore.Write(ctx, entries)
+}
+
+// 测试Stop超时后写入协程仍在运行时不允许重新启动，退出后继续链接
+// go test -v -run TestAuditRestartAfterStopTimeout ./tests/audit_test.go
+func TestAuditRestartAfterStopTimeout(t *testing.T) {
+	store := &blockingAuditStore{writing: make(chan struct{}), release: make(chan struct{})}
+	auditor := audit.New(store, audit.WithChain("test"), audit.WithBatch(1, time.Hour))
+	if err := auditor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Record(context.Background(), audit.Entry{Action: "login", Resource: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	<-store.writing
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := auditor.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stop error = %v, want deadline exceeded", err)
+	}
+	if err := auditor.Start(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("start while previous writer running = %v, want deadline exceeded", err)
+	}
+
+	close(store.release)
+	if err := auditor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Record(context.Background(), audit.Entry{Action: "logout", Resource: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := auditor.Verify(context.Background(), ""); err != nil || !result.Valid || result.Checked != 2 {
+		t.Errorf("verify after restart = %+v, %v", result, err)
+	}
+}
+
+// auditUser 审计的GORM模型
+type auditUser struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func (auditUser) AuditResource() string { return "user" }
+
+// 测试GORM插件: 提交后记录，回滚时丢弃，超出最多行数时截断
+// go test -v -run TestAuditGormTransaction ./tests/audit_test.go
+func TestAuditGormTransaction(t *testing.T) {
+	store := &memoryAuditStore{}
+	auditor := audit.New(store, audit.WithChain("test"), audit.WithBatch(100, time.Hour))
+	if err := auditor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(sqlite.Open("file:audit?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(auditor.GormPlugin(audit.WithGormMaxRows(2))); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&auditUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err != nil || sqlDB == nil {
+		t.Fatalf("db.DB() = %v, %v", sqlDB, err)
+	}
+
+	// GORM默认事务提交后记录
+	if err := db.Create(&auditUser{Name: "alice", Age: 20}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 回滚的事务不记录
+	rollback := errors.New("rollback")
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&auditUser{Name: "bob", Age: 30}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&auditUser{}).Where("name = ?", "alice").Update("age", 99).Error; err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("transaction error = %v", err)
+	}
+	// 提交的事务记录
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create([]*auditUser{{Name: "carol", Age: 40}, {Name: "dave", Age: 50}}).Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 批量修改3行，只记录前2行
+	if err := db.Model(&auditUser{}).Where("age >= ?", 20).Update("age", 60).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 回滚的bob和age=99不应出现
+	var actions []string
+	for _, entry := range store.list() {
+		var row auditUser
+		if err := json.Unmarshal(entry.After, &row); err != nil {
+			t.Fatal(err)
+		}
+		actions = append(actions, fmt.Sprintf("%s:%s:%d", entry.Action, row.Name, row.Age))
+	}
+	want := []string{"create:alice:20", "create:carol:40", "create:dave:50", "update:alice:60", "update:carol:60"}
+	if len(actions) != len(want) {
+		t.Fatalf("audit entries = %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Fatalf("audit entries = %v, want %v", actions, want)
+		}
+	}
+}
+
+// rejectingAuditStore 拒绝写入包含指定操作的批次，模拟无法写入的记录
+type rejectingAuditStore struct {
+	memoryAuditStore
+	action string
+}
+
+func (s *rejectingAuditStore) Write(ctx context.Context, entries []*audit.Entry) error {
+	for _, entry := range entries {
+		if entry.Action == s.action {
+			return errors.New("data too long")
+		}
+	}
+	return s.memoryAuditStore.Write(ctx, entries)
+}
+
+// 测试无法写入的批次重试后丢弃，之后的记录继续写入且链完整；超长字段在计算Hash前截断
+// go test -v -run TestAuditWriteFailure ./tests/audit_test.go
+func TestAuditWriteFailure(t *testing.T) {
+	store := &rejectingAuditStore{action: "reject"}
+	auditor := audit.New(store, audit.WithChain("test"), audit.WithBatch(1, 10*time.Millisecond), audit.WithWriteRetries(2))
+	if err := auditor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Record(context.Background(), audit.Entry{Action: "reject", Resource: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	waitUntil(t, func() bool { return auditor.Stats().Dropped == 1 })
+
+	long := audit.Entry{
+		Actor:      strings.Repeat("测", 300),
+		Action:     "login",
+		Resource:   "user",
+		ResourceID: strings.Repeat("1", 300),
+		RequestID:  strings.Repeat("r", 200),
+	}
+	if err := auditor.Record(context.Background(), long); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditor.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := store.list()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Seq != 1 || entry.PrevHash != "" {
+		t.Errorf("entry after discarded batch = seq %d, prev_hash %q", entry.Seq, entry.PrevHash)
+	}
+	if utf8.RuneCountInString(entry.Actor) != 128 || len(entry.ResourceID) != 128 || len(entry.RequestID) != 128 {
+		t.Errorf("field lengths = %d, %d, %d", utf8.RuneCountInString(entry.Actor), len(entry.ResourceID), len(entry.RequestID))
+	}
+	if result, err := auditor.Verify(context.Background(), ""); err != nil || !result.Valid || result.Checked != 1 {
+		t.Errorf("verify = %+v, %v", result, err)
+	}
+}
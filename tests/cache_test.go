@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// newCacheEngine 创建带响应缓存的路由，用户标识取自请求头X-User，响应内容为用户标识和处理次数
+func newCacheEngine(t *testing.T, opts ...middleware.CacheOption) (*gin.Engine, *miniredis.Miniredis, *atomic.Int32) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	redisComponent := components.NewRedisComponent(components.WithRedisAddr(server.Addr()))
+	if err := redisComponent.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redisComponent.Stop(context.Background()) })
+
+	calls := &atomic.Int32{}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.ContextMiddleware(), func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			content.FromContext(c.Request.Context()).SetIdentity(user)
+		}
+	})
+	engine.Use(middleware.NewResponseCache(redisComponent, opts...).Middleware())
+	engine.GET("/profile", func(c *gin.Context) {
+		rc := content.FromContext(c.Request.Context())
+		c.String(http.StatusOK, "%s-%d", rc.Identity(), calls.Add(1))
+	})
+	return engine, server, calls
+}
+
+// cacheGet 发送GET请求
+func cacheGet(engine *gin.Engine, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// expireCache 将缓存的新鲜截止时间改为已过期
+func expireCache(t *testing.T, server *miniredis.Miniredis) {
+	t.Helper()
+	for _, key := range server.Keys() {
+		if !strings.HasPrefix(key, "cache:resp:") || strings.HasSuffix(key, ":refresh") {
+			continue
+		}
+		value, _ := server.Get(key)
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entry["fresh_until"] = time.Now().Add(-time.Second).UnixMilli()
+		data, _ := json.Marshal(entry)
+		if err := server.Set(key, string(data)); err != nil {
+			t.Fatal(err)
+		}
+		server.SetTTL(key, time.Minute)
+	}
+}
+
+// 测试响应缓存: 未命中后命中、ETag返回304、no-cache跳过缓存读取
+// go test -v -run TestResponseCacheHit ./tests/cache_test.go
+func TestResponseCacheHit(t *testing.T) {
+	engine, _, calls := newCacheEngine(t)
+
+	miss := cacheGet(engine, map[string]string{"X-User": "alice"})
+	if miss.Header().Get("X-Cache") != "MISS" || miss.Body.String() != "alice-1" {
+		t.Fatalf("miss response = %s %q", miss.Header().Get("X-Cache"), miss.Body.String())
+	}
+	hit := cacheGet(engine, map[string]string{"X-User": "alice"})
+	if hit.Header().Get("X-Cache") != "HIT" || hit.Body.String() != "alice-1" {
+		t.Errorf("hit response = %s %q", hit.Header().Get("X-Cache"), hit.Body.String())
+	}
+	etag := hit.Header().Get("ETag")
+	if etag == "" || etag != miss.Header().Get("ETag") {
+		t.Errorf("etag = %q, miss etag = %q", etag, miss.Header().Get("ETag"))
+	}
+	if w := cacheGet(engine, map[string]string{"X-User": "alice", "If-None-Match": etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("conditional response = %d %q", w.Code, w.Body.String())
+	}
+	if w := cacheGet(engine, map[string]string{"X-User": "alice", "Cache-Control": "no-cache"}); w.Body.String() != "alice-2" {
+		t.Errorf("no-cache response = %q", w.Body.String())
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler calls = %d, want 2", calls.Load())
+	}
+}
+
+// 测试响应缓存按用户隔离: 不同用户不共享缓存，携带凭证但未认证出用户的请求不使用缓存
+// go test -v -run TestResponseCacheIdentity ./tests/cache_test.go
+func TestResponseCacheIdentity(t *testing.T) {
+	engine, _, _ := newCacheEngine(t)
+
+	cacheGet(engine, map[string]string{"X-User": "alice"})
+	if w := cacheGet(engine, map[string]string{"X-User": "bob"}); w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "bob-2" {
+		t.Errorf("bob response = %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+	if w := cacheGet(engine, map[string]string{"X-User": "alice"}); w.Body.String() != "alice-1" {
+		t.Errorf("alice response = %q", w.Body.String())
+	}
+
+	for i := 0; i < 2; i++ {
+		w := cacheGet(engine, map[string]string{"Authorization": "Bearer unknown"})
+		if w.Header().Get("X-Cache") != "" || w.Body.String() != fmt.Sprintf("-%d", 3+i) {
+			t.Errorf("credential request %d = %s %q, want uncached", i, w.Header().Get("X-Cache"), w.Body.String())
+		}
+	}
+
+	// 关闭用户区分后所有用户共享缓存
+	shared, _, _ := newCacheEngine(t, middleware.WithCacheVaryIdentity(false))
+	cacheGet(shared, map[string]string{"X-User": "alice"})
+	if w := cacheGet(shared, map[string]string{"X-User": "bob"}); w.Header().Get("X-Cache") != "HIT" || w.Body.String() != "alice-1" {
+		t.Errorf("shared response = %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+}
+
+// 测试过期后返回旧数据并在后台刷新: 响应不等待处理器，同一时间只有一个刷新
+// go test -v -run TestResponseCacheStale ./tests/cache_test.go
+func TestResponseCacheStale(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisComponent := components.NewRedisComponent(components.WithRedisAddr(server.Addr()))
+	if err := redisComponent.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer redisComponent.Stop(context.Background())
+
+	var calls atomic.Int32
+	refreshing, release := make(chan struct{}), make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.NewResponseCache(redisComponent,
+		middleware.WithCacheStaleWhileRevalidate(time.Minute),
+		middleware.WithCacheRefreshTimeout(2*time.Second),
+	).Middleware())
+	engine.GET("/profile", func(c *gin.Context) {
+		n := calls.Add(1)
+		if n == 2 {
+			close(refreshing)
+			<-release
+		}
+		c.String(http.StatusOK, "v%d", n)
+	})
+	httpServer := httptest.NewServer(engine)
+	defer httpServer.Close()
+
+	get := func() (string, string) {
+		resp, err := http.Get(httpServer.URL + "/profile")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get("X-Cache"), string(body)
+	}
+
+	if state, body := get(); state != "MISS" || body != "v1" {
+		t.Fatalf("first response = %s %q", state, body)
+	}
+	expireCache(t, server)
+
+	// 处理器阻塞时仍立即返回旧数据
+	for i := 0; i < 3; i++ {
+		if state, body := get(); state != "STALE" || body != "v1" {
+			t.Errorf("stale response %d = %s %q", i, state, body)
+		}
+	}
+	select {
+	case <-refreshing:
+	case <-time.After(2 * time.Second):
+		t.Fatal("background refresh not started")
+	}
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		state, body := get()
+		if state == "HIT" && body == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache not refreshed: %s %q", state, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler calls = %d, want 2", calls.Load())
+	}
+}
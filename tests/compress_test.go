@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// decompress 按Content-Encoding解压响应体
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var (
+		reader io.Reader
+		err    error
+	)
+	switch encoding {
+	case "":
+		return string(body)
+	case middleware.EncodingGzip:
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case middleware.EncodingBrotli:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case middleware.EncodingZstd:
+		var decoder *zstd.Decoder
+		decoder, err = zstd.NewReader(bytes.NewReader(body))
+		if err == nil {
+			defer decoder.Close()
+			reader = decoder
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("decompress %s: %v", encoding, err)
+	}
+	return string(data)
+}
+
+// 测试响应压缩: Accept-Encoding协商、最小字节数、排除的Content-Type、Flush和压缩前的Size
+// go test -v -run TestCompressMiddleware ./tests/compress_test.go
+func TestCompressMiddleware(t *testing.T) {
+	large := strings.Repeat("compressible text ", 100)
+	var size int
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.CompressMiddleware(), func(c *gin.Context) {
+		c.Next()
+		size = c.Writer.Size()
+	})
+	engine.GET("/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	engine.GET("/small", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	engine.GET("/image", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte(large)) })
+	engine.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, "data: 1\n\n")
+		c.Writer.Flush()
+		c.String(http.StatusOK, "data: 2\n\n")
+	})
+
+	cases := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{"服务端优先级", "/large", "gzip, br, zstd", middleware.EncodingBrotli, large},
+		{"q值优先", "/large", "br;q=0.5, gzip", middleware.EncodingGzip, large},
+		{"zstd", "/large", "zstd", middleware.EncodingZstd, large},
+		{"通配", "/large", "*", middleware.EncodingBrotli, large},
+		{"q=0不接受", "/large", "gzip;q=0, identity", "", large},
+		{"未携带Accept-Encoding", "/large", "", "", large},
+		{"小于最小字节数", "/small", "gzip", "", "ok"},
+		{"排除的Content-Type", "/image", "gzip", "", large},
+		{"Flush后立即压缩", "/stream", "gzip", middleware.EncodingGzip, "data: 1\n\ndata: 2\n\n"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		encoding := w.Header().Get("Content-Encoding")
+		if encoding != tc.wantEncoding {
+			t.Errorf("%s: Content-Encoding = %q, want %q", tc.name, encoding, tc.wantEncoding)
+			continue
+		}
+		if body := decompress(t, encoding, w.Body.Bytes()); body != tc.wantBody {
+			t.Errorf("%s: body = %q", tc.name, body)
+		}
+		if size != len(tc.wantBody) {
+			t.Errorf("%s: Size() = %d, want %d", tc.name, size, len(tc.wantBody))
+		}
+		if encoding != "" && w.Body.Len() >= len(tc.wantBody) && tc.path == "/large" {
+			t.Errorf("%s: compressed %d bytes, original %d", tc.name, w.Body.Len(), len(tc.wantBody))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试请求体记录: Content-Type过滤、大小上限和截断标记，处理器执行前可用且不影响处理器读取
+// go test -v -run TestContextBodyCapture ./tests/context_test.go
+func TestContextBodyCapture(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.ContextMiddleware(
+		middleware.WithCaptureMaxBytes(16),
+		middleware.WithCaptureMask(false, nil),
+	))
+	type captured struct {
+		body      string
+		truncated bool
+		read      string
+	}
+	var got captured
+	engine.POST("/echo", func(c *gin.Context) {
+		rc := content.FromContext(c.Request.Context())
+		got = captured{truncated: rc.RequestBodyTruncated}
+		if rc.RequestBody != nil {
+			got.body = string(*rc.RequestBody)
+		}
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		got.read = string(data)
+	})
+
+	long := `{"name":"0123456789abcdef"}`
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		chunked     bool
+		want        captured
+	}{
+		{"长度已知的JSON", "application/json", `{"id":1}`, false, captured{body: `{"id":1}`, read: `{"id":1}`}},
+		{"未声明长度的小请求体", "application/json", `{"id":1}`, true, captured{body: `{"id":1}`, read: `{"id":1}`}},
+		{"超出上限截断", "application/json", long, false, captured{body: `"{\"name\":\"0123456"`, truncated: true, read: long}},
+		{"未声明长度超出上限截断", "application/json", long, true, captured{body: `"{\"name\":\"0123456"`, truncated: true, read: long}},
+		{"不记录的Content-Type", "application/octet-stream", "binary", false, captured{read: "binary"}},
+		{"+json后缀", "application/problem+json", `{"id":2}`, false, captured{body: `{"id":2}`, read: `{"id":2}`}},
+	}
+	for _, tc := range cases {
+		var body io.Reader = strings.NewReader(tc.body)
+		if tc.chunked {
+			body = io.MultiReader(body)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/echo", body)
+		if tc.chunked {
+			req.ContentLength = -1
+		}
+		req.Header.Set("Content-Type", tc.contentType)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试优雅关闭: readiness置为失败、PreStopDelay期间继续处理请求、等待正在处理的请求完成
+// go test -v -run TestGinDrain ./tests/gin_drain_test.go
+func TestGinDrain(t *testing.T) {
+	const preStopDelay = 300 * time.Millisecond
+	address := freeAddress(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	ginComponent := components.NewGinComponent(
+		components.WithGinMode(gin.TestMode),
+		components.WithGinPrintRoutes(false),
+		components.WithGinListeners(components.GinListener{Address: address}),
+		components.WithGinPreStopDelay(preStopDelay),
+		components.WithGinRouter(func(engine *gin.Engine) {
+			engine.GET("/slow", func(c *gin.Context) {
+				close(started)
+				<-release
+				c.String(http.StatusOK, "done")
+			})
+		}),
+	)
+	if err := ginComponent.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	readiness := func() int {
+		resp, err := client.Get("http://" + address + components.DefaultReadinessPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := readiness(); code != http.StatusOK {
+		t.Fatalf("readiness = %d, want 200", code)
+	}
+
+	// 正在处理的请求
+	slowDone := make(chan int, 1)
+	go func() {
+		resp, err := client.Get("http://" + address + "/slow")
+		if err != nil {
+			slowDone <- 0
+			return
+		}
+		resp.Body.Close()
+		slowDone <- resp.StatusCode
+	}()
+	<-started
+	if inFlight := ginComponent.InFlight(); inFlight != 1 {
+		t.Errorf("in-flight = %d, want 1", inFlight)
+	}
+
+	begin := time.Now()
+	stopped := make(chan error, 1)
+	go func() { stopped <- ginComponent.Stop(context.Background()) }()
+
+	// 等待期间仍可访问，readiness返回503
+	deadline := time.Now().Add(preStopDelay / 2)
+	for ginComponent.IsReady() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if code := readiness(); code != http.StatusServiceUnavailable {
+		t.Errorf("readiness while draining = %d, want 503", code)
+	}
+
+	close(release)
+	if code := <-slowDone; code != http.StatusOK {
+		t.Errorf("in-flight request status = %d, want 200", code)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed < preStopDelay {
+		t.Errorf("stop returned after %s, want at least %s", elapsed, preStopDelay)
+	}
+	if inFlight := ginComponent.InFlight(); inFlight != 0 {
+		t.Errorf("in-flight after stop = %d", inFlight)
+	}
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testCert 测试证书
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	tls  tls.Certificate
+}
+
+// newTestCert 生成证书，parent为空时自签名(CA)
+func newTestCert(t *testing.T, cn string, serial int64, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, pem: certPEM, tls: pair}
+}
+
+// writeFiles 写入证书和私钥，先写临时文件再重命名，与证书更新工具的行为一致
+func (c *testCert) writeFiles(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	keyDER, _ := x509.MarshalECPrivateKey(c.key)
+	for file, data := range map[string][]byte{
+		keyFile:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		certFile: c.pem,
+	} {
+		if err := os.WriteFile(file+".tmp", data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(file+".tmp", file); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// freeAddress 获取可用的本地端口
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// 测试TLS: 证书文件替换后热加载、mTLS客户端证书身份写入请求上下文
+// go test -v -run TestGinTLS ./tests/gin_tls_test.go
+func TestGinTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	ca := newTestCert(t, "test-ca", 1, nil)
+	if err := os.WriteFile(caFile, ca.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	newTestCert(t, "server", 100, ca).writeFiles(t, certFile, keyFile)
+	client := newTestCert(t, "order-service", 200, ca)
+
+	address := freeAddress(t)
+	ginComponent := components.NewGinComponent(
+		components.WithGinMode(gin.TestMode),
+		components.WithGinPrintRoutes(false),
+		components.WithGinTLS(certFile, keyFile),
+		components.WithGinClientCA(caFile, true),
+		components.WithGinListeners(components.GinListener{Address: address, TLS: true}),
+		components.WithGinMiddleware(middleware.ContextMiddleware()),
+		components.WithGinRouter(func(engine *gin.Engine) {
+			engine.GET("/peer", func(c *gin.Context) {
+				peer := content.FromContext(c.Request.Context()).Peer
+				if peer == nil {
+					c.String(http.StatusUnauthorized, "")
+					return
+				}
+				c.String(http.StatusOK, peer.CommonName+"/"+peer.Issuer)
+			})
+		}),
+	)
+	if err := ginComponent.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer ginComponent.Stop(context.Background())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{client.tls},
+	}}}
+	resp, err := httpClient.Get("https://" + address + "/peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "order-service/test-ca" {
+		t.Errorf("peer identity = %q", body)
+	}
+
+	// 未提供客户端证书时握手失败
+	noCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if resp, err := noCert.Get("https://" + address + "/peer"); err == nil {
+		resp.Body.Close()
+		t.Error("request without client certificate should fail")
+	}
+
+	// 替换证书文件后新连接使用新证书
+	serverSerial := func() int64 {
+		conn, err := tls.Dial("tcp", address, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{client.tls}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	if serial := serverSerial(); serial != 100 {
+		t.Fatalf("server certificate serial = %d, want 100", serial)
+	}
+	newTestCert(t, "server", 101, ca).writeFiles(t, certFile, keyFile)
+	deadline := time.Now().Add(5 * time.Second)
+	for serverSerial() != 101 {
+		if time.Now().After(deadline) {
+			t.Fatal("certificate not reloaded")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// 测试Gin组件停止后重新启动: 监听unix socket，停止时删除socket文件
+// go test -v -run TestGinRestart ./tests/gin_tls_test.go
+func TestGinRestart(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	ginComponent := components.NewGinComponent(
+		components.WithGinMode(gin.TestMode),
+		components.WithGinPrintRoutes(false),
+		components.WithGinListeners(components.GinListener{Network: components.ListenerNetworkUnix, Address: socket}),
+		components.WithGinRouter(func(engine *gin.Engine) {
+			engine.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+		}),
+	)
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+		DisableKeepAlives: true,
+	}}
+
+	for i := 0; i < 2; i++ {
+		if err := ginComponent.Start(context.Background()); err != nil {
+			t.Fatalf("start %d: %v", i, err)
+		}
+		resp, err := client.Get("http://unix/ping")
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		resp.Body.Close()
+		if err := ginComponent.Stop(context.Background()); err != nil {
+			t.Fatalf("stop %d: %v", i, err)
+		}
+		if _, err := os.Stat(socket); !os.IsNotExist(err) {
+			t.Errorf("socket file should be removed after stop: %v", err)
+		}
+	}
+}
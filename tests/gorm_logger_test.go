@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+
+	"gorm.io/gorm/logger"
+)
+
+// 测试SQL表名解析和慢查询回调
+// go test -v -run TestGormLogger ./tests/gorm_logger_test.go
+func TestGormLogger(t *testing.T) {
+	cases := map[string][2]string{
+		"SELECT * FROM `gm_users` WHERE id = 1":          {"select", "gm_users"},
+		"INSERT INTO `db`.`gm_orders` (`id`) VALUES (1)": {"insert", "gm_orders"},
+		"UPDATE gm_users SET name = 'a'":                 {"update", "gm_users"},
+		"  delete from \"events\" where 1=1":             {"delete", "events"},
+		"SELECT 1":                                       {"select", "unknown"},
+	}
+	for sql, want := range cases {
+		operation, table := components.ParseSQLTable(sql)
+		if operation != want[0] || table != want[1] {
+			t.Errorf("%q: got %s/%s, want %s/%s", sql, operation, table, want[0], want[1])
+		}
+	}
+
+	var observed []string
+	gormLogger := components.NewGormLogger("mysql", &components.GormLoggerConfig{
+		Component:     "mysql/test",
+		LogLevel:      logger.Silent,
+		SlowThreshold: 10 * time.Millisecond,
+		SlowObserver: func(component, table, operation string, elapsed time.Duration) {
+			observed = append(observed, component+":"+table+":"+operation)
+		},
+	})
+	sql := func() (string, int64) { return "SELECT * FROM `gm_users`", 1 }
+	gormLogger.Trace(context.Background(), time.Now(), sql, nil)
+	gormLogger.Trace(context.Background(), time.Now().Add(-time.Second), sql, nil)
+	gormLogger.Trace(context.Background(), time.Now().Add(-time.Second), sql, errors.New("bad"))
+	if len(observed) != 2 || observed[0] != "mysql/test:gm_users:select" {
+		t.Fatalf("unexpected slow queries: %v", observed)
+	}
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotencyRedis 启动miniredis并返回Redis组件
+func newIdempotencyRedis(t *testing.T) *components.RedisComponent {
+	t.Helper()
+	server := miniredis.RunT(t)
+	redisComponent := components.NewRedisComponent(components.WithRedisAddr(server.Addr()))
+	if err := redisComponent.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redisComponent.Stop(context.Background()) })
+	return redisComponent
+}
+
+// idempotentRequest 发送携带幂等键的POST请求
+func idempotentRequest(engine *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// 测试幂等中间件: 相同请求重放保存的响应，相同key不同请求返回冲突，请求体超出上限返回4130
+// go test -v -run TestIdempotencyReplay ./tests/idempotency_test.go
+func TestIdempotencyReplay(t *testing.T) {
+	redisComponent := newIdempotencyRedis(t)
+	var calls atomic.Int32
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.IdempotencyMiddleware(redisComponent, middleware.WithIdempotencyMaxRequestSize(64)))
+	engine.POST("/orders", func(c *gin.Context) {
+		n := calls.Add(1)
+		c.Header("X-Order-Seq", strconv.Itoa(int(n)))
+		c.String(http.StatusCreated, "created-%d", n)
+	})
+
+	first := idempotentRequest(engine, "key-1", `{"amount":100}`)
+	if first.Code != http.StatusCreated || first.Body.String() != "created-1" {
+		t.Fatalf("first response = %d %q", first.Code, first.Body.String())
+	}
+
+	replayed := idempotentRequest(engine, "key-1", `{"amount":100}`)
+	if replayed.Code != http.StatusCreated || replayed.Body.String() != "created-1" {
+		t.Errorf("replayed response = %d %q", replayed.Code, replayed.Body.String())
+	}
+	if replayed.Header().Get("Idempotent-Replayed") != "true" || replayed.Header().Get("X-Order-Seq") != "1" {
+		t.Errorf("replayed headers = %v", replayed.Header())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler calls = %d, want 1", calls.Load())
+	}
+
+	// 相同key不同请求体
+	conflict := idempotentRequest(engine, "key-1", `{"amount":200}`)
+	if code := responseCode(t, conflict); conflict.Code != http.StatusConflict || code != 4090 {
+		t.Errorf("conflict response = %d/%d", conflict.Code, code)
+	}
+
+	// 请求体超出指纹计算上限
+	tooLarge := idempotentRequest(engine, "key-2", `{"remark":"`+strings.Repeat("x", 64)+`"}`)
+	if code := responseCode(t, tooLarge); tooLarge.Code != http.StatusRequestEntityTooLarge || code != 4130 {
+		t.Errorf("too large response = %d/%d", tooLarge.Code, code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler calls = %d, want 1", calls.Load())
+	}
+}
+
+// 测试幂等中间件: 相同请求处理中时返回4090和Retry-After，完成后重放
+// go test -v -run TestIdempotencyInFlight ./tests/idempotency_test.go
+func TestIdempotencyInFlight(t *testing.T) {
+	redisComponent := newIdempotencyRedis(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.IdempotencyMiddleware(redisComponent))
+	engine.POST("/orders", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- idempotentRequest(engine, "key-1", `{"amount":100}`) }()
+	<-started
+
+	processing := idempotentRequest(engine, "key-1", `{"amount":100}`)
+	if code := responseCode(t, processing); processing.Code != http.StatusConflict || code != 4090 {
+		t.Errorf("in-flight response = %d/%d", processing.Code, code)
+	}
+	if processing.Header().Get("Retry-After") == "" {
+		t.Error("in-flight response missing Retry-After")
+	}
+	// 处理中的key被不同请求使用
+	different := idempotentRequest(engine, "key-1", `{"amount":200}`)
+	if different.Code != http.StatusConflict || different.Header().Get("Retry-After") != "" {
+		t.Errorf("different request response = %d, Retry-After=%q", different.Code, different.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	if first := <-done; first.Code != http.StatusOK {
+		t.Fatalf("first response = %d", first.Code)
+	}
+	replayed := idempotentRequest(engine, "key-1", `{"amount":100}`)
+	if replayed.Body.String() != "done" || replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replayed response = %q %v", replayed.Body.String(), replayed.Header())
+	}
+}
+
+// 测试幂等中间件与压缩中间件组合: 重放的响应体与Content-Encoding一致，按本次请求的Accept-Encoding压缩
+// go test -v -run TestIdempotencyCompressReplay ./tests/idempotency_test.go
+func TestIdempotencyCompressReplay(t *testing.T) {
+	redisComponent := newIdempotencyRedis(t)
+	large := strings.Repeat("compressible text ", 200)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.CompressMiddleware(), middleware.IdempotencyMiddleware(redisComponent))
+	engine.POST("/orders", func(c *gin.Context) {
+		c.Header("ETag", `"order-1"`)
+		c.String(http.StatusCreated, large)
+	})
+
+	send := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"amount":100}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key-1")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, tc := range []struct {
+		acceptEncoding string
+		wantEncoding   string
+		wantETag       string
+	}{
+		{"gzip", middleware.EncodingGzip, `W/"order-1"`},
+		{"gzip", middleware.EncodingGzip, `W/"order-1"`}, // 重放
+		{"", "", `"order-1"`},                            // 重放，客户端不支持压缩
+	} {
+		w := send(tc.acceptEncoding)
+		if i > 0 && w.Header().Get("Idempotent-Replayed") != "true" {
+			t.Fatalf("request %d not replayed", i)
+		}
+		if encoding := w.Header().Get("Content-Encoding"); encoding != tc.wantEncoding {
+			t.Fatalf("request %d: Content-Encoding = %q, want %q", i, encoding, tc.wantEncoding)
+		}
+		if body := decompress(t, tc.wantEncoding, w.Body.Bytes()); body != large {
+			t.Errorf("request %d: body does not match Content-Encoding (%d bytes)", i, w.Body.Len())
+		}
+		if etag := w.Header().Get("ETag"); etag != tc.wantETag {
+			t.Errorf("request %d: ETag = %q, want %q", i, etag, tc.wantETag)
+		}
+		if vary := w.Header().Values("Vary"); tc.wantEncoding != "" && (len(vary) != 1 || vary[0] != "Accept-Encoding") {
+			t.Errorf("request %d: Vary = %v", i, vary)
+		}
+	}
+}
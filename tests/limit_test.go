@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+
+	"github.com/gin-gonic/gin"
+)
+
+// responseCode 解析响应中的业务码
+func responseCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	var resp response.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return resp.Code
+}
+
+// 测试请求超时: 按路由覆盖超时时间，处理器响应ctx取消后返回5040
+// go test -v -run TestTimeoutMiddleware ./tests/limit_test.go
+func TestTimeoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.RouteTimeoutMiddleware(20*time.Millisecond, middleware.RouteRules[time.Duration]{
+		"GET /export": time.Second,
+	}))
+	wait := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+			c.String(http.StatusOK, "done")
+		}
+	}
+	engine.GET("/query", wait)
+	engine.GET("/export", wait)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/query", nil))
+	if w.Code != http.StatusGatewayTimeout || responseCode(t, w) != enum.GATEWAY_TIMEOUT {
+		t.Errorf("timeout response = %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("route override response = %d %s", w.Code, w.Body.String())
+	}
+}
+
+// 测试请求体大小限制: Content-Length超出直接拒绝，未声明长度时读取超出返回4130
+// go test -v -run TestBodyLimitMiddleware ./tests/limit_test.go
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.BodyLimitMiddleware(8))
+	called := 0
+	engine.POST("/upload", func(c *gin.Context) {
+		called++
+		if _, err := c.GetRawData(); err != nil {
+			if middleware.IsBodyTooLarge(err) {
+				response.Abort(c, enum.REQUEST_ENTITY_TOO_LARGE)
+				return
+			}
+			t.Error(err)
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("0123456789")))
+	if w.Code != http.StatusRequestEntityTooLarge || responseCode(t, w) != enum.REQUEST_ENTITY_TOO_LARGE || called != 0 {
+		t.Errorf("content-length response = %d %s, handler called %d", w.Code, w.Body.String(), called)
+	}
+
+	// chunked请求，读取时超出
+	req := httptest.NewRequest(http.MethodPost, "/upload", io.MultiReader(strings.NewReader("01234"), strings.NewReader("56789")))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || responseCode(t, w) != enum.REQUEST_ENTITY_TOO_LARGE || called != 1 {
+		t.Errorf("chunked response = %d %s, handler called %d", w.Code, w.Body.String(), called)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("01234")))
+	if w.Code != http.StatusOK {
+		t.Errorf("small body response = %d %s", w.Code, w.Body.String())
+	}
+}
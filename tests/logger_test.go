@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试日志级别解析、模块级别和热加载
+// go test -v -run TestLoggerLevels ./tests/logger_test.go
+func TestLoggerLevels(t *testing.T) {
+	if _, err := logger.ParseLevel("production"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+	if err := logger.NewLoggerComponent(logger.WithLoggerLevel("production"), logger.WithLoggerIsFile(false)).Start(context.Background()); err == nil {
+		t.Fatal("expected start error for unknown level")
+	}
+
+	l := logger.NewLoggerComponent(
+		logger.WithLoggerLevel("info"),
+		logger.WithLoggerIsFile(false),
+		logger.WithLoggerModuleLevels(map[string]string{"redis": "error"}),
+	)
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mysql := logger.Named("mysql")
+	redis := logger.Named("redis")
+	if mysql.Core().Enabled(zapcore.DebugLevel) || !mysql.Core().Enabled(zapcore.InfoLevel) {
+		t.Fatal("module without level should follow global level")
+	}
+	if redis.Core().Enabled(zapcore.WarnLevel) {
+		t.Fatal("redis module should only log errors")
+	}
+
+	if err := logger.SetModuleLevel("mysql", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !mysql.Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("mysql module level should be debug")
+	}
+	if logger.Level().Enabled(zapcore.DebugLevel) {
+		t.Fatal("global level should stay info")
+	}
+
+	// 热加载: 未配置的模块恢复跟随全局级别
+	if err := logger.ApplyLevels("warn", nil); err != nil {
+		t.Fatal(err)
+	}
+	if mysql.Core().Enabled(zapcore.InfoLevel) || !redis.Core().Enabled(zapcore.WarnLevel) {
+		t.Fatal("modules should follow global level after reload")
+	}
+	if err := logger.ApplyLevels("debug", map[string]string{"mysql": "verbose"}); err == nil {
+		t.Fatal("expected error for unknown module level")
+	}
+	if logger.Level().Level() != zapcore.WarnLevel {
+		t.Fatal("invalid reload should not change levels")
+	}
+}
+
+// 测试启动前的后备输出和缓冲写入
+// go test -v -run TestLoggerComponent ./tests/logger_test.go
+func TestLoggerComponent(t *testing.T) {
+	// 启动前不会panic
+	logger.Debug("before start")
+
+	filename := t.TempDir() + "/logs/app.log"
+	l := logger.NewLoggerComponent(
+		logger.WithLoggerStdout(false),
+		logger.WithLoggerFilename(filename),
+		logger.WithLoggerBuffer(1<<20, time.Hour),
+	)
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		data, _ := os.ReadFile(filename)
+		return string(data)
+	}
+
+	logger.Info("buffered")
+	if strings.Contains(read(), "buffered") {
+		t.Fatal("info should be buffered")
+	}
+	logger.Error("flush now")
+	if !strings.Contains(read(), "flush now") {
+		t.Fatal("error should be flushed immediately")
+	}
+	logger.Info("tail")
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(read(), "tail") {
+		t.Fatal("buffer should be flushed on stop")
+	}
+	if l.GetLogger() == nil {
+		t.Fatal("fallback logger expected after stop")
+	}
+}
+
+// 测试采样、相同错误去重和按调用位置限流
+// go test -v -run TestLoggerSampling ./tests/logger_test.go
+func TestLoggerSampling(t *testing.T) {
+	filename := t.TempDir() + "/logs/app.log"
+	l := logger.NewLoggerComponent(
+		logger.WithLoggerStdout(false),
+		logger.WithLoggerFilename(filename),
+		logger.WithLoggerSampling(time.Hour, map[string]logger.SamplingRule{"info": {First: 2}}),
+		logger.WithLoggerDedup(time.Hour, "error"),
+		logger.WithLoggerCallerLimit(3, time.Hour, "warn"),
+	)
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		logger.Info("sampled")
+		logger.Warn(fmt.Sprintf("limited %d", i))
+		logger.Error("database unavailable")
+	}
+	// 汇总在窗口结束或停止时输出
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filename)
+	output := string(data)
+
+	if n := strings.Count(output, `"msg":"sampled"`); n != 2 {
+		t.Errorf("sampled logs = %d, want 2", n)
+	}
+	if n := strings.Count(output, `"msg":"database unavailable"`); n != 1 {
+		t.Errorf("deduplicated logs = %d, want 1", n)
+	}
+	if !strings.Contains(output, `database unavailable (repeated 9 times)`) {
+		t.Error("missing dedup summary")
+	}
+	if !strings.Contains(output, `"msg":"limited 2"`) || strings.Contains(output, `"msg":"limited 3"`) {
+		t.Errorf("unexpected rate limit output:\n%s", output)
+	}
+	if !strings.Contains(output, "rate limited: dropped") {
+		t.Error("missing rate limit summary")
+	}
+
+	if err := logger.NewLoggerComponent(logger.WithLoggerIsFile(false), logger.WithLoggerDedup(time.Second, "verbose")).Start(context.Background()); err == nil {
+		t.Error("expected error for unknown dedup level")
+	}
+}
+
+// 测试模块记录器跟随当前输出: 启动后写入文件，停止后输出到标准错误且不会重新创建文件
+// go test -v -run TestLoggerNamedAfterStop ./tests/logger_test.go
+func TestLoggerNamedAfterStop(t *testing.T) {
+	filename := t.TempDir() + "/logs/app.log"
+	before := logger.Named("order").With(zap.String("stage", "before"))
+	l := logger.NewLoggerComponent(logger.WithLoggerStdout(false), logger.WithLoggerFilename(filename))
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	after := logger.Named("order").With(zap.String("stage", "after"))
+	before.Info("created before start")
+	after.Info("created after start")
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filename)
+	for _, want := range []string{`"msg":"created before start","stage":"before"`, `"msg":"created after start","stage":"after"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("missing %s in:\n%s", want, data)
+		}
+	}
+
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	after.Info("after stop")
+	_ = after.Sync()
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("log file reopened after stop: %v", err)
+	}
+}
+
+// 测试停止时仍持有旧记录器的写入不会重新打开日志文件
+// go test -v -run TestLoggerStopInFlight ./tests/logger_test.go
+func TestLoggerStopInFlight(t *testing.T) {
+	filename := t.TempDir() + "/logs/app.log"
+	l := logger.NewLoggerComponent(logger.WithLoggerStdout(false), logger.WithLoggerFilename(filename))
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	inFlight := logger.L()
+	inFlight.Info("before stop")
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logger.L() == inFlight {
+		t.Fatal("global logger not replaced after stop")
+	}
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	inFlight.Info("after stop")
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("log file reopened by in-flight logger: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/content"
+	"github.com/boloc/go-frame-server/pkg/frame/middleware"
+	"github.com/boloc/go-frame-server/pkg/util/mask"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试脱敏规则、结构体标签和请求记录脱敏
+// go test -v -run TestMask ./tests/mask_test.go
+func TestMask(t *testing.T) {
+	m := mask.New()
+
+	cases := map[string]string{
+		"call 13812345678 now":         "call 138****5678 now",
+		"mail alice@example.com":       "mail a****@example.com",
+		"id 110101199003074514":        "id 110101********4514",
+		"card 6222 0212 3456 7890 128": "card 6222***********0128",
+		"order 1234567890123":          "order 1234567890123", // 未通过Luhn校验，不是卡号
+	}
+	for input, want := range cases {
+		if got := m.String(input); got != want {
+			t.Errorf("String(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	data := m.JSON([]byte(`{"user_id":9007199254740993,"Password":"p","profile":{"phone":"13812345678","accessToken":"t"}}`))
+	if string(data) != `{"Password":"******","profile":{"accessToken":"******","phone":"138****5678"},"user_id":9007199254740993}` {
+		t.Errorf("unexpected json: %s", data)
+	}
+
+	type account struct {
+		Name   string `json:"name"`
+		Secret string `json:"key" log:"mask"`
+		Inner  string `log:"-"`
+	}
+	masked, _ := json.Marshal(m.Any(&account{Name: "bob", Secret: "s", Inner: "x"}))
+	if string(masked) != `{"key":"******","name":"bob"}` {
+		t.Errorf("unexpected struct: %s", masked)
+	}
+	if got := m.URL("/login?token=abc&name=bob"); got != "/login?name=bob&token=******" {
+		t.Errorf("unexpected url: %s", got)
+	}
+
+	// 请求记录脱敏
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.ContextMiddleware())
+	var rc *content.RequestContext
+	engine.POST("/login", func(c *gin.Context) {
+		rc = content.FromGin(c)
+		c.Status(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/login?sign=1&access_token=x", strings.NewReader(`{"username":"bob","password":"123456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if rc == nil || rc.RequestID == "" || w.Header().Get(content.RequestIDHeader) != rc.RequestID {
+		t.Fatal("request id should be generated")
+	}
+	captured, err := json.Marshal(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(captured), "123456") || strings.Contains(string(captured), `"x"`) {
+		t.Errorf("sensitive data captured: %s", captured)
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// 测试组件指标: 启动后自动加入连接池指标和命令耗时，停止后移除；多个实例按名称区分
+// go test -v -run TestComponentMetrics ./tests/metrics_test.go
+func TestComponentMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if err := components.SetMetricsRegisterer(registry); err != nil {
+		t.Fatal(err)
+	}
+	defer components.SetMetricsRegisterer(prometheus.DefaultRegisterer)
+
+	ctx := context.Background()
+	redisComponent := components.NewRedisComponent(components.WithRedisAddr(miniredis.RunT(t).Addr()), components.WithRedisName("session"))
+	if err := redisComponent.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cacheComponent := components.NewRedisComponent(components.WithRedisAddr(miniredis.RunT(t).Addr()), components.WithRedisName("cache"))
+	if err := cacheComponent.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer cacheComponent.Stop(ctx)
+	if redisComponent.Name() != "redis/session" || cacheComponent.Name() != "redis/cache" {
+		t.Fatalf("names = %s, %s", redisComponent.Name(), cacheComponent.Name())
+	}
+
+	// 命令耗时为全局指标，按差值判断
+	gather := func(component string) map[string]float64 {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := make(map[string]float64)
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				labels := make(map[string]string)
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["component"] != component {
+					continue
+				}
+				switch {
+				case metric.GetGauge() != nil:
+					values[family.GetName()] = metric.GetGauge().GetValue()
+				case metric.GetHistogram() != nil:
+					values[family.GetName()+"/"+labels["command"]] = float64(metric.GetHistogram().GetSampleCount())
+				}
+			}
+		}
+		return values
+	}
+	before := gather(redisComponent.Name())
+	client := redisComponent.GetClient()
+	if err := client.Set(ctx, "k", "v", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	pipe := client.Pipeline()
+	pipe.Get(ctx, "k")
+	pipe.Get(ctx, "k")
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	values := gather(redisComponent.Name())
+	if values["redis_pool_total_connections"] < 1 {
+		t.Errorf("redis_pool_total_connections = %v", values["redis_pool_total_connections"])
+	}
+	set := values["redis_command_duration_seconds/set"] - before["redis_command_duration_seconds/set"]
+	pipeline := values["redis_command_duration_seconds/pipeline"] - before["redis_command_duration_seconds/pipeline"]
+	if set != 1 || pipeline != 1 {
+		t.Errorf("redis_command_duration_seconds = %v", values)
+	}
+	if cache := gather(cacheComponent.Name()); cache["redis_pool_total_connections"] < 1 || cache["redis_command_duration_seconds/set"] != 0 {
+		t.Errorf("cache metrics = %v", cache)
+	}
+
+	// 停止后不再输出该实例的连接池指标
+	if err := redisComponent.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gather(redisComponent.Name())["redis_pool_total_connections"]; ok {
+		t.Error("pool metrics of stopped instance still collected")
+	}
+	if _, ok := gather(cacheComponent.Name())["redis_pool_total_connections"]; !ok {
+		t.Error("pool metrics of running instance removed")
+	}
+	if err := cacheComponent.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := testutil.GatherAndCount(registry, "redis_pool_total_connections"); err != nil || count != 0 {
+		t.Errorf("pool metrics after stop = %d, %v", count, err)
+	}
+}
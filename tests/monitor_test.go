@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/monitor"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// 测试指标组件: 独立Registry、命名空间前缀、固定标签和运行时指标
+// go test -v -run TestMetricsComponent ./tests/monitor_test.go
+func TestMetricsComponent(t *testing.T) {
+	component := monitor.NewMetricsComponent(
+		monitor.WithMetricsNamespace("order-service"),
+		monitor.WithMetricsService("order", "test"),
+		monitor.WithMetricsInterval(time.Hour),
+	)
+	if err := component.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer component.Stop(context.Background())
+	monitor.ObserveCompression("gzip", 1000, 200)
+
+	server := httptest.NewServer(component.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(body)
+
+	for _, want := range []string{
+		`order_service_resource_goroutine_count{env="test",service="order"}`,
+		`order_service_http_response_compression_bytes_total{encoding="gzip",env="test",service="order",stage="compressed"} 200`,
+		`go_goroutines{env="test",service="order"}`,
+		`process_start_time_seconds{env="test",service="order"}`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+	if strings.Contains(output, "\nresource_goroutine_count") {
+		t.Error("service metrics should have namespace prefix")
+	}
+
+	// 停止后可以重新启动
+	if err := component.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := component.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 测试指标推送: Pushgateway分组路径、重试和4xx不重试，remote write编码和4xx不重试
+// go test -v -run TestMetricsPush ./tests/monitor_test.go
+func TestMetricsPush(t *testing.T) {
+	var (
+		attempts atomic.Int32
+		path     string
+		body     string
+	)
+	pushgateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		path, body = r.Method+" "+r.URL.Path, string(data)
+	}))
+	defer pushgateway.Close()
+
+	component := monitor.NewMetricsComponent(
+		monitor.WithMetricsNamespace("batch"),
+		monitor.WithMetricsService("report", "test"),
+		monitor.WithMetricsRuntimeCollectors(false),
+		monitor.WithMetricsPush(monitor.PushConfig{
+			URL:       pushgateway.URL,
+			Grouping:  map[string]string{"instance": "job-01"},
+			Retries:   2,
+			RetryWait: 10 * time.Millisecond,
+		}),
+	)
+	if err := component.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer component.Stop(context.Background())
+	if err := component.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("attempts = %d, want 2", attempts.Load())
+	}
+	if path != "PUT /metrics/job/report/instance/job-01" {
+		t.Errorf("push path = %q", path)
+	}
+	if !strings.Contains(body, "batch_resource_goroutine_count") {
+		t.Errorf("push body missing metrics: %q", body)
+	}
+
+	// Pushgateway返回4xx不重试
+	attempts.Store(0)
+	pushgateway.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	if err := component.Push(context.Background()); err == nil || attempts.Load() != 1 {
+		t.Errorf("push err = %v, attempts = %d, want error without retry", err, attempts.Load())
+	}
+
+	// remote write: snappy压缩的protobuf，附加job和分组标签
+	var (
+		writes  atomic.Int32
+		payload []byte
+	)
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writes.Add(1)
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("X-Scope-OrgID") != "tenant" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		payload, _ = s2.Decode(nil, data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer remote.Close()
+
+	writer := monitor.NewMetricsComponent(
+		monitor.WithMetricsNamespace("batch"),
+		monitor.WithMetricsRuntimeCollectors(false),
+		monitor.WithMetricsPush(monitor.PushConfig{
+			Mode:      monitor.PushModeRemoteWrite,
+			URL:       remote.URL,
+			Job:       "report",
+			Retries:   2,
+			RetryWait: 10 * time.Millisecond,
+			Headers:   map[string]string{"X-Scope-OrgID": "tenant"},
+		}),
+	)
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Stop(context.Background())
+	monitor.ObserveCompression("gzip", 1000, 200)
+	if err := writer.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// WriteRequest只包含timeseries(字段1)
+	for data := payload; len(data) > 0; {
+		number, typ, n := protowire.ConsumeTag(data)
+		if n < 0 || number != 1 || typ != protowire.BytesType {
+			t.Fatalf("invalid write request field %d/%d", number, typ)
+		}
+		data = data[n:]
+		if n = protowire.ConsumeFieldValue(number, typ, data); n < 0 {
+			t.Fatal("invalid timeseries")
+		}
+		data = data[n:]
+	}
+	for _, want := range []string{"__name__", "batch_resource_goroutine_count", "batch_http_response_compression_ratio_bucket", "+Inf", "report"} {
+		if !bytes.Contains(payload, []byte(want)) {
+			t.Errorf("remote write payload missing %s", want)
+		}
+	}
+
+	// 4xx不重试
+	writes.Store(0)
+	remote.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writes.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	if err := writer.Push(context.Background()); err == nil || writes.Load() != 1 {
+		t.Errorf("push err = %v, writes = %d, want error without retry", err, writes.Load())
+	}
+}
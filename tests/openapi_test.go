@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/api"
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+	"github.com/boloc/go-frame-server/pkg/throw"
+	"github.com/boloc/go-frame-server/pkg/throw/enum"
+)
+
+type GetOrderRequest struct {
+	ID        int64  `uri:"id" binding:"required,min=1"`
+	Remark    string `json:"remark" binding:"max=64"`
+	Notify    bool   `form:"notify"`
+	RequestID string `header:"X-Request-ID" binding:"required"`
+}
+
+type Order struct {
+	ID     int64  `json:"id"`
+	Remark string `json:"remark"`
+}
+
+// 测试OpenAPI文档生成: 参数位置、请求体、公共结构和失败响应
+// go test -v -run TestOpenAPISpec ./tests/openapi_test.go
+func TestOpenAPISpec(t *testing.T) {
+	spec := api.NewSpec()
+	spec.AddOperation(http.MethodPut, "/v1/orders/:id", reflect.TypeOf(GetOrderRequest{}), reflect.TypeOf(&Order{}),
+		api.WithSummary("更新订单"), api.WithErrorCodes(enum.NOT_FOUND))
+	doc := spec.Document()
+
+	op := doc.Paths["/v1/orders/{id}"]["put"]
+	if op == nil {
+		t.Fatal("operation not found in spec")
+	}
+	if op.Summary != "更新订单" || op.Responses["404"] == nil || op.Responses["200"] == nil {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+
+	in := make(map[string]string)
+	for _, p := range op.Parameters {
+		in[p.Name] = p.In
+	}
+	if in["id"] != "path" || in["notify"] != "query" || in["X-Request-ID"] != "header" {
+		t.Fatalf("unexpected parameters %+v", in)
+	}
+	if op.RequestBody == nil || op.RequestBody.Content["application/json"].Schema.Properties["remark"] == nil {
+		t.Fatal("request body schema missing")
+	}
+	for _, name := range []string{"PageRequest", "PageResponse", "ErrorResponse", "Order"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Fatalf("schema %s missing", name)
+		}
+	}
+
+	// 400响应的业务码与校验失败实际返回的一致
+	code, _ := response.ErrorCode(context.Background(), throw.ValidationException(errors.New("invalid")))
+	if desc := op.Responses["400"].Description; !strings.HasPrefix(desc, fmt.Sprintf("%d ", code)) {
+		t.Errorf("400 description = %q, want validation code %d", desc, code)
+	}
+}
+
+// 测试生成文档与注册并发: 文档是快照，之后注册的接口不影响已生成的文档
+// go test -v -race -run TestOpenAPISpecConcurrent ./tests/openapi_test.go
+func TestOpenAPISpecConcurrent(t *testing.T) {
+	spec := api.NewSpec()
+	spec.AddOperation(http.MethodGet, "/v1/orders/:id", reflect.TypeOf(GetOrderRequest{}), reflect.TypeOf(&Order{}))
+	snapshot := spec.Document()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			spec.AddOperation(http.MethodGet, fmt.Sprintf("/v1/orders/:id/items/%d", i), reflect.TypeOf(GetOrderRequest{}), nil)
+		}(i)
+		go func() {
+			defer wg.Done()
+			for range spec.Document().Paths {
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(snapshot.Paths) != 1 {
+		t.Errorf("snapshot paths = %d, want 1", len(snapshot.Paths))
+	}
+	if paths := len(spec.Document().Paths); paths != 9 {
+		t.Errorf("paths = %d, want 9", paths)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/realip"
+)
+
+// 测试客户端真实IP解析
+// go test -v -run TestRealIPResolver  ./tests/realip_test.go
+func TestRealIPResolver(t *testing.T) {
+	resolver, err := realip.NewResolver([]string{"10.0.0.0/8", "127.0.0.1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"非受信任代理忽略请求头", "203.0.113.9:5000", map[string]string{"X-Forwarded-For": "1.1.1.1"}, "203.0.113.9"},
+		{"X-Forwarded-For从右往左跳过受信任代理", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "6.6.6.6, 1.1.1.1, 10.0.0.5"}, "1.1.1.1"},
+		{"受信任代理后默认忽略X-Real-IP", "127.0.0.1:5000", map[string]string{"X-Real-IP": "2.2.2.2"}, "127.0.0.1"},
+		{"受信任代理后忽略客户端伪造的CF-Connecting-IP", "10.1.1.1:5000", map[string]string{"CF-Connecting-IP": "3.3.3.3", "X-Forwarded-For": "4.4.4.4"}, "4.4.4.4"},
+		{"受信任代理后忽略客户端伪造的Forwarded", "10.1.1.1:5000", map[string]string{"Forwarded": "for=5.5.5.5", "X-Forwarded-For": "4.4.4.4"}, "4.4.4.4"},
+		{"unix socket默认不信任请求头", "@", map[string]string{"X-Forwarded-For": "4.4.4.4"}, ""},
+		{"非法请求头回退直连地址", "10.1.1.1:5000", map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.1.1.1"},
+		{"全部为受信任代理取最左侧", "10.1.1.1:5000", map[string]string{"X-Forwarded-For": "10.2.2.2, 10.3.3.3"}, "10.2.2.2"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remote
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		if got := resolver.ClientIP(req); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// 测试显式开启的请求头和unix socket
+// go test -v -run TestRealIPOptIn  ./tests/realip_test.go
+func TestRealIPOptIn(t *testing.T) {
+	resolver, err := realip.NewResolver([]string{"10.0.0.0/8"},
+		[]string{realip.HeaderCFConnectingIP, realip.HeaderForwarded, realip.HeaderXForwardedFor},
+		realip.WithTrustUnixSocket(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"CF-Connecting-IP优先", "10.1.1.1:5000", map[string]string{"CF-Connecting-IP": "3.3.3.3", "X-Forwarded-For": "4.4.4.4"}, "3.3.3.3"},
+		{"Forwarded", "10.1.1.1:5000", map[string]string{"Forwarded": `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"非受信任代理仍忽略请求头", "203.0.113.9:5000", map[string]string{"CF-Connecting-IP": "3.3.3.3"}, "203.0.113.9"},
+		{"信任unix socket", "@", map[string]string{"X-Forwarded-For": "4.4.4.4"}, "4.4.4.4"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remote
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		if got := resolver.ClientIP(req); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// 测试非法的受信任代理配置
+// go test -v -run TestRealIPInvalidProxy  ./tests/realip_test.go
+func TestRealIPInvalidProxy(t *testing.T) {
+	if _, err := realip.NewResolver([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Fatal("expected error for invalid cidr")
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试业务码对应的HTTP状态码: 只有4000-5999按业务码推导，失败响应不使用1xx/2xx/3xx
+// go test -v -run TestResponseHTTPStatus ./tests/response_test.go
+func TestResponseHTTPStatus(t *testing.T) {
+	cases := []struct {
+		code int
+		want int
+	}{
+		{0, http.StatusOK},
+		{4001, http.StatusBadRequest},
+		{4130, http.StatusRequestEntityTooLarge},
+		{4290, http.StatusTooManyRequests},
+		{4990, http.StatusBadRequest}, // 非标准4xx
+		{5040, http.StatusGatewayTimeout},
+		{5990, http.StatusInternalServerError}, // 非标准5xx
+		{1001, http.StatusBadRequest},
+		{2040, http.StatusBadRequest},
+		{3010, http.StatusBadRequest},
+		{3040, http.StatusBadRequest},
+		{6000, http.StatusInternalServerError},
+		{-1, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		if got := response.HTTPStatus(tc.code); got != tc.want {
+			t.Errorf("HTTPStatus(%d) = %d, want %d", tc.code, got, tc.want)
+		}
+	}
+}
+
+// 测试自定义业务码的失败响应保留响应体
+// go test -v -run TestResponseFailCustomCode ./tests/response_test.go
+func TestResponseFailCustomCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, code := range []int{1001, 2040, 3010, 3040} {
+		engine := gin.New()
+		engine.GET("/fail", func(c *gin.Context) { response.Fail(c, code, "custom") })
+		engine.GET("/abort", func(c *gin.Context) { response.Abort(c, code, "custom") })
+		for _, path := range []string{"/fail", "/abort"} {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			var body response.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Errorf("%s %d: invalid body %q", path, code, w.Body.String())
+				continue
+			}
+			if w.Code != http.StatusBadRequest || body.Code != code || body.Message != "custom" {
+				t.Errorf("%s %d: status %d, body %+v", path, code, w.Code, body)
+			}
+			if w.Header().Get("Location") != "" {
+				t.Errorf("%s %d: unexpected Location", path, code)
+			}
+		}
+	}
+}
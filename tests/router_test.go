@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/router"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试路由模块挂载: 版本前缀、路由信息和重复路由检测
+// go test -v -run TestRouterMount ./tests/router_test.go
+func TestRouterMount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	orders := router.NewModule("orders", "/orders", func(group *gin.RouterGroup) {
+		group.GET("/:id", func(c *gin.Context) {})
+	}, router.WithVersion("v1"))
+	routes, err := router.Mount(engine, orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Path != "/v1/orders/:id" || routes[0].Module != "orders" || routes[0].Version != "v1" {
+		t.Errorf("routes = %+v", routes)
+	}
+
+	// 与已有路由重复时返回错误，不panic
+	duplicate := router.NewModule("orders-copy", "/orders", func(group *gin.RouterGroup) {
+		group.GET("/:id", func(c *gin.Context) {})
+	}, router.WithVersion("v1"))
+	if _, err := router.Mount(engine, duplicate); err == nil || !strings.Contains(err.Error(), "orders-copy") {
+		t.Errorf("duplicate route error = %v", err)
+	}
+	if err := router.Safe("direct", func() { engine.GET("/v1/orders/:id", func(c *gin.Context) {}) }); err == nil {
+		t.Error("Safe should convert duplicate route panic to error")
+	}
+	if err := router.Safe("ok", func() { engine.GET("/ping", func(c *gin.Context) {}) }); err != nil {
+		t.Error(err)
+	}
+}
+
+// 测试基于请求头的API版本路由
+// go test -v -run TestVersionHeaderHandler ./tests/router_test.go
+func TestVersionHeaderHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	modules := []router.RouteModule{}
+	for _, version := range []string{"v1", "v2"} {
+		version := version
+		modules = append(modules, router.NewModule("orders", "/orders", func(group *gin.RouterGroup) {
+			group.GET("/:id", func(c *gin.Context) { c.String(http.StatusOK, version+":"+c.Param("id")) })
+		}, router.WithVersion(version)))
+	}
+	engine.GET("/other", func(c *gin.Context) { c.String(http.StatusOK, "other") })
+	if _, err := router.Mount(engine, modules...); err != nil {
+		t.Fatal(err)
+	}
+	handler := router.VersionHeaderHandler(engine, "X-API-Version", "v1", modules)
+
+	cases := []struct {
+		name    string
+		path    string
+		version string
+		code    int
+		body    string
+	}{
+		{"请求头指定版本", "/orders/1", "v2", http.StatusOK, "v2:1"},
+		{"未携带使用默认版本", "/orders/1", "", http.StatusOK, "v1:1"},
+		{"路径已带版本时忽略请求头", "/v1/orders/1", "v2", http.StatusOK, "v1:1"},
+		{"非版本模块路径不改写", "/other", "v2", http.StatusOK, "other"},
+		{"未注册的版本不改写", "/orders/1", "../other", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.version != "" {
+			req.Header.Set("X-API-Version", tc.version)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tc.code || (tc.body != "" && w.Body.String() != tc.body) {
+			t.Errorf("%s: got %d %q, want %d %q", tc.name, w.Code, w.Body.String(), tc.code, tc.body)
+		}
+	}
+}
+
+// 测试路由注册失败后重新启动: 已注册的路由和已挂载的模块不重复注册
+// go test -v -run TestRouterRegisterRetry ./tests/router_test.go
+func TestRouterRegisterRetry(t *testing.T) {
+	attempts := 0
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.FullPath()) }
+	address := freeAddress(t)
+	ginComponent := components.NewGinComponent(
+		components.WithGinMode(gin.TestMode),
+		components.WithGinPrintRoutes(false),
+		components.WithGinListeners(components.GinListener{Address: address}),
+		components.WithGinRouter(func(engine *gin.Engine) { engine.GET("/ping", ok) }),
+		components.WithGinRouteModules(
+			router.NewModule("orders", "/orders", func(group *gin.RouterGroup) { group.GET("", ok) }),
+			router.NewModule("users", "/users", func(group *gin.RouterGroup) {
+				if attempts++; attempts == 1 {
+					panic("dependency not ready")
+				}
+				group.GET("", ok)
+			}),
+		),
+	)
+	ctx := context.Background()
+	if err := ginComponent.Start(ctx); err == nil || !strings.Contains(err.Error(), "dependency not ready") {
+		t.Fatalf("first start err = %v", err)
+	}
+	if err := ginComponent.Start(ctx); err != nil {
+		t.Fatalf("retry start err = %v", err)
+	}
+	defer ginComponent.Stop(ctx)
+
+	for _, path := range []string{"/ping", "/orders", "/users"} {
+		resp, err := http.Get("http://" + address + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s = %d", path, resp.StatusCode)
+		}
+	}
+}
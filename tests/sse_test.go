@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+	"github.com/boloc/go-frame-server/pkg/frame/sse"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// 测试SSE事件推送
+// go test -v -run TestSSEStream  ./tests/sse_test.go
+func TestSSEStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/events", func(c *gin.Context) {
+		events := make(chan sse.Event, 2)
+		events <- sse.Event{ID: "1", Event: "report", Data: map[string]int{"count": 3}}
+		events <- sse.Event{Data: "line1\nline2"}
+		close(events)
+		if err := sse.Stream(c, events, sse.WithKeepAlive(0)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+	want := "id: 1\nevent: report\ndata: {\"count\":3}\n\ndata: line1\ndata: line2\n\n"
+	if got := w.Body.String(); got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+// 测试SSE事件ID和事件类型包含换行符时拒绝写入
+// go test -v -run TestSSEEncodeInvalid ./tests/sse_test.go
+func TestSSEEncodeInvalid(t *testing.T) {
+	for _, event := range []sse.Event{
+		{ID: "1\ndata: injected", Data: "x"},
+		{Event: "report\r\nid: 999", Data: "x"},
+	} {
+		var b strings.Builder
+		if err := sse.Encode(&b, event); !errors.Is(err, sse.ErrInvalidField) {
+			t.Errorf("encode %+v: err = %v, want ErrInvalidField", event, err)
+		}
+		if b.Len() != 0 {
+			t.Errorf("encode %+v wrote %q", event, b.String())
+		}
+	}
+}
+
+// 测试SSE断线续传: 携带Last-Event-ID重连时补发缓冲中之后的事件，channel中已补发的事件不重复推送
+// go test -v -run TestSSEResume ./tests/sse_test.go
+func TestSSEResume(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	buffer := sse.NewRedisBuffer(client, "test:sse", 100, time.Minute)
+
+	ctx := context.Background()
+	var appended []sse.Event
+	for i := 1; i <= 3; i++ {
+		event, err := buffer.Append(ctx, sse.Event{Event: "report", Data: fmt.Sprintf("event-%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		appended = append(appended, event)
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/events", func(c *gin.Context) {
+		events := make(chan sse.Event, 2)
+		events <- appended[2] // 已补发，跳过
+		events <- sse.Event{ID: "9999999999999-0", Data: "live"}
+		close(events)
+		if err := sse.Stream(c, events, sse.WithKeepAlive(0), sse.WithResume(buffer)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", appended[0].ID)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	want := fmt.Sprintf("id: %s\nevent: report\ndata: event-2\n\nid: %s\nevent: report\ndata: event-3\n\nid: 9999999999999-0\ndata: live\n\n",
+		appended[1].ID, appended[2].ID)
+	if got := w.Body.String(); got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+// 测试Gin组件停止时通知本组件的SSE流结束，其他组件的SSE流不受影响
+// go test -v -run TestSSEShutdown ./tests/sse_test.go
+func TestSSEShutdown(t *testing.T) {
+	newServer := func(result chan<- error) (*components.GinComponent, string) {
+		address := freeAddress(t)
+		ginComponent := components.NewGinComponent(
+			components.WithGinMode(gin.TestMode),
+			components.WithGinPrintRoutes(false),
+			components.WithGinShutdownTimeout(2*time.Second),
+			components.WithGinListeners(components.GinListener{Address: address}),
+			components.WithGinRouter(func(engine *gin.Engine) {
+				engine.GET("/events", func(c *gin.Context) {
+					result <- sse.Stream(c, make(chan sse.Event), sse.WithKeepAlive(0), sse.WithRetry(time.Second))
+				})
+			}),
+		)
+		if err := ginComponent.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return ginComponent, address
+	}
+	connect := func(address string) io.ReadCloser {
+		resp, err := http.Get("http://" + address + "/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 读取重连间隔，确认流已建立
+		line := make([]byte, len("retry: 1000\n\n"))
+		if _, err := io.ReadFull(resp.Body, line); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Body
+	}
+
+	resultA, resultB := make(chan error, 1), make(chan error, 1)
+	componentA, addressA := newServer(resultA)
+	componentB, addressB := newServer(resultB)
+	bodyA, bodyB := connect(addressA), connect(addressB)
+	defer bodyA.Close()
+	defer bodyB.Close()
+
+	begin := time.Now()
+	if err := componentA.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("stop took %s, sse stream not drained", elapsed)
+	}
+	if err := <-resultA; !errors.Is(err, sse.ErrShutdown) {
+		t.Errorf("stream A err = %v, want ErrShutdown", err)
+	}
+	if _, err := io.ReadAll(bodyA); err != nil {
+		t.Errorf("stream A body: %v", err)
+	}
+	select {
+	case err := <-resultB:
+		t.Errorf("stream B ended by other component: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := componentB.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-resultB; !errors.Is(err, sse.ErrShutdown) {
+		t.Errorf("stream B err = %v, want ErrShutdown", err)
+	}
+}
+
+// 测试Hub停止后新建的流被拒绝，Reset后重新接受
+// go test -v -run TestSSEHubRejectAfterShutdown ./tests/sse_test.go
+func TestSSEHubRejectAfterShutdown(t *testing.T) {
+	hub := sse.NewHub()
+	result := make(chan error, 1)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sse.Middleware(hub))
+	engine.GET("/events", func(c *gin.Context) {
+		events := make(chan sse.Event)
+		close(events)
+		result <- sse.Stream(c, events, sse.WithKeepAlive(0))
+	})
+	stream := func() (int, error) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+		select {
+		case err := <-result:
+			return w.Code, err
+		case <-time.After(2 * time.Second):
+			t.Fatal("stream not finished")
+			return 0, nil
+		}
+	}
+
+	if err := hub.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if code, err := stream(); code != http.StatusServiceUnavailable || !errors.Is(err, sse.ErrShutdown) {
+		t.Errorf("stream after shutdown = %d, %v, want 503 ErrShutdown", code, err)
+	}
+	if hub.Active() != 0 {
+		t.Errorf("active = %d after rejected stream", hub.Active())
+	}
+
+	hub.Reset()
+	if code, err := stream(); code != http.StatusOK || err != nil {
+		t.Errorf("stream after reset = %d, %v", code, err)
+	}
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boloc/go-frame-server/pkg/frame/tracing"
+	"github.com/boloc/go-frame-server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+)
+
+// 测试链路追踪: 继续上游traceparent、外部请求注入、日志trace_id和文件导出
+// go test -v -run TestTracing ./tests/tracing_test.go
+func TestTracing(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+	file := filepath.Join(t.TempDir(), "traces.json")
+	component := tracing.NewTracingComponent(
+		tracing.WithTracingServiceName("tracing-test"),
+		tracing.WithTracingExporter(tracing.ExporterFile),
+		tracing.WithTracingFile(file),
+	)
+	if err := component.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 下游服务记录收到的traceparent
+	var downstreamHeader string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamHeader = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+	client := tracing.InstrumentResty(resty.New())
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(tracing.Middleware(tracing.WithMiddlewareSkipPaths("/livez")))
+	var logTraceID string
+	engine.GET("/orders/:id", func(c *gin.Context) {
+		for _, field := range logger.ContextFields(c.Request.Context()) {
+			if field.Key == "trace_id" {
+				logTraceID = field.String
+			}
+		}
+		if _, err := client.R().SetContext(c.Request.Context()).Get(downstream.URL + "/stock?token=secret"); err != nil {
+			t.Error(err)
+		}
+		c.Status(http.StatusInternalServerError)
+	})
+	engine.GET("/livez", func(c *gin.Context) {
+		if tracing.TraceID(c.Request.Context()) != "" {
+			t.Error("skipped path should not be traced")
+		}
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	engine.ServeHTTP(w, req)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	if got := w.Header().Get("X-Trace-ID"); got != traceID {
+		t.Errorf("X-Trace-ID = %q, want %q", got, traceID)
+	}
+	if logTraceID != traceID {
+		t.Errorf("log trace_id = %q, want %q", logTraceID, traceID)
+	}
+	if !strings.HasPrefix(downstreamHeader, "00-"+traceID+"-") || strings.Contains(downstreamHeader, parentID) {
+		t.Errorf("downstream traceparent = %q", downstreamHeader)
+	}
+
+	// 停止时导出剩余的span
+	if err := component.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fields := logger.ContextFields(context.Background()); len(fields) != 0 {
+		t.Errorf("context fields after stop = %v", fields)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	type spanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	type exportedSpan struct {
+		Name        string
+		SpanContext spanContext
+		Parent      spanContext
+		Attributes  []struct {
+			Key   string
+			Value struct{ Value any }
+		}
+	}
+	spans := make(map[string]exportedSpan)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var span exportedSpan
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatal(err)
+		}
+		if span.SpanContext.TraceID != traceID {
+			t.Errorf("span %s trace id = %s", span.Name, span.SpanContext.TraceID)
+		}
+		spans[span.Name] = span
+	}
+	if len(spans) != 2 {
+		t.Fatalf("spans = %v, want server and client span", spans)
+	}
+	server, ok := spans["GET /orders/:id"]
+	if !ok || server.Parent.SpanID != parentID {
+		t.Errorf("server span = %+v", server)
+	}
+	outgoing, ok := spans["HTTP GET"]
+	if !ok {
+		t.Fatal("missing client span")
+	}
+	for _, attr := range outgoing.Attributes {
+		if attr.Key == "url.full" && strings.Contains(attr.Value.Value.(string), "secret") {
+			t.Errorf("url.full should not contain query: %v", attr.Value.Value)
+		}
+	}
+}
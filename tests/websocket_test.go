@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boloc/go-frame-server/pkg/frame/components"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// wsHub 测试用WebSocket组件，记录已建立的连接
+type wsHub struct {
+	*components.WebSocketComponent
+	server  *httptest.Server
+	mu      sync.Mutex
+	clients map[string]*components.WebSocketClient
+}
+
+// newWSHub 创建并启动WebSocket组件，用户标识取自查询参数user，只允许订阅public:前缀的房间
+func newWSHub(t *testing.T, opts ...components.WebSocketOption) *wsHub {
+	t.Helper()
+	hub := &wsHub{clients: make(map[string]*components.WebSocketClient)}
+	opts = append([]components.WebSocketOption{
+		components.WithWebSocketAuthenticator(func(c *gin.Context) (string, error) {
+			return c.Query("user"), nil
+		}),
+		components.WithWebSocketRoomAuthorizer(func(_ *components.WebSocketClient, room string) bool {
+			return strings.HasPrefix(room, "public:")
+		}),
+		components.WithWebSocketOnConnect(func(client *components.WebSocketClient) {
+			hub.mu.Lock()
+			hub.clients[client.Identity] = client
+			hub.mu.Unlock()
+		}),
+	}, opts...)
+	hub.WebSocketComponent = components.NewWebSocketComponent(opts...)
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/ws", hub.Handler())
+	hub.server = httptest.NewServer(engine)
+	t.Cleanup(hub.server.Close)
+	return hub
+}
+
+// dial 建立连接并等待组件登记
+func (h *wsHub) dial(t *testing.T, user string) (*websocket.Conn, *components.WebSocketClient) {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(h.server.URL, "http")+"/ws?user="+user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	var client *components.WebSocketClient
+	waitUntil(t, func() bool {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		client = h.clients[user]
+		return client != nil
+	})
+	return conn, client
+}
+
+// waitUntil 等待条件成立
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// readText 读取一条消息
+func readText(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// 测试WebSocket房间: 订阅授权、加入离开、房间广播、全体广播、按用户发送和停止时的关闭码
+// go test -v -run TestWebSocketHub ./tests/websocket_test.go
+func TestWebSocketHub(t *testing.T) {
+	hub := newWSHub(t)
+	ctx := context.Background()
+	alice, aliceClient := hub.dial(t, "alice")
+	bob, _ := hub.dial(t, "bob")
+	if hub.Count() != 2 {
+		t.Fatalf("count = %d, want 2", hub.Count())
+	}
+
+	// 未授权的房间订阅被忽略
+	for _, room := range []string{"private:admin", "public:news"} {
+		if err := alice.WriteJSON(map[string]string{"action": "subscribe", "room": room}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitUntil(t, func() bool { return len(aliceClient.Rooms()) == 1 })
+	if rooms := aliceClient.Rooms(); rooms[0] != "public:news" {
+		t.Fatalf("rooms = %v", rooms)
+	}
+	_ = hub.Broadcast(ctx, "private:admin", []byte("secret"))
+	_ = hub.Broadcast(ctx, "public:news", []byte("news"))
+	if got := readText(t, alice); got != "news" {
+		t.Errorf("alice received %q, want news", got)
+	}
+
+	// 离开房间后只收到全体广播
+	if err := alice.WriteJSON(map[string]string{"action": "unsubscribe", "room": "public:news"}); err != nil {
+		t.Fatal(err)
+	}
+	waitUntil(t, func() bool { return len(aliceClient.Rooms()) == 0 })
+	_ = hub.Broadcast(ctx, "public:news", []byte("news"))
+	_ = hub.Broadcast(ctx, "", []byte("all"))
+	_ = hub.SendToUser(ctx, "bob", []byte("to-bob"))
+	if got := readText(t, alice); got != "all" {
+		t.Errorf("alice received %q, want all", got)
+	}
+	if got := readText(t, bob); got != "all" {
+		t.Errorf("bob received %q, want all", got)
+	}
+	if got := readText(t, bob); got != "to-bob" {
+		t.Errorf("bob received %q, want to-bob", got)
+	}
+
+	// 停止时发送1001关闭帧
+	if err := hub.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_ = alice.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := alice.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("close error = %v, want 1001", err)
+	}
+	if hub.Count() != 0 {
+		t.Errorf("count after stop = %d", hub.Count())
+	}
+}
+
+// 测试WebSocket跨实例广播: 两个实例通过Redis发布订阅互相投递
+// go test -v -run TestWebSocketRedisFanout ./tests/websocket_test.go
+func TestWebSocketRedisFanout(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisComponent := components.NewRedisComponent(components.WithRedisAddr(server.Addr()))
+	ctx := context.Background()
+	if err := redisComponent.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer redisComponent.Stop(ctx)
+
+	nodeA := newWSHub(t, components.WithWebSocketRedis(redisComponent, "test:websocket"))
+	defer nodeA.Stop(ctx)
+	nodeB := newWSHub(t, components.WithWebSocketRedis(redisComponent, "test:websocket"))
+	defer nodeB.Stop(ctx)
+
+	conn, client := nodeB.dial(t, "carol")
+	nodeB.Join(client, "public:orders")
+	if err := nodeA.Broadcast(ctx, "public:orders", []byte("from-a")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, conn); got != "from-a" {
+		t.Errorf("received %q, want from-a", got)
+	}
+	if err := nodeA.SendToUser(ctx, "carol", []byte("to-carol")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, conn); got != "to-carol" {
+		t.Errorf("received %q, want to-carol", got)
+	}
+}